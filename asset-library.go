@@ -14,6 +14,8 @@ import (
 
 type AnyAssetLibrary interface {
 	LoadAll()
+	LoadNext() bool
+	Pending() int
 	Unload(path string)
 	UnloadAll()
 }
@@ -66,6 +68,24 @@ func (r *AssetLibrary[T]) LoadAll() {
 	r.loaderQueue = r.loaderQueue[:0]
 }
 
+// LoadNext loads one requested asset, false if there was none
+func (r *AssetLibrary[T]) LoadNext() bool {
+	if len(r.loaderQueue) == 0 {
+		return false
+	}
+
+	last := len(r.loaderQueue) - 1
+	path := r.loaderQueue[last]
+	*r.data[path] = r.loader(path)
+	r.loaderQueue = r.loaderQueue[:last]
+	return true
+}
+
+// Pending is the number of requested assets not loaded yet
+func (r *AssetLibrary[T]) Pending() int {
+	return len(r.loaderQueue)
+}
+
 func (r *AssetLibrary[T]) Unload(path string) {
 	value, ok := r.data[path]
 	assert.True(ok, fmt.Errorf("asset not loaded: %s", path))
@@ -0,0 +1,277 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+)
+
+const (
+	codecImport = "gomp/pkg/codec"
+	ecsImport   = "gomp/pkg/ecs"
+
+	defaultQuantBits = 16
+	maxQuantBits     = 32
+)
+
+// generate returns source of Append<Type> and Read<Type> functions of the types and init registering them
+func generate(dir string, typeNames []string, output string) ([]byte, error) {
+	l, pkg, err := newLoader(dir, output)
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	var registrations []string
+	for _, name := range typeNames {
+		name = strings.TrimSpace(name)
+		decl, ok := pkg.types[name]
+		if !ok {
+			return nil, fmt.Errorf("type %s not found in package %s", name, pkg.name)
+		}
+
+		typ, err := l.resolveDecl(decl)
+		if err != nil {
+			return nil, fmt.Errorf("%w; unsupported fields are skipped with `gomp:\"-\"` tag", err)
+		}
+		if typ.kind != kindStruct {
+			return nil, fmt.Errorf("type %s is not a struct", name)
+		}
+
+		if err = generateType(&body, name, typ); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		registrations = append(registrations, fmt.Sprintf("ecs.RegisterCodec(Append%s, Read%s)", name, name))
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by gompcodec. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&src, "package %s\n\n", pkg.name)
+	fmt.Fprintf(&src, "import (\n%q\n%q\n)\n\n", codecImport, ecsImport)
+	fmt.Fprintf(&src, "func init() {\n%s\n}\n", strings.Join(registrations, "\n"))
+	src.Write(body.Bytes())
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated code is invalid: %w\n%s", err, src.Bytes())
+	}
+	return formatted, nil
+}
+
+func generateType(w *bytes.Buffer, name string, typ *fieldType) error {
+	e := emitter{}
+	if err := e.emit(typ, "c", tagOptions{}); err != nil {
+		return err
+	}
+	e.closeBits()
+
+	fmt.Fprintf(w, "\nfunc Append%s(dst []byte, c *%s) []byte {\n", name, name)
+	if e.usesBits {
+		fmt.Fprintf(w, "var bits codec.BitWriter\n")
+	}
+	w.WriteString(e.enc.String())
+	fmt.Fprintf(w, "return dst\n}\n")
+
+	fmt.Fprintf(w, "\nfunc Read%s(data []byte, c *%s) ([]byte, error) {\n", name, name)
+	if e.dec.Len() > 0 {
+		fmt.Fprintf(w, "var err error\n")
+	}
+	if e.usesBits {
+		fmt.Fprintf(w, "var bits codec.BitReader\n")
+	}
+	w.WriteString(e.dec.String())
+	fmt.Fprintf(w, "return data, nil\n}\n")
+	return nil
+}
+
+// emitter writes encoding and decoding statements of fields in the same order.
+// Consecutive bit fields share bytes, any other field or a loop closes the bit group.
+type emitter struct {
+	enc, dec strings.Builder
+	inBits   bool
+	usesBits bool
+	depth    int
+}
+
+const readCheck = "; err != nil {\nreturn data, err\n}\n"
+
+func (e *emitter) emit(typ *fieldType, path string, tag tagOptions) error {
+	switch typ.kind {
+	case kindStruct:
+		if tag.bits != 0 || tag.quant {
+			return fmt.Errorf("%s: bits and quant apply to numeric fields only", path)
+		}
+		for _, f := range typ.fields {
+			if err := e.emit(f.typ, path+"."+f.name, f.tag); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case kindArray:
+		e.closeBits()
+		index := e.loopVar()
+		fmt.Fprintf(&e.enc, "for %s := range %s {\n", index, path)
+		fmt.Fprintf(&e.dec, "for %s := range %s {\n", index, path)
+		if err := e.loopBody(typ.elem, path+"["+index+"]", tag); err != nil {
+			return err
+		}
+		e.enc.WriteString("}\n")
+		e.dec.WriteString("}\n")
+		return nil
+
+	case kindSlice:
+		e.closeBits()
+		if typ.elem.kind == kindBasic && (typ.elem.basic == "byte" || typ.elem.basic == "uint8") && tag.bits == 0 {
+			fmt.Fprintf(&e.enc, "dst = codec.AppendBytes(dst, %s)\n", path)
+			fmt.Fprintf(&e.dec, "if data, err = codec.ReadBytes(data, &%s)%s", path, readCheck)
+			return nil
+		}
+
+		index := e.loopVar()
+		length := "n" + index[1:]
+		fmt.Fprintf(&e.enc, "dst = codec.AppendLen(dst, len(%s))\n", path)
+		fmt.Fprintf(&e.enc, "for %s := range %s {\n", index, path)
+		fmt.Fprintf(&e.dec, "{\nvar %s int\n", length)
+		fmt.Fprintf(&e.dec, "if data, err = codec.ReadLen(data, &%s)%s", length, readCheck)
+		fmt.Fprintf(&e.dec, "%s = codec.Resize(%s, %s)\n", path, path, length)
+		fmt.Fprintf(&e.dec, "for %s := range %s {\n", index, path)
+		if err := e.loopBody(typ.elem, path+"["+index+"]", tag); err != nil {
+			return err
+		}
+		e.enc.WriteString("}\n")
+		e.dec.WriteString("}\n}\n")
+		return nil
+
+	default:
+		return e.emitBasic(typ.basic, path, tag)
+	}
+}
+
+func (e *emitter) loopBody(elem *fieldType, path string, tag tagOptions) error {
+	e.depth++
+	defer func() { e.depth-- }()
+
+	if err := e.emit(elem, path, tag); err != nil {
+		return err
+	}
+	e.closeBits()
+	return nil
+}
+
+func (e *emitter) loopVar() string {
+	return "i" + strconv.Itoa(e.depth)
+}
+
+func (e *emitter) emitBasic(basic, path string, tag tagOptions) error {
+	width := basicWidths[basic]
+	signed := strings.HasPrefix(basic, "int") || basic == "rune"
+	unsigned := strings.HasPrefix(basic, "uint") || basic == "byte"
+	float := strings.HasPrefix(basic, "float")
+
+	switch {
+	case tag.quant:
+		if !float {
+			return fmt.Errorf("%s: quant applies to float fields only", path)
+		}
+		bits := tag.bits
+		if bits == 0 {
+			bits = defaultQuantBits
+		}
+		if bits > maxQuantBits {
+			return fmt.Errorf("%s: quantized field takes at most %d bits", path, maxQuantBits)
+		}
+		args := fmt.Sprintf("%s, %s, %d", formatFloat(tag.min), formatFloat(tag.max), bits)
+		e.openBits()
+		fmt.Fprintf(&e.enc, "dst = codec.AppendBitsQuant(dst, &bits, %s, %s)\n", path, args)
+		fmt.Fprintf(&e.dec, "if err = codec.ReadBitsQuant(&bits, &%s, %s)%s", path, args, readCheck)
+		return nil
+
+	case basic == "bool":
+		e.openBits()
+		fmt.Fprintf(&e.enc, "dst = codec.AppendBitsBool(dst, &bits, %s)\n", path)
+		fmt.Fprintf(&e.dec, "if err = codec.ReadBitsBool(&bits, &%s)%s", path, readCheck)
+		return nil
+
+	case tag.bits != 0:
+		if !signed && !unsigned {
+			return fmt.Errorf("%s: bits apply to integer fields, floats need quant", path)
+		}
+		if tag.bits > width {
+			return fmt.Errorf("%s: %d bits do not fit %s", path, tag.bits, basic)
+		}
+		function := "Uint"
+		if signed {
+			function = "Int"
+		}
+		e.openBits()
+		fmt.Fprintf(&e.enc, "dst = codec.AppendBits%s(dst, &bits, %s, %d)\n", function, path, tag.bits)
+		fmt.Fprintf(&e.dec, "if err = codec.ReadBits%s(&bits, &%s, %d)%s", function, path, tag.bits, readCheck)
+		return nil
+	}
+
+	var function string
+	switch {
+	case width == 8:
+		function = "Uint8"
+	case signed:
+		function = "Varint"
+	case unsigned:
+		function = "Uvarint"
+	case basic == "float32":
+		function = "Float32"
+	case basic == "float64":
+		function = "Float64"
+	case basic == "string":
+		function = "String"
+	default:
+		return fmt.Errorf("%s: unsupported type %s", path, basic)
+	}
+
+	e.closeBits()
+	fmt.Fprintf(&e.enc, "dst = codec.Append%s(dst, %s)\n", function, path)
+	fmt.Fprintf(&e.dec, "if data, err = codec.Read%s(data, &%s)%s", function, path, readCheck)
+	return nil
+}
+
+func (e *emitter) openBits() {
+	if e.inBits {
+		return
+	}
+	e.inBits = true
+	e.usesBits = true
+	e.dec.WriteString("bits.Reset(data)\n")
+}
+
+func (e *emitter) closeBits() {
+	if !e.inBits {
+		return
+	}
+	e.inBits = false
+	e.enc.WriteString("dst = bits.Flush(dst)\n")
+	e.dec.WriteString("data = bits.Rest()\n")
+}
+
+func formatFloat(v float64) string {
+	s := strconv.FormatFloat(v, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eE") {
+		s += ".0"
+	}
+	return s
+}
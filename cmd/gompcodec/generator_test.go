@@ -0,0 +1,52 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sampleDir = "../../pkg/codec/internal/codectest"
+
+// Generated code is tested in codectest, here it must be up to date
+func TestGenerateUpToDate(t *testing.T) {
+	src, err := generate(sampleDir, []string{"Sample", "Slot"}, "codec_gen.go")
+	require.NoError(t, err)
+
+	committed, err := os.ReadFile(filepath.Join(sampleDir, "codec_gen.go"))
+	require.NoError(t, err)
+	require.Equal(t, string(committed), string(src), "run go generate ./pkg/codec/...")
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		err    string
+	}{
+		{name: "pointer", source: "type C struct { P *int }", err: "field P: unsupported type"},
+		{name: "float bits", source: "type C struct { F float32 `gomp:\"bits=8\"` }", err: "floats need quant"},
+		{name: "too many bits", source: "type C struct { F uint8 `gomp:\"bits=9\"` }", err: "9 bits do not fit uint8"},
+		{name: "quant int", source: "type C struct { F int `gomp:\"quant=0:1\"` }", err: "quant applies to float fields only"},
+		{name: "bad tag", source: "type C struct { F int `gomp:\"fast\"` }", err: "unknown gomp tag option"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example\n"), 0o644))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "c.go"), []byte("package c\n"+tt.source+"\n"), 0o644))
+
+			_, err := generate(dir, []string{"C"}, "codec_gen.go")
+			require.ErrorContains(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,375 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Types are resolved from syntax only, without type checking, so packages using cgo are fine.
+// Packages of the same module are parsed on demand, other packages are limited to knownTypes.
+
+var knownTypes = map[string]string{
+	"time.Duration": "int64",
+}
+
+var basicWidths = map[string]uint{
+	"bool": 1, "byte": 8, "rune": 32,
+	"int": 64, "int8": 8, "int16": 16, "int32": 32, "int64": 64,
+	"uint": 64, "uint8": 8, "uint16": 16, "uint32": 32, "uint64": 64, "uintptr": 64,
+	"float32": 32, "float64": 64,
+	"string": 0,
+}
+
+type typeKind uint8
+
+const (
+	kindBasic typeKind = iota
+	kindStruct
+	kindArray
+	kindSlice
+)
+
+type fieldType struct {
+	kind   typeKind
+	basic  string  // Underlying basic type of kindBasic
+	fields []field // Fields of kindStruct
+	elem   *fieldType
+}
+
+type field struct {
+	name string
+	typ  *fieldType
+	tag  tagOptions
+}
+
+type tagOptions struct {
+	skip     bool
+	bits     uint
+	quant    bool
+	min, max float64
+}
+
+type packageInfo struct {
+	path  string
+	name  string
+	types map[string]*typeDecl
+}
+
+type typeDecl struct {
+	pkg     *packageInfo
+	name    string
+	expr    ast.Expr
+	imports map[string]string // Import names of the declaring file
+}
+
+type loader struct {
+	fset       *token.FileSet
+	modulePath string
+	moduleDir  string
+	skipFile   string
+	root       *packageInfo // Package the code is generated for
+	packages   map[string]*packageInfo
+	resolving  map[*typeDecl]bool
+}
+
+func newLoader(dir, skipFile string) (*loader, *packageInfo, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	l := &loader{
+		fset:      token.NewFileSet(),
+		skipFile:  skipFile,
+		packages:  make(map[string]*packageInfo),
+		resolving: make(map[*typeDecl]bool),
+	}
+
+	l.moduleDir, l.modulePath, err = findModule(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rel, err := filepath.Rel(l.moduleDir, dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	importPath := path.Join(l.modulePath, filepath.ToSlash(rel))
+
+	l.root, err = l.load(importPath)
+	return l, l.root, err
+}
+
+func findModule(dir string) (moduleDir, modulePath string, err error) {
+	for moduleDir = dir; ; moduleDir = filepath.Dir(moduleDir) {
+		file, err := os.Open(filepath.Join(moduleDir, "go.mod"))
+		if err == nil {
+			defer file.Close()
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				if name, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+					return moduleDir, strings.Trim(strings.TrimSpace(name), `"`), nil
+				}
+			}
+			return "", "", fmt.Errorf("no module directive in %s", file.Name())
+		}
+		if filepath.Dir(moduleDir) == moduleDir {
+			return "", "", errors.New("go.mod not found")
+		}
+	}
+}
+
+func (l *loader) load(importPath string) (*packageInfo, error) {
+	if pkg, ok := l.packages[importPath]; ok {
+		return pkg, nil
+	}
+
+	rel, ok := strings.CutPrefix(importPath, l.modulePath)
+	if !ok || rel != "" && rel[0] != '/' {
+		return nil, fmt.Errorf("package %s is outside of module %s", importPath, l.modulePath)
+	}
+	dir := filepath.Join(l.moduleDir, filepath.FromSlash(rel))
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &packageInfo{path: importPath, types: make(map[string]*typeDecl)}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") || name == l.skipFile {
+			continue
+		}
+		if match, err := build.Default.MatchFile(dir, name); err != nil || !match {
+			continue
+		}
+
+		file, err := parser.ParseFile(l.fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		pkg.name = file.Name.Name
+
+		imports := make(map[string]string, len(file.Imports))
+		for _, spec := range file.Imports {
+			importPath, _ := strconv.Unquote(spec.Path.Value)
+			name := path.Base(importPath)
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			imports[name] = importPath
+		}
+
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				spec := spec.(*ast.TypeSpec)
+				if spec.TypeParams != nil {
+					continue
+				}
+				pkg.types[spec.Name.Name] = &typeDecl{pkg: pkg, name: spec.Name.Name, expr: spec.Type, imports: imports}
+			}
+		}
+	}
+
+	l.packages[importPath] = pkg
+	return pkg, nil
+}
+
+// resolve returns wire layout of a type expression used in the scope of decl
+func (l *loader) resolve(expr ast.Expr, scope *typeDecl) (*fieldType, error) {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		if decl, ok := scope.pkg.types[expr.Name]; ok {
+			return l.resolveDecl(decl)
+		}
+		if _, ok := basicWidths[expr.Name]; ok {
+			return &fieldType{kind: kindBasic, basic: expr.Name}, nil
+		}
+		return nil, fmt.Errorf("unsupported type %s", expr.Name)
+
+	case *ast.SelectorExpr:
+		pkgName, ok := expr.X.(*ast.Ident)
+		if !ok {
+			return nil, fmt.Errorf("unsupported type %s", l.source(expr))
+		}
+		qualified := pkgName.Name + "." + expr.Sel.Name
+		if basic, ok := knownTypes[qualified]; ok {
+			return &fieldType{kind: kindBasic, basic: basic}, nil
+		}
+
+		importPath, ok := scope.imports[pkgName.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown package of %s", qualified)
+		}
+		pkg, err := l.load(importPath)
+		if err != nil {
+			return nil, fmt.Errorf("type %s: %w", qualified, err)
+		}
+		decl, ok := pkg.types[expr.Sel.Name]
+		if !ok || !ast.IsExported(expr.Sel.Name) {
+			return nil, fmt.Errorf("type %s not found", qualified)
+		}
+		return l.resolveDecl(decl)
+
+	case *ast.ParenExpr:
+		return l.resolve(expr.X, scope)
+
+	case *ast.ArrayType:
+		elem, err := l.resolve(expr.Elt, scope)
+		if err != nil {
+			return nil, err
+		}
+		if expr.Len == nil {
+			return &fieldType{kind: kindSlice, elem: elem}, nil
+		}
+		return &fieldType{kind: kindArray, elem: elem}, nil
+
+	case *ast.StructType:
+		result := &fieldType{kind: kindStruct}
+		for _, f := range expr.Fields.List {
+			tag, err := parseTag(f.Tag)
+			if err != nil {
+				return nil, err
+			}
+			if tag.skip {
+				continue
+			}
+
+			names := f.Names
+			if len(names) == 0 {
+				names = []*ast.Ident{embeddedName(f.Type)}
+			}
+
+			typ, err := l.resolve(f.Type, scope)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", names[0], err)
+			}
+			for _, name := range names {
+				if name == nil || name.Name == "_" {
+					continue
+				}
+				if !ast.IsExported(name.Name) && scope.pkg != l.root {
+					return nil, fmt.Errorf("unexported field %s of %s.%s", name.Name, scope.pkg.name, scope.name)
+				}
+				result.fields = append(result.fields, field{name: name.Name, typ: typ, tag: tag})
+			}
+		}
+		return result, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported type %s", l.source(expr))
+	}
+}
+
+func (l *loader) resolveDecl(decl *typeDecl) (*fieldType, error) {
+	if l.resolving[decl] {
+		return nil, fmt.Errorf("recursive type %s", decl.name)
+	}
+	l.resolving[decl] = true
+	defer delete(l.resolving, decl)
+
+	typ, err := l.resolve(decl.expr, decl)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", decl.name, err)
+	}
+	return typ, nil
+}
+
+func (l *loader) source(expr ast.Expr) string {
+	pos := l.fset.Position(expr.Pos())
+	return fmt.Sprintf("%T at %s", expr, pos)
+}
+
+func embeddedName(expr ast.Expr) *ast.Ident {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr
+	case *ast.SelectorExpr:
+		return expr.Sel
+	default:
+		return nil
+	}
+}
+
+// parseTag parses `gomp:"-"` or `gomp:"quant=min:max,bits=n"`
+func parseTag(lit *ast.BasicLit) (tagOptions, error) {
+	var opts tagOptions
+	if lit == nil {
+		return opts, nil
+	}
+
+	raw, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return opts, err
+	}
+	value, ok := reflect.StructTag(raw).Lookup("gomp")
+	if !ok {
+		return opts, nil
+	}
+	if value == "-" {
+		opts.skip = true
+		return opts, nil
+	}
+
+	for _, option := range strings.Split(value, ",") {
+		key, arg, _ := strings.Cut(strings.TrimSpace(option), "=")
+		switch key {
+		case "bits":
+			bits, err := strconv.ParseUint(arg, 10, 8)
+			if err != nil || bits == 0 || bits > 64 {
+				return opts, fmt.Errorf("invalid gomp tag %q: bits must be 1..64", value)
+			}
+			opts.bits = uint(bits)
+		case "quant":
+			minArg, maxArg, ok := strings.Cut(arg, ":")
+			if !ok {
+				return opts, fmt.Errorf("invalid gomp tag %q: quant=min:max expected", value)
+			}
+			opts.min, err = strconv.ParseFloat(minArg, 64)
+			if err != nil {
+				return opts, fmt.Errorf("invalid gomp tag %q: %w", value, err)
+			}
+			opts.max, err = strconv.ParseFloat(maxArg, 64)
+			if err != nil {
+				return opts, fmt.Errorf("invalid gomp tag %q: %w", value, err)
+			}
+			if opts.min >= opts.max {
+				return opts, fmt.Errorf("invalid gomp tag %q: min must be less than max", value)
+			}
+			opts.quant = true
+		default:
+			return opts, fmt.Errorf("unknown gomp tag option %q", key)
+		}
+	}
+	return opts, nil
+}
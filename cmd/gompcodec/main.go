@@ -0,0 +1,65 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+// Gompcodec generates binary codecs of components and registers them with ecs.RegisterCodec,
+// so every ecs.ComponentManager of the types encodes patches without hand written encoders.
+//
+// Usage in a component package:
+//
+//	//go:generate go run gomp/cmd/gompcodec -type=Position,Rotation
+//
+// Fields are annotated with the gomp tag:
+//
+//	Angle  float32 `gomp:"quant=0:6.2832,bits=12"` // quantized to 12 bits in the range
+//	Frame  uint8   `gomp:"bits=5"`                 // packed with neighbour bit fields
+//	Cache  []byte  `gomp:"-"`                      // not encoded
+//
+// Bool fields are always packed as single bits.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	typeNames := flag.String("type", "", "comma separated list of component types; required")
+	output := flag.String("output", "codec_gen.go", "output file name")
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("gompcodec: ")
+
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	src, err := generate(dir, strings.Split(*typeNames, ","), *output)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err = os.WriteFile(filepath.Join(dir, *output), src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,141 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package gomp
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type EnginePhase uint8
+
+const (
+	PhaseFrame       EnginePhase = iota // Whole frame, without waiting for it
+	PhaseUpdate                         // Game.Update
+	PhaseFixedUpdate                    // Every Game.FixedUpdate of the frame, hooks run per tick, timing is per frame
+	PhaseRender                         // Game.Render
+	EnginePhaseCount
+)
+
+func (p EnginePhase) String() string {
+	switch p {
+	case PhaseFrame:
+		return "frame"
+	case PhaseUpdate:
+		return "update"
+	case PhaseFixedUpdate:
+		return "fixed_update"
+	case PhaseRender:
+		return "render"
+	}
+	return "unknown"
+}
+
+func NewEngineMetrics() *EngineMetrics {
+	return &EngineMetrics{}
+}
+
+// EngineMetrics collects timing histograms of Engine.Run phases, safe to read from other goroutines.
+// It is an http.Handler serving a JSON snapshot, mount it on the debug server mux.
+type EngineMetrics struct {
+	mx           sync.Mutex
+	phases       [EnginePhaseCount]Histogram
+	frames       uint64
+	fixedUpdates uint64
+	frameSkips   uint64
+	droppedTime  time.Duration
+}
+
+type EngineMetricsSnapshot struct {
+	Frames       uint64
+	FixedUpdates uint64
+	FrameSkips   uint64        // Frames that hit MaxFrameSkips with time left to simulate
+	DroppedTime  time.Duration // Game time discarded by SpiralDrop
+	Phases       map[string]HistogramSnapshot
+}
+
+// frameTiming is what a frame adds to the metrics, recorded at once to lock once per frame
+type frameTiming struct {
+	phases       [EnginePhaseCount]time.Duration
+	fixedUpdates int
+	skipped      bool
+	dropped      time.Duration
+}
+
+func (m *EngineMetrics) record(frame *frameTiming) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	m.frames++
+	m.fixedUpdates += uint64(frame.fixedUpdates)
+	if frame.skipped {
+		m.frameSkips++
+	}
+	m.droppedTime += frame.dropped
+
+	m.phases[PhaseFrame].Observe(frame.phases[PhaseFrame])
+	m.phases[PhaseUpdate].Observe(frame.phases[PhaseUpdate])
+	// Frames without ticks or drawing would only pile up zeros
+	if frame.fixedUpdates > 0 {
+		m.phases[PhaseFixedUpdate].Observe(frame.phases[PhaseFixedUpdate])
+	}
+	if !Headless {
+		m.phases[PhaseRender].Observe(frame.phases[PhaseRender])
+	}
+}
+
+// Phase returns a copy of the histogram of phase
+func (m *EngineMetrics) Phase(phase EnginePhase) Histogram {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return m.phases[phase]
+}
+
+func (m *EngineMetrics) Snapshot() EngineMetricsSnapshot {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	snapshot := EngineMetricsSnapshot{
+		Frames:       m.frames,
+		FixedUpdates: m.fixedUpdates,
+		FrameSkips:   m.frameSkips,
+		DroppedTime:  m.droppedTime,
+		Phases:       make(map[string]HistogramSnapshot, len(m.phases)),
+	}
+	for phase := range m.phases {
+		snapshot.Phases[EnginePhase(phase).String()] = m.phases[phase].Snapshot()
+	}
+	return snapshot
+}
+
+func (m *EngineMetrics) Reset() {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	m.phases = [EnginePhaseCount]Histogram{}
+	m.frames = 0
+	m.fixedUpdates = 0
+	m.frameSkips = 0
+	m.droppedTime = 0
+}
+
+func (m *EngineMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(m.Snapshot()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -16,7 +16,7 @@ Thank you for your support!
 package gomp
 
 import (
-	"log"
+	"github.com/negrel/assert"
 	"time"
 )
 
@@ -24,58 +24,196 @@ const (
 	MaxFrameSkips = 5
 )
 
+// SpiralPolicy decides what happens to game time left to simulate after MaxFrameSkips fixed updates
+type SpiralPolicy uint8
+
+const (
+	// SpiralCatchUp keeps the time, it is simulated in the next frames
+	SpiralCatchUp SpiralPolicy = iota
+	// SpiralDrop discards whole fixed steps of it, the game slows down instead of falling further behind
+	SpiralDrop
+)
+
+type EngineConfig struct {
+	Tickrate      uint // Fixed updates per second
+	Framerate     uint // Frames per second, 0 runs as fast as Render presents
+	MaxFrameSkips int  // Fixed updates a frame may run to catch up, MaxFrameSkips if 0
+	VSync         bool // Handed to ConfigurableGame before Init, Game applies it when opening its window only
+	Spiral        SpiralPolicy
+}
+
+func DefaultEngineConfig() EngineConfig {
+	return EngineConfig{
+		Tickrate:      50,
+		MaxFrameSkips: MaxFrameSkips,
+	}
+}
+
 func NewEngine(game AnyGame) Engine {
 	engine := Engine{
-		Game: game,
+		Game:    game,
+		Config:  DefaultEngineConfig(),
+		Metrics: NewEngineMetrics(),
 	}
 
 	return engine
 }
 
+// LockstepTicker gates fixed updates in lockstep mode, network.Lockstep implements it
+type LockstepTicker interface {
+	// Advance reports whether inputs of all peers for the next tick are there, so the tick may be simulated
+	Advance() bool
+}
+
+// InterpolatedGame renders between fixed updates, Game implements it
+type InterpolatedGame interface {
+	// SetInterpolationAlpha is called before Render with the fraction of the fixed step passed since the last fixed update
+	SetInterpolationAlpha(alpha float32)
+}
+
+// TimedGame owns the TimeControl the engine turns real time into game time with, Game implements it
+type TimedGame interface {
+	TimeControl() *TimeControl
+}
+
+// ConfigurableGame applies the parts of EngineConfig it owns, like VSync of its window, Game implements it
+type ConfigurableGame interface {
+	Configure(config EngineConfig)
+}
+
+// EngineHook runs before or after a phase, dt is the one the phase gets
+type EngineHook func(phase EnginePhase, dt time.Duration)
+
 type Engine struct {
-	Game AnyGame
+	Game    AnyGame
+	Config  EngineConfig
+	Metrics *EngineMetrics
+
+	// Lockstep stalls FixedUpdate until inputs of the next tick arrive, fixed updates run on time if nil
+	Lockstep LockstepTicker
+
+	before [EnginePhaseCount][]EngineHook
+	after  [EnginePhaseCount][]EngineHook
+}
+
+func (e *Engine) Before(phase EnginePhase, hook EngineHook) {
+	e.before[phase] = append(e.before[phase], hook)
+}
+
+func (e *Engine) After(phase EnginePhase, hook EngineHook) {
+	e.after[phase] = append(e.after[phase], hook)
 }
 
-func (e *Engine) Run(tickrate uint, framerate uint) {
+func (e *Engine) runHooks(hooks []EngineHook, phase EnginePhase, dt time.Duration) {
+	for _, hook := range hooks {
+		hook(phase, dt)
+	}
+}
 
-	fixedUpdDuration := time.Second / time.Duration(tickrate)
+// Run ticks the game with Config until it should be destroyed. Headless builds run Update and FixedUpdate only.
+// Games implementing TimedGame get dt in game time, paused and scaled by their TimeControl.
+func (e *Engine) Run() {
+	assert.True(e.Config.Tickrate > 0, "Tickrate must be greater than 0")
+
+	fixedUpdDuration := time.Second / time.Duration(e.Config.Tickrate)
+	maxFrameSkips := e.Config.MaxFrameSkips
+	if maxFrameSkips <= 0 {
+		maxFrameSkips = MaxFrameSkips
+	}
 
 	var renderTicker *time.Ticker
-	if framerate > 0 {
-		renderTicker = time.NewTicker(time.Second / time.Duration(framerate))
+	if e.Config.Framerate > 0 {
+		renderTicker = time.NewTicker(time.Second / time.Duration(e.Config.Framerate))
 		defer renderTicker.Stop()
 	}
 
+	if configurable, ok := e.Game.(ConfigurableGame); ok {
+		configurable.Configure(e.Config)
+	}
 	e.Game.Init()
 	defer e.Game.Destroy()
 
-	var lastUpdateAt = time.Now() // TODO: REMOVE?
-	var nextFixedUpdateAt = time.Now()
-	var dt = time.Since(lastUpdateAt)
+	var timeControl *TimeControl
+	if timed, ok := e.Game.(TimedGame); ok {
+		timeControl = timed.TimeControl()
+	}
+	if e.Metrics == nil {
+		e.Metrics = NewEngineMetrics()
+	}
+
+	var lastUpdateAt = time.Now()
+	var accumulated time.Duration // Game time not yet simulated by FixedUpdate
+	var dt time.Duration
+	var timing frameTiming
 
 	for !e.Game.ShouldDestroy() {
 		if renderTicker != nil {
 			<-renderTicker.C
+		} else if Headless {
+			// Nothing to draw, so nothing to do until the next tick
+			time.Sleep(max(fixedUpdDuration-accumulated, time.Millisecond))
+		}
+		frameStartedAt := time.Now()
+		timing = frameTiming{}
+
+		dt = frameStartedAt.Sub(lastUpdateAt)
+		lastUpdateAt = frameStartedAt
+		if timeControl != nil {
+			dt = timeControl.Advance(dt, fixedUpdDuration)
 		}
-		dt = time.Since(lastUpdateAt)
-		lastUpdateAt = time.Now()
+		e.runHooks(e.before[PhaseFrame], PhaseFrame, dt)
 
 		// Update
+		e.runHooks(e.before[PhaseUpdate], PhaseUpdate, dt)
+		phaseStartedAt := time.Now()
 		e.Game.Update(dt)
+		timing.phases[PhaseUpdate] = time.Since(phaseStartedAt)
+		e.runHooks(e.after[PhaseUpdate], PhaseUpdate, dt)
 
 		// Fixed Update
-		loops := 0
-		// TODO: Refactor to work without for loop
-		for nextFixedUpdateAt.Compare(time.Now()) == -1 && loops < MaxFrameSkips {
+		accumulated += dt
+		for accumulated >= fixedUpdDuration && timing.fixedUpdates < maxFrameSkips {
+			if e.Lockstep != nil && !e.Lockstep.Advance() {
+				// Stalled on missing inputs, waiting time is not caught up later
+				accumulated = fixedUpdDuration
+				break
+			}
+			e.runHooks(e.before[PhaseFixedUpdate], PhaseFixedUpdate, fixedUpdDuration)
+			phaseStartedAt = time.Now()
 			e.Game.FixedUpdate(fixedUpdDuration)
-			nextFixedUpdateAt = nextFixedUpdateAt.Add(fixedUpdDuration)
-			loops++
+			timing.phases[PhaseFixedUpdate] += time.Since(phaseStartedAt)
+			e.runHooks(e.after[PhaseFixedUpdate], PhaseFixedUpdate, fixedUpdDuration)
+			accumulated -= fixedUpdDuration
+			timing.fixedUpdates++
 		}
-		if loops >= MaxFrameSkips {
-			log.Println("Too many updates detected")
+		if timing.fixedUpdates >= maxFrameSkips && accumulated >= fixedUpdDuration {
+			timing.skipped = true
+			if e.Config.Spiral == SpiralDrop {
+				timing.dropped = accumulated - accumulated%fixedUpdDuration
+				accumulated -= timing.dropped
+			}
 		}
 
-		// RenderAssterodd
-		e.Game.Render(dt)
+		// Render
+		if !Headless {
+			if interpolated, ok := e.Game.(InterpolatedGame); ok {
+				interpolated.SetInterpolationAlpha(interpolationAlpha(accumulated, fixedUpdDuration))
+			}
+			e.runHooks(e.before[PhaseRender], PhaseRender, dt)
+			phaseStartedAt = time.Now()
+			e.Game.Render(dt)
+			timing.phases[PhaseRender] = time.Since(phaseStartedAt)
+			e.runHooks(e.after[PhaseRender], PhaseRender, dt)
+		}
+
+		timing.phases[PhaseFrame] = time.Since(frameStartedAt)
+		e.Metrics.record(&timing)
+		e.runHooks(e.after[PhaseFrame], PhaseFrame, dt)
 	}
 }
+
+// interpolationAlpha is how far from 0 to 1 we are between the last fixed update and the next one
+func interpolationAlpha(accumulated, fixedUpdDuration time.Duration) float32 {
+	alpha := float32(accumulated) / float32(fixedUpdDuration)
+	return min(max(alpha, 0), 1)
+}
@@ -0,0 +1,121 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package gomp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testGame struct {
+	updates, fixedUpdates, renders int
+	maxFixedUpdates                int
+}
+
+func (g *testGame) Init()                        {}
+func (g *testGame) Update(dt time.Duration)      { g.updates++ }
+func (g *testGame) FixedUpdate(dt time.Duration) { g.fixedUpdates++ }
+func (g *testGame) Render(dt time.Duration)      { g.renders++ }
+func (g *testGame) Destroy()                     {}
+func (g *testGame) ShouldDestroy() bool          { return g.fixedUpdates >= g.maxFixedUpdates }
+
+// stallingLockstep lets every other tick through
+type stallingLockstep struct {
+	calls int
+}
+
+func (l *stallingLockstep) Advance() bool {
+	l.calls++
+	return l.calls%2 == 0
+}
+
+func TestEngineRun(t *testing.T) {
+	game := &testGame{maxFixedUpdates: 5}
+	lockstep := &stallingLockstep{}
+	engine := NewEngine(game)
+	engine.Config.Tickrate = 200
+	engine.Lockstep = lockstep
+	var beforeTicks, afterTicks int
+	engine.Before(PhaseFixedUpdate, func(phase EnginePhase, dt time.Duration) {
+		require.Equal(t, game.fixedUpdates, afterTicks)
+		beforeTicks++
+	})
+	engine.After(PhaseFixedUpdate, func(phase EnginePhase, dt time.Duration) {
+		require.Equal(t, time.Second/200, dt)
+		afterTicks++
+	})
+
+	engine.Run()
+
+	require.Equal(t, 5, game.fixedUpdates)
+	require.Equal(t, 5, beforeTicks)
+	require.Equal(t, 5, afterTicks)
+	require.GreaterOrEqual(t, lockstep.calls, 10)
+
+	metrics := engine.Metrics.Snapshot()
+	require.Equal(t, uint64(game.updates), metrics.Frames)
+	require.Equal(t, uint64(5), metrics.FixedUpdates)
+	require.Equal(t, uint64(game.updates), metrics.Phases["update"].Count)
+	require.NotZero(t, metrics.Phases["fixed_update"].Count)
+	if Headless {
+		require.Zero(t, game.renders)
+	} else {
+		require.Equal(t, game.updates, game.renders)
+	}
+}
+
+// slowGame takes longer to simulate a tick than the tick lasts
+type slowGame struct {
+	testGame
+}
+
+func (g *slowGame) FixedUpdate(dt time.Duration) {
+	time.Sleep(3 * dt)
+	g.fixedUpdates++
+}
+
+func TestEngineSpiralDrop(t *testing.T) {
+	game := &slowGame{testGame{maxFixedUpdates: 5}}
+	engine := NewEngine(game)
+	engine.Config.Tickrate = 1000
+	engine.Config.MaxFrameSkips = 1
+	engine.Config.Spiral = SpiralDrop
+
+	engine.Run()
+
+	metrics := engine.Metrics.Snapshot()
+	require.NotZero(t, metrics.FrameSkips)
+	require.GreaterOrEqual(t, metrics.DroppedTime, time.Millisecond)
+	require.Equal(t, uint64(5), metrics.FixedUpdates)
+}
+
+func TestHistogram(t *testing.T) {
+	var histogram Histogram
+	require.Zero(t, histogram.Quantile(0.5))
+
+	for _, duration := range []time.Duration{50 * time.Microsecond, 100 * time.Microsecond, 100 * time.Microsecond, 10 * time.Second} {
+		histogram.Observe(duration)
+	}
+	snapshot := histogram.Snapshot()
+	require.Equal(t, uint64(4), snapshot.Count)
+	require.Equal(t, 125*time.Microsecond, snapshot.P50)
+	// Durations past the last bound are capped by the max
+	require.Equal(t, 10*time.Second, snapshot.P99)
+	require.Equal(t, uint64(1), snapshot.Buckets[0].Count)
+	require.Equal(t, uint64(2), snapshot.Buckets[1].Count)
+	require.Equal(t, uint64(1), snapshot.Buckets[len(snapshot.Buckets)-1].Count)
+}
+
+func TestInterpolationAlpha(t *testing.T) {
+	step := 10 * time.Millisecond
+	require.Equal(t, float32(0), interpolationAlpha(0, step))
+	require.Equal(t, float32(0.75), interpolationAlpha(step*3/4, step))
+	// Time left over after too many frame skips is clamped
+	require.Equal(t, float32(1), interpolationAlpha(2*step, step))
+}
@@ -8,8 +8,9 @@ package assets
 
 import (
 	"embed"
-	"fmt"
 	"gomp"
+	"gomp/pkg/render"
+	"gomp/pkg/render/rlrender"
 	"image/png"
 	"log"
 	"strings"
@@ -23,10 +24,9 @@ import (
 var fs embed.FS
 
 var Textures = gomp.CreateAssetLibrary(
-	func(path string) rl.Texture2D {
+	func(path string) render.Texture {
 		assert.True(rl.IsWindowReady(), "Window is not initialized")
 
-		fmt.Print()
 		file, err := fs.Open(path)
 		if err != nil {
 			log.Panic("Error opening file")
@@ -38,12 +38,11 @@ var Textures = gomp.CreateAssetLibrary(
 			log.Panic("Error decoding file")
 		}
 
-		rlImg := rl.NewImageFromImage(img)
-		return rl.LoadTextureFromImage(rlImg)
+		return rlrender.Default.LoadTexture(img)
 	},
-	func(path string, asset *rl.Texture2D) {
+	func(path string, asset *render.Texture) {
 		assert.True(rl.IsWindowReady(), "Window is not initialized")
-		rl.UnloadTexture(*asset)
+		rlrender.Default.UnloadTexture(*asset)
 	},
 )
 
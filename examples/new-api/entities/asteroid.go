@@ -15,11 +15,11 @@ Thank you for your support!
 package entities
 
 import (
-	rl "github.com/gen2brain/raylib-go/raylib"
 	"gomp/examples/new-api/assets"
 	"gomp/examples/new-api/components"
 	"gomp/examples/new-api/config"
 	"gomp/pkg/ecs"
+	"gomp/pkg/render"
 	"gomp/stdcomponents"
 	"gomp/vectors"
 	"image/color"
@@ -27,16 +27,17 @@ import (
 )
 
 type CreateAsteroidManagers struct {
-	EntityManager   *ecs.EntityManager
-	Positions       *stdcomponents.PositionComponentManager
-	Rotations       *stdcomponents.RotationComponentManager
-	Scales          *stdcomponents.ScaleComponentManager
-	Velocities      *stdcomponents.VelocityComponentManager
-	CircleColliders *stdcomponents.CircleColliderComponentManager
-	Sprites         *stdcomponents.SpriteComponentManager
-	AsteroidTags    *components.AsteroidComponentManager
-	Hp              *components.HpComponentManager
-	RigidBodies     *stdcomponents.RigidBodyComponentManager
+	EntityManager      *ecs.EntityManager
+	Positions          *stdcomponents.PositionComponentManager
+	Rotations          *stdcomponents.RotationComponentManager
+	PreviousTransforms *stdcomponents.PreviousTransformComponentManager
+	Scales             *stdcomponents.ScaleComponentManager
+	Velocities         *stdcomponents.VelocityComponentManager
+	CircleColliders    *stdcomponents.CircleColliderComponentManager
+	Sprites            *stdcomponents.SpriteComponentManager
+	AsteroidTags       *components.AsteroidComponentManager
+	Hp                 *components.HpComponentManager
+	RigidBodies        *stdcomponents.RigidBodyComponentManager
 }
 
 func CreateAsteroid(
@@ -60,6 +61,8 @@ func CreateAsteroid(
 			Y: 1 * scaleFactor,
 		},
 	})
+	props.PreviousTransforms.Create(e, stdcomponents.PreviousTransform{})
+
 	props.Velocities.Create(e, stdcomponents.Velocity{
 		X: velocityX,
 		Y: velocityY,
@@ -75,13 +78,13 @@ func CreateAsteroid(
 	})
 	props.Sprites.Create(e, stdcomponents.Sprite{
 		Texture: assets.Textures.Get("meteor_large.png"),
-		Frame: rl.Rectangle{
+		Frame: render.Rectangle{
 			X:      0,
 			Y:      0,
 			Width:  64,
 			Height: 64,
 		},
-		Origin: rl.Vector2{
+		Origin: vectors.Vec2{
 			X: 32,
 			Y: 32,
 		},
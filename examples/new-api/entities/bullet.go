@@ -15,27 +15,28 @@ Thank you for your support!
 package entities
 
 import (
-	rl "github.com/gen2brain/raylib-go/raylib"
 	"gomp/examples/new-api/assets"
 	"gomp/examples/new-api/components"
 	"gomp/examples/new-api/config"
 	"gomp/pkg/ecs"
+	"gomp/pkg/render"
 	"gomp/stdcomponents"
 	"gomp/vectors"
 	"image/color"
 )
 
 type CreateBulletManagers struct {
-	EntityManager   *ecs.EntityManager
-	Positions       *stdcomponents.PositionComponentManager
-	Rotations       *stdcomponents.RotationComponentManager
-	Scales          *stdcomponents.ScaleComponentManager
-	Velocities      *stdcomponents.VelocityComponentManager
-	CircleColliders *stdcomponents.CircleColliderComponentManager
-	RigidBodies     *stdcomponents.RigidBodyComponentManager
-	Sprites         *stdcomponents.SpriteComponentManager
-	BulletTags      *components.BulletTagComponentManager
-	Hps             *components.HpComponentManager
+	EntityManager      *ecs.EntityManager
+	Positions          *stdcomponents.PositionComponentManager
+	Rotations          *stdcomponents.RotationComponentManager
+	PreviousTransforms *stdcomponents.PreviousTransformComponentManager
+	Scales             *stdcomponents.ScaleComponentManager
+	Velocities         *stdcomponents.VelocityComponentManager
+	CircleColliders    *stdcomponents.CircleColliderComponentManager
+	RigidBodies        *stdcomponents.RigidBodyComponentManager
+	Sprites            *stdcomponents.SpriteComponentManager
+	BulletTags         *components.BulletTagComponentManager
+	Hps                *components.HpComponentManager
 }
 
 func CreateBullet(
@@ -58,6 +59,8 @@ func CreateBullet(
 			Y: 1,
 		},
 	})
+	props.PreviousTransforms.Create(bullet, stdcomponents.PreviousTransform{})
+
 	props.Velocities.Create(bullet, stdcomponents.Velocity{
 		X: velocityX,
 		Y: velocityY,
@@ -74,13 +77,13 @@ func CreateBullet(
 	})
 	props.Sprites.Create(bullet, stdcomponents.Sprite{
 		Texture: assets.Textures.Get("bullet.png"),
-		Frame: rl.Rectangle{
+		Frame: render.Rectangle{
 			X:      0,
 			Y:      0,
 			Width:  64,
 			Height: 64,
 		},
-		Origin: rl.Vector2{
+		Origin: vectors.Vec2{
 			X: 32,
 			Y: 32,
 		},
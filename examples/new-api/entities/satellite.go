@@ -15,24 +15,25 @@ Thank you for your support!
 package entities
 
 import (
-	rl "github.com/gen2brain/raylib-go/raylib"
 	"gomp/examples/new-api/assets"
 	"gomp/examples/new-api/config"
 	"gomp/pkg/ecs"
+	"gomp/pkg/render"
 	"gomp/stdcomponents"
 	"gomp/vectors"
 	"image/color"
 )
 
 type CreateSatelliteManagers struct {
-	EntityManager *ecs.EntityManager
-	Positions     *stdcomponents.PositionComponentManager
-	Rotations     *stdcomponents.RotationComponentManager
-	Scales        *stdcomponents.ScaleComponentManager
-	Sprites       *stdcomponents.SpriteComponentManager
-	BoxColliders  *stdcomponents.BoxColliderComponentManager
-	RigidBodies   *stdcomponents.RigidBodyComponentManager
-	Velocities    *stdcomponents.VelocityComponentManager
+	EntityManager      *ecs.EntityManager
+	Positions          *stdcomponents.PositionComponentManager
+	Rotations          *stdcomponents.RotationComponentManager
+	PreviousTransforms *stdcomponents.PreviousTransformComponentManager
+	Scales             *stdcomponents.ScaleComponentManager
+	Sprites            *stdcomponents.SpriteComponentManager
+	BoxColliders       *stdcomponents.BoxColliderComponentManager
+	RigidBodies        *stdcomponents.RigidBodyComponentManager
+	Velocities         *stdcomponents.VelocityComponentManager
 }
 
 func CreateSatellite(
@@ -57,6 +58,8 @@ func CreateSatellite(
 		},
 	})
 
+	props.PreviousTransforms.Create(satellite, stdcomponents.PreviousTransform{})
+
 	props.Velocities.Create(satellite, stdcomponents.Velocity{
 		X: 0,
 		Y: 0,
@@ -64,8 +67,8 @@ func CreateSatellite(
 
 	props.Sprites.Create(satellite, stdcomponents.Sprite{
 		Texture: assets.Textures.Get("satellite_B.png"),
-		Origin:  rl.Vector2{X: 32, Y: 40},
-		Frame:   rl.Rectangle{0, 0, 64, 64},
+		Origin:  vectors.Vec2{X: 32, Y: 40},
+		Frame:   render.Rectangle{0, 0, 64, 64},
 		Tint:    color.RGBA{255, 255, 255, 255},
 	})
 
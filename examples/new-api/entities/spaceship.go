@@ -15,11 +15,11 @@ Thank you for your support!
 package entities
 
 import (
-	rl "github.com/gen2brain/raylib-go/raylib"
 	"gomp/examples/new-api/assets"
 	"gomp/examples/new-api/components"
 	"gomp/examples/new-api/config"
 	"gomp/pkg/ecs"
+	"gomp/pkg/render"
 	"gomp/stdcomponents"
 	"gomp/vectors"
 	"image/color"
@@ -27,14 +27,15 @@ import (
 )
 
 type CreateSpaceShipManagers struct {
-	EntityManager *ecs.EntityManager
-	Positions     *stdcomponents.PositionComponentManager
-	Rotations     *stdcomponents.RotationComponentManager
-	Scales        *stdcomponents.ScaleComponentManager
-	Velocities    *stdcomponents.VelocityComponentManager
-	Sprites       *stdcomponents.SpriteComponentManager
-	BoxColliders  *stdcomponents.BoxColliderComponentManager
-	RigidBodies   *stdcomponents.RigidBodyComponentManager
+	EntityManager      *ecs.EntityManager
+	Positions          *stdcomponents.PositionComponentManager
+	Rotations          *stdcomponents.RotationComponentManager
+	PreviousTransforms *stdcomponents.PreviousTransformComponentManager
+	Scales             *stdcomponents.ScaleComponentManager
+	Velocities         *stdcomponents.VelocityComponentManager
+	Sprites            *stdcomponents.SpriteComponentManager
+	BoxColliders       *stdcomponents.BoxColliderComponentManager
+	RigidBodies        *stdcomponents.RigidBodyComponentManager
 
 	PlayerTags       *components.PlayerTagComponentManager
 	Hps              *components.HpComponentManager
@@ -66,6 +67,8 @@ func CreateSpaceShip(
 		},
 	})
 
+	props.PreviousTransforms.Create(spaceShip, stdcomponents.PreviousTransform{})
+
 	props.Velocities.Create(spaceShip, stdcomponents.Velocity{
 		X: 0,
 		Y: 0,
@@ -73,8 +76,8 @@ func CreateSpaceShip(
 
 	props.Sprites.Create(spaceShip, stdcomponents.Sprite{
 		Texture: assets.Textures.Get("ship_E.png"),
-		Origin:  rl.Vector2{X: 32, Y: 40},
-		Frame:   rl.Rectangle{0, 0, 64, 64},
+		Origin:  vectors.Vec2{X: 32, Y: 40},
+		Frame:   render.Rectangle{0, 0, 64, 64},
 		Tint:    color.RGBA{255, 255, 255, 255},
 	})
 
@@ -15,11 +15,11 @@ Thank you for your support!
 package entities
 
 import (
-	rl "github.com/gen2brain/raylib-go/raylib"
 	"gomp/examples/new-api/assets"
 	"gomp/examples/new-api/components"
 	"gomp/examples/new-api/config"
 	"gomp/pkg/ecs"
+	"gomp/pkg/render"
 	"gomp/stdcomponents"
 	"gomp/vectors"
 	"image/color"
@@ -75,13 +75,13 @@ func CreateWall(
 	})
 	props.Sprites.Create(entity, stdcomponents.Sprite{
 		Texture: assets.Textures.Get("wall.png"),
-		Frame: rl.Rectangle{
+		Frame: render.Rectangle{
 			X:      0,
 			Y:      0,
 			Width:  width,
 			Height: height,
 		},
-		Origin: rl.Vector2{
+		Origin: vectors.Vec2{
 			X: 0,
 			Y: 0,
 		},
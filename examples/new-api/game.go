@@ -18,8 +18,12 @@ import (
 	"github.com/hajimehoshi/go-steamworks"
 	"golang.org/x/text/language"
 	"gomp"
+	"gomp/examples/new-api/assets"
 	"gomp/examples/new-api/scenes"
+	"gomp/network"
+	"net/http"
 	"os"
+	"time"
 )
 
 const appID = 12 // Rewrite this
@@ -53,9 +57,19 @@ func main() {
 	game := gomp.NewGame(
 		&sceneList.Main,
 		&sceneList.Assterodd,
+		&sceneList.Loading,
 	)
-	game.CurrentSceneId = scenes.AssteroddSceneId
+	game.CurrentSceneId = scenes.LoadingSceneId
+	game.Assets = []gomp.AnyAssetLibrary{&assets.Textures, &assets.Audio}
+	game.Load(scenes.AssteroddSceneId, scenes.LoadingSceneId, gomp.NewFadeTransition(time.Millisecond*500))
+
+	// Example servers use generated certificates, release clients should pin them with PinnedFingerprints instead
+	clientConfig := network.DevQuicClientConfig()
+	network.Quic.ClientConfig = &clientConfig
 
 	engine := gomp.NewEngine(&game)
-	engine.Run(50, 0)
+	engine.Config.Tickrate = 50
+	// Served by the debug server of DebugSystem
+	http.Handle("/debug/engine", engine.Metrics)
+	engine.Run()
 }
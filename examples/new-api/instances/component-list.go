@@ -44,6 +44,16 @@ type ComponentList struct {
 	SpatialIndex       stdcomponents.SpatialIndexComponentManager
 	RigidBody          stdcomponents.RigidBodyComponentManager
 	BvhTree            stdcomponents.BvhTreeComponentManager
+	Interpolation      stdcomponents.InterpolationComponentManager
+	NetworkPeer        stdcomponents.NetworkPeerComponentManager
+	NetworkObserver    stdcomponents.NetworkObserverComponentManager
+	NetworkPriority    stdcomponents.NetworkPriorityComponentManager
+	NetworkStats       stdcomponents.NetworkStatsOverlayComponentManager
+	Camera             stdcomponents.Camera2DComponentManager
+	RenderLayer        stdcomponents.RenderLayerComponentManager
+	RenderLayers       stdcomponents.RenderLayers
+	PreviousTransform  stdcomponents.PreviousTransformComponentManager
+	TimeGroup          stdcomponents.TimeGroupComponentManager
 
 	Health               components.HpComponentManager
 	Controller           components.ControllerComponentManager
@@ -84,6 +94,16 @@ func NewComponentList() ComponentList {
 		SpatialIndex:       stdcomponents.NewSpatialIndexComponentManager(),
 		RigidBody:          stdcomponents.NewRigidBodyComponentManager(),
 		BvhTree:            stdcomponents.NewBvhTreeComponentManager(),
+		Interpolation:      stdcomponents.NewInterpolationComponentManager(),
+		NetworkPeer:        stdcomponents.NewNetworkPeerComponentManager(),
+		NetworkObserver:    stdcomponents.NewNetworkObserverComponentManager(),
+		NetworkPriority:    stdcomponents.NewNetworkPriorityComponentManager(),
+		NetworkStats:       stdcomponents.NewNetworkStatsOverlayComponentManager(),
+		Camera:             stdcomponents.NewCamera2DComponentManager(),
+		RenderLayer:        stdcomponents.NewRenderLayerComponentManager(),
+		RenderLayers:       stdcomponents.NewRenderLayers(),
+		PreviousTransform:  stdcomponents.NewPreviousTransformComponentManager(),
+		TimeGroup:          stdcomponents.NewTimeGroupComponentManager(),
 
 		Health:               components.NewHealthComponentManager(),
 		Controller:           components.NewControllerComponentManager(),
@@ -29,6 +29,11 @@ func NewSystemList() SystemList {
 		Network:                  stdsystems.NewNetworkSystem(),
 		NetworkReceive:           stdsystems.NewNetworkReceiveSystem(),
 		NetworkSend:              stdsystems.NewNetworkSendSystem(),
+		NetworkInterest:          stdsystems.NewNetworkInterestSystem(),
+		NetworkStats:             stdsystems.NewNetworkStatsSystem(),
+		Camera:                   stdsystems.NewCameraSystem(),
+		TexturePro:               stdsystems.NewTextureProSystem(),
+		PreviousTransform:        stdsystems.NewPreviousTransformSystem(),
 		AnimationSpriteMatrix:    stdsystems.NewAnimationSpriteMatrixSystem(),
 		AnimationPlayer:          stdsystems.NewAnimationPlayerSystem(),
 		TextureRenderSpriteSheet: stdsystems.NewTextureRenderSpriteSheetSystem(),
@@ -40,7 +45,10 @@ func NewSystemList() SystemList {
 		CollisionDetectionBVH:    stdsystems.NewCollisionDetectionBVHSystem(),
 		ColliderSystem:           stdsystems.NewColliderSystem(),
 		CollisionResolution:      stdsystems.NewCollisionResolutionSystem(),
+		Interpolation:            stdsystems.NewInterpolationSystem(),
+		NetworkReplication:       stdsystems.NewNetworkReplicationSystem(),
 
+		Render:          stdsystems.NewRenderSystem(),
 		RenderAssterodd: systems.NewRenderAssteroddSystem(),
 		RenderBogdan:    systems.NewRenderBogdanSystem(),
 
@@ -65,6 +73,11 @@ type SystemList struct {
 	Network                  stdsystems.NetworkSystem
 	NetworkReceive           stdsystems.NetworkReceiveSystem
 	NetworkSend              stdsystems.NetworkSendSystem
+	NetworkInterest          stdsystems.NetworkInterestSystem
+	NetworkStats             stdsystems.NetworkStatsSystem
+	Camera                   stdsystems.CameraSystem
+	TexturePro               stdsystems.TextureProSystem
+	PreviousTransform        stdsystems.PreviousTransformSystem
 	AnimationSpriteMatrix    stdsystems.AnimationSpriteMatrixSystem
 	AnimationPlayer          stdsystems.AnimationPlayerSystem
 	TextureRenderSpriteSheet stdsystems.TextureRenderSpriteSheetSystem
@@ -76,7 +89,10 @@ type SystemList struct {
 	CollisionDetectionBVH    stdsystems.CollisionDetectionBVHSystem
 	ColliderSystem           stdsystems.ColliderSystem
 	CollisionResolution      stdsystems.CollisionResolutionSystem
+	Interpolation            stdsystems.InterpolationSystem
+	NetworkReplication       stdsystems.NetworkReplicationSystem
 
+	Render          stdsystems.RenderSystem
 	RenderAssterodd systems.RenderAssteroddSystem
 	RenderBogdan    systems.RenderBogdanSystem
 
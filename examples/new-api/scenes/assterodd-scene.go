@@ -73,6 +73,13 @@ func (s *AssteroddScene) Id() gomp.SceneId {
 
 func (s *AssteroddScene) Init() {
 	s.World.Init()
+
+	// Game time
+	gameTime := s.Game.TimeControl()
+	s.World.Systems.Velocity.Time = gameTime
+	s.World.Systems.AnimationPlayer.Time = gameTime
+	s.World.Systems.Debug.Time = gameTime
+	s.World.Systems.AssteroddSystem.Time = gameTime
 	s.World.Systems.ColliderSystem.Init()
 
 	// Scenes
@@ -92,8 +99,16 @@ func (s *AssteroddScene) Init() {
 	s.World.Systems.SpriteMatrix.Init()
 	s.World.Systems.Sprite.Init()
 	s.World.Systems.YSort.Init()
+	s.World.Systems.PreviousTransform.Init()
+	s.World.Systems.TexturePro.Init()
+	s.World.Systems.Camera.Init()
 
 	// RenderAssterodd
+	s.World.Systems.NetworkStats.Init()
+	s.World.Systems.Render.Init()
+	s.World.Systems.Render.CullMargin = 64
+	s.World.Systems.Render.Frame = &s.Game.Frame
+	s.World.Systems.RenderAssterodd.Render = &s.World.Systems.Render
 	s.World.Systems.RenderAssterodd.Init()
 	s.World.Systems.Debug.Init()
 	s.World.Systems.AssetLib.Init()
@@ -111,6 +126,7 @@ func (s *AssteroddScene) Update(dt time.Duration) gomp.SceneId {
 }
 
 func (s *AssteroddScene) FixedUpdate(dt time.Duration) {
+	s.World.Systems.PreviousTransform.Run()
 	s.World.Systems.SpaceshipIntents.Run(dt)
 	s.World.Systems.Velocity.Run(dt)
 	s.World.Systems.DampingSystem.Run(dt)
@@ -124,13 +140,18 @@ func (s *AssteroddScene) FixedUpdate(dt time.Duration) {
 func (s *AssteroddScene) Render(dt time.Duration) {
 	// Animation
 	s.World.Systems.AnimationSpriteMatrix.Run()
-	s.World.Systems.AnimationPlayer.Run()
+	s.World.Systems.AnimationPlayer.Run(dt)
 
 	s.World.Systems.SpriteMatrix.Run()
 	s.World.Systems.Sprite.Run()
 	s.World.Systems.Debug.Run()
 	s.World.Systems.AssetLib.Run()
 	s.World.Systems.YSort.Run()
+	s.World.Systems.NetworkStats.Run(dt)
+	s.World.Systems.TexturePro.Alpha = s.Game.InterpolationAlpha()
+	s.World.Systems.TexturePro.Run()
+	s.World.Systems.Camera.Alpha = s.World.Systems.TexturePro.Alpha
+	s.World.Systems.Camera.Run(dt)
 
 	shouldContinue := s.World.Systems.RenderAssterodd.Run(dt)
 	if !shouldContinue {
@@ -162,10 +183,15 @@ func (s *AssteroddScene) Destroy() {
 	s.World.Systems.Sprite.Destroy()
 	s.World.Systems.SpriteMatrix.Destroy()
 	s.World.Systems.YSort.Destroy()
+	s.World.Systems.PreviousTransform.Destroy()
+	s.World.Systems.TexturePro.Destroy()
+	s.World.Systems.Camera.Destroy()
 
 	// RenderAssterodd
 	s.World.Systems.Debug.Destroy()
 	s.World.Systems.AssetLib.Destroy()
+	s.World.Systems.NetworkStats.Destroy()
+	s.World.Systems.Render.Destroy()
 	s.World.Systems.RenderAssterodd.Destroy()
 	s.World.Systems.Audio.Destroy()
 	s.World.Systems.SpatialAudio.Destroy()
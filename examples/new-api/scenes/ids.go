@@ -20,4 +20,5 @@ const (
 	MenuSceneId gomp.SceneId = iota
 	MainSceneId
 	AssteroddSceneId
+	LoadingSceneId
 )
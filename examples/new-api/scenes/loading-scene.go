@@ -0,0 +1,68 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package scenes
+
+import (
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"gomp"
+	"gomp/pkg/render"
+	"gomp/vectors"
+	"time"
+)
+
+func NewLoadingScene() LoadingScene {
+	return LoadingScene{}
+}
+
+// LoadingScene shows a progress bar while Game.Load loads the next scene
+type LoadingScene struct {
+	Game *gomp.Game
+}
+
+func (s *LoadingScene) Id() gomp.SceneId {
+	return LoadingSceneId
+}
+
+func (s *LoadingScene) Init() {}
+
+func (s *LoadingScene) Update(dt time.Duration) gomp.SceneId {
+	if rl.WindowShouldClose() {
+		s.Game.SetShouldDestroy(true)
+	}
+	return LoadingSceneId
+}
+
+func (s *LoadingScene) FixedUpdate(dt time.Duration) {}
+
+func (s *LoadingScene) Render(dt time.Duration) {
+	width, height := s.Game.RenderSystem.Size()
+	barWidth := float32(width / 2)
+	x := (float32(width) - barWidth) / 2
+	y := float32(height / 2)
+
+	frame := &s.Game.Frame
+	frame.Clear(rl.Black)
+	frame.DrawText("Loading...", x, y-40, 20, rl.RayWhite)
+	frame.DrawRectangleLines(render.NewRectangle(x, y, barWidth, 20), 1, rl.RayWhite)
+	frame.DrawRectangle(render.NewRectangle(x, y, barWidth*s.Game.LoadingProgress(), 20), vectors.Vec2{}, 0, rl.RayWhite)
+}
+
+func (s *LoadingScene) Destroy() {}
+
+func (s *LoadingScene) OnEnter() {}
+
+func (s *LoadingScene) OnExit() {}
+
+var _ gomp.AnyScene = (*LoadingScene)(nil)
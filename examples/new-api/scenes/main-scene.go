@@ -18,6 +18,7 @@ import (
 	"gomp"
 	"gomp/examples/new-api/instances"
 	"gomp/pkg/ecs"
+	"gomp/stdcomponents"
 	"time"
 )
 
@@ -39,6 +40,12 @@ func (s *MainScene) Id() gomp.SceneId {
 func (s *MainScene) Init() {
 	s.World.Init()
 
+	// Game time
+	gameTime := s.Game.TimeControl()
+	s.World.Systems.Velocity.Time = gameTime
+	s.World.Systems.AnimationPlayer.Time = gameTime
+	s.World.Systems.Debug.Time = gameTime
+
 	// Network receive
 	s.World.Systems.Network.Init()
 	s.World.Systems.NetworkReceive.Init()
@@ -50,8 +57,17 @@ func (s *MainScene) Init() {
 	s.World.Systems.CollisionDetectionGrid.Init()
 
 	// Network patches
+	s.World.Systems.NetworkInterest.Init()
+	s.World.Systems.NetworkSend.Interest = &s.World.Systems.NetworkInterest
 	s.World.Systems.NetworkSend.Init()
 
+	// Network interpolation
+	s.World.Systems.NetworkReplication.OnSpawn = func(entity ecs.Entity, id stdcomponents.NetworkId) {
+		s.World.Components.Interpolation.Create(entity, stdcomponents.NewInterpolation())
+	}
+	s.World.Systems.NetworkReplication.Init()
+	s.World.Systems.Interpolation.Init()
+
 	// Animation
 	s.World.Systems.AnimationSpriteMatrix.Init()
 	s.World.Systems.AnimationPlayer.Init()
@@ -60,6 +76,7 @@ func (s *MainScene) Init() {
 	s.World.Systems.YSort.Init()
 
 	// RenderAssterodd
+	s.World.Systems.RenderBogdan.Frame = &s.Game.Frame
 	s.World.Systems.RenderBogdan.Init()
 	s.World.Systems.Debug.Init()
 	s.World.Systems.AssetLib.Init()
@@ -68,6 +85,7 @@ func (s *MainScene) Init() {
 func (s *MainScene) Update(dt time.Duration) gomp.SceneId {
 	// Network receive
 	s.World.Systems.NetworkReceive.Run(dt)
+	s.World.Systems.NetworkReplication.Run(dt)
 	s.World.Systems.Player.Run()
 
 	return MainSceneId
@@ -80,13 +98,17 @@ func (s *MainScene) FixedUpdate(dt time.Duration) {
 	s.World.Systems.Velocity.Run(dt)
 	s.World.Systems.CollisionDetectionGrid.Run(dt)
 	s.World.Systems.CollisionHandler.Run(dt)
+	s.World.Systems.NetworkInterest.Run(dt)
 	s.World.Systems.NetworkSend.Run(dt)
 }
 
 func (s *MainScene) Render(dt time.Duration) {
+	// Network interpolation
+	s.World.Systems.Interpolation.Run(dt)
+
 	// Animation
 	s.World.Systems.AnimationSpriteMatrix.Run()
-	s.World.Systems.AnimationPlayer.Run()
+	s.World.Systems.AnimationPlayer.Run(dt)
 
 	s.World.Systems.SpriteMatrix.Run()
 	s.World.Systems.Debug.Run()
@@ -111,7 +133,10 @@ func (s *MainScene) Destroy() {
 	s.World.Systems.CollisionDetectionGrid.Destroy()
 
 	// Network patches
+	s.World.Systems.NetworkInterest.Destroy()
 	s.World.Systems.NetworkSend.Destroy()
+	s.World.Systems.NetworkReplication.Destroy()
+	s.World.Systems.Interpolation.Destroy()
 
 	// Animation
 	s.World.Systems.AnimationSpriteMatrix.Destroy()
@@ -18,10 +18,12 @@ func NewSceneList() SceneList {
 	return SceneList{
 		Main:      NewMainScene(),
 		Assterodd: NewAssteroddScene(),
+		Loading:   NewLoadingScene(),
 	}
 }
 
 type SceneList struct {
 	Main      MainScene
 	Assterodd AssteroddScene
+	Loading   LoadingScene
 }
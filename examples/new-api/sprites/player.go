@@ -15,33 +15,34 @@ Thank you for your support!
 package sprites
 
 import (
-	rl "github.com/gen2brain/raylib-go/raylib"
 	"gomp/examples/new-api/assets"
+	"gomp/pkg/render"
 	"gomp/stdcomponents"
+	"gomp/vectors"
 )
 
 var PlayerSpriteMatrix = stdcomponents.SpriteMatrix{
 	Texture: assets.Textures.Get("milansheet.png"),
-	Origin:  rl.Vector2{X: 0.5, Y: 0.5},
+	Origin:  vectors.Vec2{X: 0.5, Y: 0.5},
 	FPS:     12,
 	Animations: []stdcomponents.SpriteMatrixAnimation{
 		{
 			Name:        "idle",
-			Frame:       rl.Rectangle{X: 0, Y: 0, Width: 96, Height: 128},
+			Frame:       render.Rectangle{X: 0, Y: 0, Width: 96, Height: 128},
 			NumOfFrames: 1,
 			Vertical:    false,
 			Loop:        true,
 		},
 		{
 			Name:        "walk",
-			Frame:       rl.Rectangle{X: 0, Y: 512, Width: 96, Height: 128},
+			Frame:       render.Rectangle{X: 0, Y: 512, Width: 96, Height: 128},
 			NumOfFrames: 8,
 			Vertical:    false,
 			Loop:        true,
 		},
 		{
 			Name:        "jump",
-			Frame:       rl.Rectangle{X: 96, Y: 0, Width: 96, Height: 128},
+			Frame:       render.Rectangle{X: 96, Y: 0, Width: 96, Height: 128},
 			NumOfFrames: 1,
 			Vertical:    false,
 			Loop:        false,
@@ -16,9 +16,11 @@ package systems
 
 import (
 	rl "github.com/gen2brain/raylib-go/raylib"
+	"gomp"
 	"gomp/examples/new-api/components"
 	"gomp/examples/new-api/entities"
 	"gomp/pkg/ecs"
+	"gomp/pkg/render"
 	"gomp/stdcomponents"
 	"gomp/vectors"
 	"math/rand"
@@ -30,15 +32,16 @@ func NewAssteroddSystem() AssteroddSystem {
 }
 
 type AssteroddSystem struct {
-	EntityManager   *ecs.EntityManager
-	Positions       *stdcomponents.PositionComponentManager
-	Rotations       *stdcomponents.RotationComponentManager
-	Scales          *stdcomponents.ScaleComponentManager
-	Velocities      *stdcomponents.VelocityComponentManager
-	Sprites         *stdcomponents.SpriteComponentManager
-	BoxColliders    *stdcomponents.BoxColliderComponentManager
-	CircleColliders *stdcomponents.CircleColliderComponentManager
-	RigidBodies     *stdcomponents.RigidBodyComponentManager
+	EntityManager      *ecs.EntityManager
+	Positions          *stdcomponents.PositionComponentManager
+	Rotations          *stdcomponents.RotationComponentManager
+	PreviousTransforms *stdcomponents.PreviousTransformComponentManager
+	Scales             *stdcomponents.ScaleComponentManager
+	Velocities         *stdcomponents.VelocityComponentManager
+	Sprites            *stdcomponents.SpriteComponentManager
+	BoxColliders       *stdcomponents.BoxColliderComponentManager
+	CircleColliders    *stdcomponents.CircleColliderComponentManager
+	RigidBodies        *stdcomponents.RigidBodyComponentManager
 
 	PlayerTags       *components.PlayerTagComponentManager
 	AsteroidTags     *components.AsteroidComponentManager
@@ -51,33 +54,41 @@ type AssteroddSystem struct {
 	SceneManager     *components.AsteroidSceneManagerComponentManager
 	WallTags         *components.WallTagComponentManager
 	SoundEffects     *components.SoundEffectsComponentManager
+	Cameras          *stdcomponents.Camera2DComponentManager
+	Time             *gomp.TimeControl
+
+	camera  ecs.Entity
+	minimap ecs.Entity
+	lastHp  int32
 }
 
 func (s *AssteroddSystem) Init() {
-	entities.CreateSpaceShip(entities.CreateSpaceShipManagers{
-		EntityManager:    s.EntityManager,
-		Positions:        s.Positions,
-		Rotations:        s.Rotations,
-		Scales:           s.Scales,
-		Velocities:       s.Velocities,
-		Sprites:          s.Sprites,
-		BoxColliders:     s.BoxColliders,
-		RigidBodies:      s.RigidBodies,
-		PlayerTags:       s.PlayerTags,
-		Hps:              s.Hps,
-		Weapons:          s.Weapons,
-		SpaceshipIntents: s.SpaceshipIntents,
-		SoundEffects:     s.SoundEffects,
+	spaceship := entities.CreateSpaceShip(entities.CreateSpaceShipManagers{
+		EntityManager:      s.EntityManager,
+		Positions:          s.Positions,
+		Rotations:          s.Rotations,
+		PreviousTransforms: s.PreviousTransforms,
+		Scales:             s.Scales,
+		Velocities:         s.Velocities,
+		Sprites:            s.Sprites,
+		BoxColliders:       s.BoxColliders,
+		RigidBodies:        s.RigidBodies,
+		PlayerTags:         s.PlayerTags,
+		Hps:                s.Hps,
+		Weapons:            s.Weapons,
+		SpaceshipIntents:   s.SpaceshipIntents,
+		SoundEffects:       s.SoundEffects,
 	}, 300, 300, -44.9)
 	entities.CreateSatellite(entities.CreateSatelliteManagers{
-		EntityManager: s.EntityManager,
-		Positions:     s.Positions,
-		Rotations:     s.Rotations,
-		Scales:        s.Scales,
-		Velocities:    s.Velocities,
-		Sprites:       s.Sprites,
-		BoxColliders:  s.BoxColliders,
-		RigidBodies:   s.RigidBodies,
+		EntityManager:      s.EntityManager,
+		Positions:          s.Positions,
+		Rotations:          s.Rotations,
+		PreviousTransforms: s.PreviousTransforms,
+		Scales:             s.Scales,
+		Velocities:         s.Velocities,
+		Sprites:            s.Sprites,
+		BoxColliders:       s.BoxColliders,
+		RigidBodies:        s.RigidBodies,
 	}, 500, 500, 0)
 	entities.CreateSpaceSpawner(entities.CreateSpaceSpawnerManagers{
 		EntityManager: s.EntityManager,
@@ -107,21 +118,42 @@ func (s *AssteroddSystem) Init() {
 			Y: float32(rand.Intn(5000)),
 		}
 		entities.CreateBullet(entities.CreateBulletManagers{
-			EntityManager:   s.EntityManager,
-			Positions:       s.Positions,
-			Rotations:       s.Rotations,
-			Scales:          s.Scales,
-			Velocities:      s.Velocities,
-			CircleColliders: s.CircleColliders,
-			RigidBodies:     s.RigidBodies,
-			Sprites:         s.Sprites,
-			BulletTags:      s.BulletTags,
-			Hps:             s.Hps,
+			EntityManager:      s.EntityManager,
+			Positions:          s.Positions,
+			Rotations:          s.Rotations,
+			PreviousTransforms: s.PreviousTransforms,
+			Scales:             s.Scales,
+			Velocities:         s.Velocities,
+			CircleColliders:    s.CircleColliders,
+			RigidBodies:        s.RigidBodies,
+			Sprites:            s.Sprites,
+			BulletTags:         s.BulletTags,
+			Hps:                s.Hps,
 		}, randPos.X, randPos.Y, 0, 0, 0)
 	}
 
 	manager := s.EntityManager.Create()
 	s.SceneManager.Create(manager, components.AsteroidSceneManager{})
+
+	width, height := float32(rl.GetScreenWidth()), float32(rl.GetScreenHeight())
+	s.camera = s.EntityManager.Create()
+	s.Cameras.Create(s.camera, stdcomponents.Camera2D{
+		Active:    true,
+		Viewport:  render.NewRectangle(0, 0, width, height),
+		Target:    spaceship,
+		Position:  s.Positions.Get(spaceship).XY,
+		Smoothing: 40,
+		Bounds:    render.NewRectangle(-1000, -1000, 7000, 7000),
+	})
+	// Toggled with M, it draws the whole scene a second time
+	s.minimap = s.EntityManager.Create()
+	s.Cameras.Create(s.minimap, stdcomponents.Camera2D{
+		Order:      1,
+		Viewport:   render.NewRectangle(width-210, 10, 200, 200),
+		Background: render.Color{R: 20, G: 20, B: 20, A: 255},
+		Position:   vectors.Vec2{X: 2500, Y: 2500},
+		Zoom:       200.0 / 7000,
+	})
 }
 func (s *AssteroddSystem) Run(dt time.Duration) {
 	s.PlayerTags.EachEntity(func(e ecs.Entity) bool {
@@ -154,6 +186,11 @@ func (s *AssteroddSystem) Run(dt time.Duration) {
 		return true
 	})
 
+	if rl.IsKeyPressed(rl.KeyM) {
+		minimap := s.Cameras.Get(s.minimap)
+		minimap.Active = !minimap.Active
+	}
+
 	s.SceneManager.EachEntity(func(e ecs.Entity) bool {
 		sceneManager := s.SceneManager.Get(e)
 		s.PlayerTags.EachEntity(func(e ecs.Entity) bool {
@@ -161,6 +198,11 @@ func (s *AssteroddSystem) Run(dt time.Duration) {
 			if playerHp == nil {
 				return true
 			}
+			if playerHp.Hp < s.lastHp {
+				s.Cameras.Get(s.camera).Shake(8, time.Millisecond*300)
+				s.Time.Hitstop(time.Millisecond * 80)
+			}
+			s.lastHp = playerHp.Hp
 			sceneManager.PlayerHp = playerHp.Hp
 			return false
 		})
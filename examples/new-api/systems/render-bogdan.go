@@ -18,7 +18,9 @@ import (
 	"fmt"
 	rl "github.com/gen2brain/raylib-go/raylib"
 	"gomp/pkg/ecs"
+	"gomp/pkg/render"
 	"gomp/stdcomponents"
+	"gomp/vectors"
 	"math"
 	"slices"
 	"sync"
@@ -46,7 +48,10 @@ type RenderBogdanSystem struct {
 	Collisions       *stdcomponents.CollisionComponentManager
 	renderList       []renderEntry
 	instanceData     []stdcomponents.RLTexturePro
-	camera           rl.Camera2D
+	camera           render.Camera
+
+	// Frame of the game the scene is drawn into, assigned by the scene
+	Frame *render.CommandList
 }
 
 type renderEntry struct {
@@ -56,11 +61,8 @@ type renderEntry struct {
 }
 
 func (s *RenderBogdanSystem) Init() {
-	s.camera = rl.Camera2D{
-		Target:   rl.NewVector2(0, 0),
-		Offset:   rl.NewVector2(0, 0),
-		Rotation: 0,
-		Zoom:     1,
+	s.camera = render.Camera{
+		Zoom: 1,
 	}
 }
 func (s *RenderBogdanSystem) Run(dt time.Duration) bool {
@@ -70,33 +72,31 @@ func (s *RenderBogdanSystem) Run(dt time.Duration) bool {
 
 	s.prepareRender(dt)
 
-	rl.BeginDrawing()
-	rl.ClearBackground(rl.Black)
+	s.Frame.Clear(rl.Black)
 	// draw grid
 	const gridSize = 256
-	for i := int32(1); i < 1024/gridSize; i++ {
-		rl.DrawLine(i*gridSize, 0, i*gridSize, 768, rl.Green)
+	for i := float32(1); i < 1024/gridSize; i++ {
+		s.Frame.DrawRectangle(render.NewRectangle(i*gridSize, 0, 1, 768), vectors.Vec2{}, 0, rl.Green)
 	}
-	for i := int32(1); i < 768/gridSize; i++ {
-		rl.DrawLine(0, i*gridSize, 1024, i*gridSize, rl.Green)
+	for i := float32(1); i < 768/gridSize; i++ {
+		s.Frame.DrawRectangle(render.NewRectangle(0, i*gridSize, 1024, 1), vectors.Vec2{}, 0, rl.Green)
 	}
 	s.render()
 	s.ColliderBoxes.EachEntity(func(e ecs.Entity) bool {
 		box := s.ColliderBoxes.Get(e)
 		pos := s.Positions.Get(e)
 
-		rl.DrawRectangleLines(int32(pos.XY.X), int32(pos.XY.Y), int32(box.WH.X), int32(box.WH.Y), rl.Red)
+		s.Frame.DrawRectangleLines(render.NewRectangle(pos.XY.X, pos.XY.Y, box.WH.X, box.WH.Y), 1, rl.Red)
 		return true
 	})
 	s.Collisions.EachEntity(func(entity ecs.Entity) bool {
 		pos := s.Positions.Get(entity)
-		rl.DrawRectangle(int32(pos.XY.X), int32(pos.XY.X), 16, 16, rl.Red)
+		s.Frame.DrawRectangle(render.NewRectangle(pos.XY.X, pos.XY.X, 16, 16), vectors.Vec2{}, 0, rl.Red)
 		return true
 	})
-	rl.DrawRectangle(0, 0, 200, 60, rl.DarkBrown)
-	rl.DrawFPS(10, 10)
-	rl.DrawText(fmt.Sprintf("%d entities", s.EntityManager.Size()), 10, 30, 20, rl.RayWhite)
-	rl.EndDrawing()
+	s.Frame.DrawRectangle(render.NewRectangle(0, 0, 200, 60), vectors.Vec2{}, 0, rl.DarkBrown)
+	s.Frame.DrawText(fmt.Sprintf("%d FPS", rl.GetFPS()), 10, 10, 20, rl.Lime)
+	s.Frame.DrawText(fmt.Sprintf("%d entities", s.EntityManager.Size()), 10, 30, 20, rl.RayWhite)
 
 	return true
 }
@@ -260,9 +260,9 @@ func (s *RenderBogdanSystem) prepareTints(wg *sync.WaitGroup) {
 }
 
 func (s *RenderBogdanSystem) submitBatch(texID int, data []stdcomponents.RLTexturePro) {
-	rl.BeginMode2D(s.camera)
+	s.Frame.BeginCamera(s.camera)
 	for i := range data {
-		rl.DrawTexturePro(*data[i].Texture, data[i].Frame, data[i].Dest, data[i].Origin, data[i].Rotation, data[i].Tint)
+		s.Frame.DrawTexture(*data[i].Texture, data[i].Frame, data[i].Dest, data[i].Origin, data[i].Rotation, data[i].Tint)
 	}
-	rl.EndMode2D()
+	s.Frame.EndCamera()
 }
@@ -30,21 +30,22 @@ func NewSpaceshipIntentsSystem() SpaceshipIntentsSystem {
 }
 
 type SpaceshipIntentsSystem struct {
-	EntityManager    *ecs.EntityManager
-	SpaceshipIntents *components.SpaceshipIntentComponentManager
-	Positions        *stdcomponents.PositionComponentManager
-	Velocities       *stdcomponents.VelocityComponentManager
-	Rotations        *stdcomponents.RotationComponentManager
-	Scales           *stdcomponents.ScaleComponentManager
-	BoxColliders     *stdcomponents.BoxColliderComponentManager
-	CircleColliders  *stdcomponents.CircleColliderComponentManager
-	BulletTags       *components.BulletTagComponentManager
-	Sprites          *stdcomponents.SpriteComponentManager
-	RigidBodies      *stdcomponents.RigidBodyComponentManager
-	Weapons          *components.WeaponComponentManager
-	Hps              *components.HpComponentManager
-	SoundEffects     *components.SoundEffectsComponentManager
-	moveSpeed        float32
+	EntityManager      *ecs.EntityManager
+	SpaceshipIntents   *components.SpaceshipIntentComponentManager
+	Positions          *stdcomponents.PositionComponentManager
+	Velocities         *stdcomponents.VelocityComponentManager
+	Rotations          *stdcomponents.RotationComponentManager
+	PreviousTransforms *stdcomponents.PreviousTransformComponentManager
+	Scales             *stdcomponents.ScaleComponentManager
+	BoxColliders       *stdcomponents.BoxColliderComponentManager
+	CircleColliders    *stdcomponents.CircleColliderComponentManager
+	BulletTags         *components.BulletTagComponentManager
+	Sprites            *stdcomponents.SpriteComponentManager
+	RigidBodies        *stdcomponents.RigidBodyComponentManager
+	Weapons            *components.WeaponComponentManager
+	Hps                *components.HpComponentManager
+	SoundEffects       *components.SoundEffectsComponentManager
+	moveSpeed          float32
 }
 
 func (s *SpaceshipIntentsSystem) Init() {}
@@ -115,16 +116,17 @@ func (s *SpaceshipIntentsSystem) Run(dt time.Duration) {
 					bulletVelocityY := vel.Y + float32(math.Cos(angle+math.Pi))*bulletSpeed
 					bulletVelocityX := vel.X - float32(math.Sin(angle+math.Pi))*bulletSpeed
 					entities.CreateBullet(entities.CreateBulletManagers{
-						EntityManager:   s.EntityManager,
-						Positions:       s.Positions,
-						Rotations:       s.Rotations,
-						Scales:          s.Scales,
-						Velocities:      s.Velocities,
-						CircleColliders: s.CircleColliders,
-						RigidBodies:     s.RigidBodies,
-						Sprites:         s.Sprites,
-						BulletTags:      s.BulletTags,
-						Hps:             s.Hps,
+						EntityManager:      s.EntityManager,
+						Positions:          s.Positions,
+						Rotations:          s.Rotations,
+						PreviousTransforms: s.PreviousTransforms,
+						Scales:             s.Scales,
+						Velocities:         s.Velocities,
+						CircleColliders:    s.CircleColliders,
+						RigidBodies:        s.RigidBodies,
+						Sprites:            s.Sprites,
+						BulletTags:         s.BulletTags,
+						Hps:                s.Hps,
 					}, pos.XY.X, pos.XY.Y, angle, bulletVelocityX, bulletVelocityY)
 				}
 				weapon.CooldownLeft = weapon.Cooldown
@@ -16,6 +16,7 @@ package gomp
 
 import (
 	"github.com/negrel/assert"
+	"gomp/pkg/render"
 	"reflect"
 	"time"
 )
@@ -44,17 +45,40 @@ func NewGame(scenes ...AnyScene) Game {
 	game := Game{
 		Scenes:       sceneSet,
 		RenderSystem: NewRenderSystem(),
+		Time:         NewTimeControl(),
+		initialized:  make(map[SceneId]bool, len(scenes)),
 	}
 
 	return game
 }
 
+// Game runs a stack of scenes. The top one is updated, the ones below are kept as they were,
+// and rendered only when every scene above them is transparent.
+// Rendered scenes record into Frame from the bottom one up, and Game presents it once per frame.
 type Game struct {
 	Scenes         map[SceneId]AnyScene
-	CurrentSceneId SceneId
-
-	shouldDestroy bool
-	RenderSystem  RenderSystem
+	CurrentSceneId SceneId // Top of the stack, set before Init to choose the first scene
+
+	// Assets are loaded by Load while its loading scene is shown
+	Assets     []AnyAssetLibrary
+	LoadBudget time.Duration // Frame time spent loading assets, DefaultLoadBudget if 0
+
+	// Frame collects the commands of the rendered scenes. It is empty when the bottom scene renders,
+	// so only that scene clears the screen, the scenes above draw over it.
+	Frame render.CommandList
+	// Overlay holds transition effects of the frame, Game draws it over Frame
+	Overlay render.CommandList
+
+	shouldDestroy      bool
+	interpolationAlpha float32
+	RenderSystem       RenderSystem
+	Time               TimeControl
+
+	stack       []SceneId
+	initialized map[SceneId]bool
+	changes     []sceneChange
+	transition  activeTransition
+	loading     sceneLoading
 }
 
 func (g *Game) Init() {
@@ -62,36 +86,57 @@ func (g *Game) Init() {
 		g.injectToScene(scene)
 	}
 
-	scene, ok := g.Scenes[g.CurrentSceneId]
-	assert.True(ok, "Scene not found")
 	g.RenderSystem.Init()
-	scene.Init()
+	g.pushScene(g.CurrentSceneId)
 }
 
 func (g *Game) Update(dt time.Duration) {
 	// Scenes
-	scene, ok := g.Scenes[g.CurrentSceneId]
-	assert.True(ok, "Scene not found")
-	g.CurrentSceneId = scene.Update(dt)
+	scene := g.scene(g.CurrentSceneId)
+	if next := scene.Update(dt); next != scene.Id() {
+		g.Replace(next, nil)
+	}
+	g.updateSceneStack()
 }
 
 func (g *Game) FixedUpdate(dt time.Duration) {
 	// Scenes
-	scene, ok := g.Scenes[g.CurrentSceneId]
-	assert.True(ok, "Scene not found")
-	scene.FixedUpdate(dt)
+	g.scene(g.CurrentSceneId).FixedUpdate(dt)
 }
 
 func (g *Game) Render(dt time.Duration) {
-	scene, ok := g.Scenes[g.CurrentSceneId]
-	assert.True(ok, "Scene not found")
-	scene.Render(dt)
+	g.Frame.Reset()
+	g.Overlay.Reset()
+	if g.transition.active {
+		width, height := g.RenderSystem.Size()
+		screen := render.NewRectangle(0, 0, float32(width), float32(height))
+		g.transition.change.transition.Draw(&g.Overlay, screen, g.transitionProgress())
+	}
+
+	bottom := len(g.stack) - 1
+	for bottom > 0 {
+		transparent, ok := g.scene(g.stack[bottom]).(TransparentScene)
+		if !ok || !transparent.Transparent() {
+			break
+		}
+		bottom--
+	}
+	for _, id := range g.stack[bottom:] {
+		g.scene(id).Render(dt)
+	}
+
+	g.Frame.Append(&g.Overlay)
+	g.RenderSystem.Present(&g.Frame)
 }
 
 func (g *Game) Destroy() {
-	scene, ok := g.Scenes[g.CurrentSceneId]
-	assert.True(ok, "Scene not found")
-	scene.Destroy()
+	for len(g.stack) > 0 {
+		g.popScene()
+	}
+	// Scenes initialized by an unfinished Load
+	for id := range g.initialized {
+		g.destroyScene(id)
+	}
 	g.RenderSystem.Destroy()
 }
 
@@ -103,6 +148,24 @@ func (g *Game) SetShouldDestroy(value bool) {
 	g.shouldDestroy = value
 }
 
+// Configure is called by Engine before Init
+func (g *Game) Configure(config EngineConfig) {
+	g.RenderSystem.SetVSync(config.VSync)
+}
+
+func (g *Game) TimeControl() *TimeControl {
+	return &g.Time
+}
+
+func (g *Game) SetInterpolationAlpha(alpha float32) {
+	g.interpolationAlpha = alpha
+}
+
+// InterpolationAlpha is how far rendering is between the previous and current fixed update, see PreviousTransformSystem
+func (g *Game) InterpolationAlpha() float32 {
+	return g.interpolationAlpha
+}
+
 func (g *Game) injectToScene(scene AnyScene) {
 	reflectedScene := reflect.ValueOf(scene).Elem()
 	sceneLen := reflectedScene.NumField()
@@ -6,8 +6,10 @@ replace github.com/ugorji/go v1.1.4 => github.com/ugorji/go v1.1.7
 
 require (
 	github.com/coder/websocket v1.8.12
+	github.com/felixge/fgprof v0.9.5
 	github.com/gen2brain/raylib-go/raylib v0.0.0-20250215042252-db8e47f0e5c5
 	github.com/hajimehoshi/ebiten/v2 v2.8.6
+	github.com/hajimehoshi/go-steamworks v0.0.0-20241112125913-96b2a6baef69
 	github.com/jakecoffman/cp/v2 v2.1.0
 	github.com/jfreymuth/go-sdl3 v0.1.3-0.20250226211328-622f8250e21c
 	github.com/jupiterrider/purego-sdl3 v0.0.0-20250223121749-61a56748f345
@@ -27,13 +29,11 @@ require (
 	github.com/Zyko0/go-sdl3 v0.0.0-20250324113244-771f317184f7 // indirect
 	github.com/Zyko0/purego-gen v0.0.0-20250308152853-097c3ba1e28a // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/felixge/fgprof v0.9.5 // indirect
 	github.com/francoispqt/gojay v1.2.13 // indirect
 	github.com/go-task/slim-sprig v0.0.0-20230315185526-52ccab3ef572 // indirect
 	github.com/google/pprof v0.0.0-20240227163752-401108e1b7e7 // indirect
 	github.com/gorilla/context v1.1.2 // indirect
 	github.com/gorilla/securecookie v1.1.2 // indirect
-	github.com/hajimehoshi/go-steamworks v0.0.0-20241112125913-96b2a6baef69 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/niemeyer/pretty v0.0.0-20200227124842-a10e7caefd8e // indirect
 	github.com/onsi/ginkgo/v2 v2.9.5 // indirect
@@ -63,6 +63,6 @@ require (
 	golang.org/x/net v0.33.0 // indirect
 	golang.org/x/sync v0.10.0 // indirect
 	golang.org/x/sys v0.28.0 // indirect
-	golang.org/x/text v0.21.0 // indirect
+	golang.org/x/text v0.21.0
 	google.golang.org/protobuf v1.36.1
 )
@@ -0,0 +1,107 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package gomp
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	histogramFirstBound  = 62500 * time.Nanosecond
+	histogramBucketCount = 17 // Bounds double from 62.5µs up to 4.096s, one more bucket takes the rest
+)
+
+// Histogram counts durations in buckets of exponentially growing upper bounds
+type Histogram struct {
+	counts [histogramBucketCount + 1]uint64
+	count  uint64
+	sum    time.Duration
+	max    time.Duration
+}
+
+type HistogramBucket struct {
+	UpperBound time.Duration // math.MaxInt64 for the last bucket
+	Count      uint64
+}
+
+type HistogramSnapshot struct {
+	Count   uint64
+	Mean    time.Duration
+	P50     time.Duration // Quantiles are upper bounds of their buckets
+	P99     time.Duration
+	Max     time.Duration
+	Buckets []HistogramBucket
+}
+
+func histogramBound(bucket int) time.Duration {
+	if bucket >= histogramBucketCount {
+		return math.MaxInt64
+	}
+	return histogramFirstBound << bucket
+}
+
+func (h *Histogram) Observe(duration time.Duration) {
+	bucket := 0
+	for bucket < histogramBucketCount && duration > histogramBound(bucket) {
+		bucket++
+	}
+	h.counts[bucket]++
+	h.count++
+	h.sum += duration
+	h.max = max(h.max, duration)
+}
+
+func (h *Histogram) Count() uint64 {
+	return h.count
+}
+
+func (h *Histogram) Mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return h.sum / time.Duration(h.count)
+}
+
+// Quantile is the upper bound of the bucket the q-th duration falls in, capped by the max observed
+func (h *Histogram) Quantile(q float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q * float64(h.count)))
+	var seen uint64
+	for bucket, count := range h.counts {
+		seen += count
+		if seen >= rank {
+			return min(histogramBound(bucket), h.max)
+		}
+	}
+	return h.max
+}
+
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	snapshot := HistogramSnapshot{
+		Count:   h.count,
+		Mean:    h.Mean(),
+		P50:     h.Quantile(0.5),
+		P99:     h.Quantile(0.99),
+		Max:     h.max,
+		Buckets: make([]HistogramBucket, len(h.counts)),
+	}
+	for bucket, count := range h.counts {
+		snapshot.Buckets[bucket] = HistogramBucket{UpperBound: histogramBound(bucket), Count: count}
+	}
+	return snapshot
+}
@@ -0,0 +1,449 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package network
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/quic-go/quic-go"
+)
+
+const (
+	// ChannelReliableOrdered delivers every message in order they were sent. Use it for RPCs and chat.
+	ChannelReliableOrdered Channel = iota
+	// ChannelReliableUnordered delivers every message, but a lost packet does not stall the following ones.
+	ChannelReliableUnordered
+	// ChannelUnreliableSequenced goes over QUIC datagrams. Messages may be lost,
+	// and messages older than the last delivered one are dropped. Use it for state.
+	ChannelUnreliableSequenced
+	channelsCount
+
+	// channelControl carries heartbeats, it is never exposed to the game
+	channelControl Channel = 0xff
+)
+
+const (
+	controlPing byte = iota + 1
+	controlPong
+)
+
+const (
+	// DefaultMTU fits into a single QUIC datagram on most networks
+	DefaultMTU = 1100
+	// MaxMessageSize limits a single message on any channel
+	MaxMessageSize = 1 << 20
+
+	datagramHeaderSize = 5 // channel, sequence, fragment index, fragments count
+	maxFragments       = 255
+	maxPendingMessages = 32 // fragmented messages waiting for the rest of fragments
+)
+
+var (
+	ErrUnknownChannel  = errors.New("unknown channel")
+	ErrMessageTooLarge = errors.New("message is too large")
+	ErrNotConnected    = errors.New("not connected")
+	ErrPeerTimeout     = errors.New("peer timed out")
+)
+
+// Message is a message received from a peer
+type Message struct {
+	Peer    PeerId
+	Channel Channel
+	Data    []byte
+}
+
+type ChannelConfig struct {
+	// MTU is the biggest payload written at once. Unreliable messages above it are split into
+	// datagram fragments, reliable ones are written into the stream with MTU sized chunks.
+	MTU int
+}
+
+type ChannelConfigs [channelsCount]ChannelConfig
+
+func DefaultChannelConfigs() ChannelConfigs {
+	return ChannelConfigs{
+		ChannelReliableOrdered:     {MTU: DefaultMTU},
+		ChannelReliableUnordered:   {MTU: DefaultMTU},
+		ChannelUnreliableSequenced: {MTU: DefaultMTU},
+	}
+}
+
+func (c *ChannelConfigs) mtu(ch Channel) int {
+	mtu := c[ch].MTU
+	if mtu <= 0 {
+		return DefaultMTU
+	}
+	return mtu
+}
+
+// channelConn implements channel semantics on top of a single QUIC connection
+type channelConn struct {
+	conn      quic.Connection
+	peer      PeerId
+	configs   *ChannelConfigs
+	onMessage func(Message)
+
+	// Reliable ordered channel is a single bidirectional stream with length prefixed frames
+	ordered   quic.Stream
+	orderedMx sync.Mutex
+
+	sendSequence atomic.Uint32
+
+	lastReceived atomic.Int64 // Unix nanoseconds of the last packet from the peer
+	rtt          atomic.Int64 // Smoothed round trip time in nanoseconds
+	timedOut     atomic.Bool
+	stats        peerCounters
+
+	// Receiving side of unreliable channel, touched only by receiveDatagrams
+	lastSequence uint16
+	hasSequence  bool
+	fragments    map[uint16]*fragmentedMessage
+}
+
+type fragmentedMessage struct {
+	parts    [][]byte
+	received int
+}
+
+func newChannelConn(conn quic.Connection, ordered quic.Stream, peer PeerId, configs *ChannelConfigs, onMessage func(Message)) *channelConn {
+	c := &channelConn{
+		conn:      conn,
+		peer:      peer,
+		configs:   configs,
+		onMessage: onMessage,
+		ordered:   ordered,
+		fragments: make(map[uint16]*fragmentedMessage),
+	}
+	c.touch()
+	return c
+}
+
+func (c *channelConn) touch() {
+	c.lastReceived.Store(time.Now().UnixNano())
+}
+
+// RTT returns smoothed round trip time measured by heartbeats
+func (c *channelConn) RTT() time.Duration {
+	return time.Duration(c.rtt.Load())
+}
+
+// heartbeat pings the peer every interval until ctx is done.
+// Returns ErrPeerTimeout when nothing was received from the peer for longer than timeout.
+func (c *channelConn) heartbeat(ctx context.Context, interval, timeout time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			if now.Sub(time.Unix(0, c.lastReceived.Load())) > timeout {
+				c.timedOut.Store(true)
+				return ErrPeerTimeout
+			}
+
+			packet := make([]byte, 0, 10)
+			packet = append(packet, byte(channelControl), controlPing)
+			packet = binary.BigEndian.AppendUint64(packet, uint64(now.UnixNano()))
+			if err := c.conn.SendDatagram(packet); err != nil {
+				return err
+			}
+			c.stats.packetsSent.Add(1)
+		}
+	}
+}
+
+func (c *channelConn) handleControl(packet []byte) error {
+	if len(packet) != 10 {
+		return ErrMalformedMessage
+	}
+
+	switch packet[1] {
+	case controlPing:
+		packet[1] = controlPong
+		if err := c.conn.SendDatagram(packet); err != nil {
+			return err
+		}
+		c.stats.packetsSent.Add(1)
+		return nil
+	case controlPong:
+		sent := int64(binary.BigEndian.Uint64(packet[2:]))
+		sample := time.Now().UnixNano() - sent
+		if sample < 0 {
+			return ErrMalformedMessage
+		}
+		if rtt := c.rtt.Load(); rtt != 0 {
+			sample = rtt + (sample-rtt)/8
+		}
+		c.rtt.Store(sample)
+		return nil
+	default:
+		return ErrMalformedMessage
+	}
+}
+
+func (c *channelConn) send(ch Channel, data []byte) error {
+	if len(data) > MaxMessageSize {
+		return ErrMessageTooLarge
+	}
+
+	var err error
+	switch ch {
+	case ChannelReliableOrdered:
+		err = c.sendOrdered(data)
+	case ChannelReliableUnordered:
+		err = c.sendUnordered(data)
+	case ChannelUnreliableSequenced:
+		err = c.sendUnreliable(data)
+	default:
+		return ErrUnknownChannel
+	}
+	if err != nil {
+		return err
+	}
+	c.stats.sent(ch, len(data))
+	return nil
+}
+
+// Stats returns traffic counters of the connection
+func (c *channelConn) Stats() PeerStats {
+	return c.stats.snapshot(c.peer, c.RTT())
+}
+
+func (c *channelConn) sendOrdered(data []byte) error {
+	var header [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(header[:], uint64(len(data)))
+
+	c.orderedMx.Lock()
+	defer c.orderedMx.Unlock()
+
+	if _, err := c.ordered.Write(header[:n]); err != nil {
+		return err
+	}
+	return writeChunked(c.ordered, data, c.configs.mtu(ChannelReliableOrdered))
+}
+
+func (c *channelConn) sendUnordered(data []byte) error {
+	stream, err := c.conn.OpenUniStreamSync(c.conn.Context())
+	if err != nil {
+		return err
+	}
+
+	if _, err = stream.Write([]byte{byte(ChannelReliableUnordered)}); err != nil {
+		return err
+	}
+	if err = writeChunked(stream, data, c.configs.mtu(ChannelReliableUnordered)); err != nil {
+		return err
+	}
+	return stream.Close()
+}
+
+func (c *channelConn) sendUnreliable(data []byte) error {
+	mtu := c.configs.mtu(ChannelUnreliableSequenced)
+	count := max((len(data)+mtu-1)/mtu, 1)
+	if count > maxFragments {
+		return ErrMessageTooLarge
+	}
+
+	sequence := uint16(c.sendSequence.Add(1))
+	packet := make([]byte, 0, datagramHeaderSize+min(len(data), mtu))
+
+	for i := range count {
+		chunk := data[i*mtu : min((i+1)*mtu, len(data))]
+
+		packet = append(packet[:0], byte(ChannelUnreliableSequenced))
+		packet = binary.BigEndian.AppendUint16(packet, sequence)
+		packet = append(packet, byte(i), byte(count))
+		packet = append(packet, chunk...)
+
+		if err := c.conn.SendDatagram(packet); err != nil {
+			return err
+		}
+		c.stats.packetsSent.Add(1)
+	}
+	return nil
+}
+
+// receiveOrdered blocks until the ordered stream is closed
+func (c *channelConn) receiveOrdered() error {
+	reader := bufio.NewReader(c.ordered)
+	for {
+		length, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return err
+		}
+		c.touch()
+		// Empty messages are delivered like on the other channels
+		if length > MaxMessageSize {
+			return ErrMessageTooLarge
+		}
+
+		data := make([]byte, length)
+		if _, err = io.ReadFull(reader, data); err != nil {
+			return err
+		}
+
+		c.stats.received(ChannelReliableOrdered, len(data))
+		c.onMessage(Message{Peer: c.peer, Channel: ChannelReliableOrdered, Data: data})
+	}
+}
+
+func (c *channelConn) receiveUnordered(ctx context.Context) {
+	for {
+		stream, err := c.conn.AcceptUniStream(ctx)
+		if err != nil {
+			return
+		}
+
+		c.touch()
+		go func(stream quic.ReceiveStream) {
+			// Channel prefix and one byte over the limit, so larger messages are detected
+			data, err := io.ReadAll(io.LimitReader(stream, MaxMessageSize+2))
+			// Unidirectional streams carry nothing but the unordered channel,
+			// peers must not pass their messages as ordered or control ones
+			if err != nil || len(data) == 0 || len(data)-1 > MaxMessageSize || Channel(data[0]) != ChannelReliableUnordered {
+				stream.CancelRead(0)
+				return
+			}
+
+			c.stats.received(ChannelReliableUnordered, len(data)-1)
+			c.onMessage(Message{Peer: c.peer, Channel: ChannelReliableUnordered, Data: data[1:]})
+		}(stream)
+	}
+}
+
+func (c *channelConn) receiveDatagrams(ctx context.Context) {
+	for {
+		packet, err := c.conn.ReceiveDatagram(ctx)
+		if err != nil {
+			return
+		}
+
+		c.touch()
+		c.stats.packetsReceived.Add(1)
+		if len(packet) > 0 && Channel(packet[0]) == channelControl {
+			_ = c.handleControl(packet)
+			continue
+		}
+
+		data, ok, err := c.handleDatagram(packet)
+		if err != nil {
+			continue
+		}
+		if ok {
+			c.stats.received(ChannelUnreliableSequenced, len(data))
+			c.onMessage(Message{Peer: c.peer, Channel: ChannelUnreliableSequenced, Data: data})
+		}
+	}
+}
+
+// handleDatagram returns a complete message once all of its fragments arrived
+func (c *channelConn) handleDatagram(packet []byte) ([]byte, bool, error) {
+	if len(packet) < datagramHeaderSize || Channel(packet[0]) != ChannelUnreliableSequenced {
+		return nil, false, ErrMalformedMessage
+	}
+
+	sequence := binary.BigEndian.Uint16(packet[1:3])
+	index, count := int(packet[3]), int(packet[4])
+	payload := packet[datagramHeaderSize:]
+
+	if count == 0 || index >= count {
+		return nil, false, ErrMalformedMessage
+	}
+
+	// Newer message is already delivered
+	if c.hasSequence && !sequenceNewer(sequence, c.lastSequence) {
+		return nil, false, nil
+	}
+
+	if count == 1 {
+		c.deliverSequence(sequence)
+		return payload, true, nil
+	}
+
+	message, ok := c.fragments[sequence]
+	if !ok {
+		if len(c.fragments) >= maxPendingMessages {
+			return nil, false, nil
+		}
+		message = &fragmentedMessage{parts: make([][]byte, count)}
+		c.fragments[sequence] = message
+	}
+	if len(message.parts) != count {
+		return nil, false, fmt.Errorf("%w: fragments count mismatch", ErrMalformedMessage)
+	}
+	if message.parts[index] != nil {
+		return nil, false, nil
+	}
+
+	message.parts[index] = payload
+	message.received++
+	if message.received < count {
+		return nil, false, nil
+	}
+
+	size := 0
+	for _, part := range message.parts {
+		size += len(part)
+	}
+	data := make([]byte, 0, size)
+	for _, part := range message.parts {
+		data = append(data, part...)
+	}
+
+	c.deliverSequence(sequence)
+	return data, true, nil
+}
+
+func (c *channelConn) deliverSequence(sequence uint16) {
+	// Skipped sequences are lost, including incomplete fragmented messages
+	if c.hasSequence {
+		c.stats.messagesLost.Add(uint64(sequence - c.lastSequence - 1))
+	}
+	c.lastSequence = sequence
+	c.hasSequence = true
+
+	// Incomplete older messages will never be delivered
+	for s := range c.fragments {
+		if !sequenceNewer(s, sequence) {
+			delete(c.fragments, s)
+		}
+	}
+}
+
+// sequenceNewer reports whether a is newer than b, taking wrap around into account
+func sequenceNewer(a, b uint16) bool {
+	return int16(a-b) > 0
+}
+
+func writeChunked(w io.Writer, data []byte, mtu int) error {
+	for len(data) > 0 {
+		chunk := data[:min(len(data), mtu)]
+		if _, err := w.Write(chunk); err != nil {
+			return err
+		}
+		data = data[len(chunk):]
+	}
+	return nil
+}
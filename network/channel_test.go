@@ -0,0 +1,78 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package network
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func datagram(sequence uint16, index, count byte, payload string) []byte {
+	packet := []byte{byte(ChannelUnreliableSequenced)}
+	packet = binary.BigEndian.AppendUint16(packet, sequence)
+	packet = append(packet, index, count)
+	return append(packet, payload...)
+}
+
+func TestChannelDatagramReassembly(t *testing.T) {
+	c := newChannelConn(nil, nil, 0, nil, nil)
+
+	data, ok, err := c.handleDatagram(datagram(1, 1, 2, "world"))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	data, ok, err = c.handleDatagram(datagram(1, 0, 2, "hello "))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "hello world", string(data))
+
+	_, _, err = c.handleDatagram(datagram(2, 2, 2, "x"))
+	require.ErrorIs(t, err, ErrMalformedMessage)
+}
+
+func TestChannelDatagramStaleDropped(t *testing.T) {
+	c := newChannelConn(nil, nil, 0, nil, nil)
+
+	// Incomplete older message is discarded once a newer one is delivered
+	_, ok, _ := c.handleDatagram(datagram(5, 0, 2, "old"))
+	require.False(t, ok)
+
+	data, ok, _ := c.handleDatagram(datagram(6, 0, 1, "new"))
+	require.True(t, ok)
+	require.Equal(t, "new", string(data))
+	require.Empty(t, c.fragments)
+
+	_, ok, _ = c.handleDatagram(datagram(5, 1, 2, "old"))
+	require.False(t, ok)
+	_, ok, _ = c.handleDatagram(datagram(6, 0, 1, "dup"))
+	require.False(t, ok)
+
+	// Sequence wraps around
+	c.lastSequence = 65535
+	data, ok, _ = c.handleDatagram(datagram(0, 0, 1, "wrapped"))
+	require.True(t, ok)
+	require.Equal(t, "wrapped", string(data))
+}
+
+func TestChannelMaxMessageSize(t *testing.T) {
+	server, serverEvents, serverMessages := startTestServer(t)
+	client, _, _ := connectTestClient(t, server)
+	waitEvent(t, serverEvents)
+
+	for _, ch := range []Channel{ChannelReliableOrdered, ChannelReliableUnordered} {
+		data := make([]byte, MaxMessageSize)
+		data[len(data)-1] = byte(ch)
+		require.NoError(t, client.Send(ch, data))
+		msg := waitMessage(t, serverMessages)
+		require.Equal(t, ch, msg.Channel)
+		require.Equal(t, data, msg.Data)
+
+		require.ErrorIs(t, client.Send(ch, make([]byte, MaxMessageSize+1)), ErrMessageTooLarge)
+	}
+}
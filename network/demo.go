@@ -0,0 +1,439 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package network
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// DemoExtension is the conventional extension of demo files
+const DemoExtension = ".gdemo"
+
+const (
+	demoMagic        = "GDEMO"
+	demoVersion      = 1
+	maxDemoFrameSize = 64 << 20 // keyframes hold the whole game state
+
+	DefaultKeyframeInterval = 10 * time.Second
+)
+
+var ErrMalformedDemo = errors.New("malformed demo")
+
+type demoFrameKind uint8
+
+const (
+	demoFrameTick demoFrameKind = iota + 1
+	demoFrameMessage
+	demoFrameInput
+	demoFrameKeyframe
+	demoFramePeerEvent
+)
+
+// DemoRecorder records everything received by QuicNetwork, local input and periodic keyframes
+// to a .gdemo stream. Pass it to QuicNetwork.Record, messages are recorded before handlers see them.
+// Frames are grouped by ticks, so playback releases them on exactly the same ticks.
+type DemoRecorder struct {
+	mx    sync.Mutex
+	w     *bufio.Writer
+	frame []byte
+	err   error
+
+	elapsed      time.Duration
+	lastKeyframe time.Duration
+	hasKeyframe  bool
+
+	// KeyframeInterval is how often Snapshot is recorded, smaller interval makes seeking faster
+	KeyframeInterval time.Duration
+	// Snapshot returns full game state, e.g. encoded ecs.EntityManager.PatchSnapshot. Keyframes are off if nil.
+	Snapshot func() ([]byte, error)
+}
+
+func NewDemoRecorder(w io.Writer) (*DemoRecorder, error) {
+	r := &DemoRecorder{
+		w:                bufio.NewWriter(w),
+		KeyframeInterval: DefaultKeyframeInterval,
+	}
+
+	header := binary.BigEndian.AppendUint16([]byte(demoMagic), demoVersion)
+	if _, err := r.w.Write(header); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// RecordMessage records a message into the current tick, QuicNetwork calls it when game code reads the message
+func (r *DemoRecorder) RecordMessage(msg Message) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	r.frame = binary.AppendUvarint(r.frame[:0], uint64(msg.Channel))
+	r.frame = binary.AppendVarint(r.frame, int64(msg.Peer))
+	r.frame = append(r.frame, msg.Data...)
+	r.write(demoFrameMessage, r.frame)
+}
+
+// RecordPeerEvent records a peer event into the current tick, QuicNetwork calls it from PollPeerEvent
+func (r *DemoRecorder) RecordPeerEvent(event PeerEvent) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	r.frame = appendDemoPeerEvent(r.frame[:0], event)
+	r.write(demoFramePeerEvent, r.frame)
+}
+
+// RecordInput records local input of the current tick in any game specific format
+func (r *DemoRecorder) RecordInput(data []byte) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.write(demoFrameInput, data)
+}
+
+// Tick closes the current tick, it must be called once per game tick after state was updated
+func (r *DemoRecorder) Tick(dt time.Duration) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	r.elapsed += dt
+	r.frame = binary.AppendUvarint(r.frame[:0], uint64(dt))
+	r.write(demoFrameTick, r.frame)
+
+	if r.Snapshot == nil || r.hasKeyframe && r.elapsed-r.lastKeyframe < r.KeyframeInterval {
+		return
+	}
+
+	snapshot, err := r.Snapshot()
+	if err != nil {
+		r.setErr(err)
+		return
+	}
+	r.write(demoFrameKeyframe, snapshot)
+	r.lastKeyframe = r.elapsed
+	r.hasKeyframe = true
+}
+
+// Err returns the first error happened while recording, recording stops after it
+func (r *DemoRecorder) Err() error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return r.err
+}
+
+// Close flushes recorded frames, it should be detached from QuicNetwork before
+func (r *DemoRecorder) Close() error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	if r.err != nil {
+		return r.err
+	}
+	return r.w.Flush()
+}
+
+func (r *DemoRecorder) write(kind demoFrameKind, payload []byte) {
+	if r.err != nil {
+		return
+	}
+
+	var header [1 + binary.MaxVarintLen64]byte
+	header[0] = byte(kind)
+	n := binary.PutUvarint(header[1:], uint64(len(payload)))
+
+	if _, err := r.w.Write(header[:1+n]); err != nil {
+		r.setErr(err)
+		return
+	}
+	if _, err := r.w.Write(payload); err != nil {
+		r.setErr(err)
+	}
+}
+
+func (r *DemoRecorder) setErr(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+type demoFrame struct {
+	kind    demoFrameKind
+	elapsed time.Duration // Time at the end of the tick the frame belongs to
+	payload []byte
+}
+
+// DemoPlayer replays a recorded demo as a client transport. Game code receives
+// recorded messages as if they came from the server, sent data is dropped.
+// Pass it to QuicNetwork.Play to route messages through handlers like they were recorded.
+type DemoPlayer struct {
+	frames    []demoFrame
+	keyframes []int // Indexes of keyframe frames
+	cursor    int
+	elapsed   time.Duration
+	duration  time.Duration
+
+	inbox  [channelsCount][]Message
+	events []PeerEvent
+	inputs [][]byte
+
+	// OnMessage and OnPeerEvent receive frames released by Tick instead of Receive and PollPeerEvent
+	OnMessage   func(Message)
+	OnPeerEvent func(PeerEvent)
+}
+
+// LoadDemo reads the whole demo into memory
+func LoadDemo(r io.Reader) (*DemoPlayer, error) {
+	reader := bufio.NewReader(r)
+
+	header := make([]byte, len(demoMagic)+2)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, err
+	}
+	if string(header[:len(demoMagic)]) != demoMagic || binary.BigEndian.Uint16(header[len(demoMagic):]) != demoVersion {
+		return nil, ErrMalformedDemo
+	}
+
+	p := &DemoPlayer{}
+	var elapsed time.Duration
+	var pending []int // Frames of the tick, which end is not read yet
+
+	for {
+		kind, err := reader.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		length, err := binary.ReadUvarint(reader)
+		if err != nil || length > maxDemoFrameSize {
+			return nil, ErrMalformedDemo
+		}
+		payload := make([]byte, length)
+		if _, err = io.ReadFull(reader, payload); err != nil {
+			return nil, ErrMalformedDemo
+		}
+
+		frame := demoFrame{kind: demoFrameKind(kind), payload: payload}
+		switch frame.kind {
+		case demoFrameTick:
+			dt, n := binary.Uvarint(payload)
+			if n <= 0 {
+				return nil, ErrMalformedDemo
+			}
+			elapsed += time.Duration(dt)
+			for _, i := range pending {
+				p.frames[i].elapsed = elapsed
+			}
+			pending = pending[:0]
+			frame.elapsed = elapsed
+		case demoFrameKeyframe:
+			frame.elapsed = elapsed
+			p.keyframes = append(p.keyframes, len(p.frames))
+		case demoFrameMessage, demoFrameInput, demoFramePeerEvent:
+			pending = append(pending, len(p.frames))
+		default:
+			return nil, ErrMalformedDemo
+		}
+		p.frames = append(p.frames, frame)
+	}
+
+	// Frames of an unfinished tick are never played
+	p.frames = p.frames[:len(p.frames)-len(pending)]
+	p.duration = elapsed
+	return p, nil
+}
+
+// Tick releases messages and inputs of the next recorded tick and returns its dt.
+// Returns false when the demo is over.
+func (p *DemoPlayer) Tick() (time.Duration, bool) {
+	p.clear()
+
+	for p.cursor < len(p.frames) {
+		frame := &p.frames[p.cursor]
+		p.cursor++
+
+		switch frame.kind {
+		case demoFrameMessage:
+			msg, err := decodeDemoMessage(frame.payload)
+			if err != nil || msg.Channel < 0 || msg.Channel >= channelsCount {
+				continue
+			}
+			if p.OnMessage != nil {
+				p.OnMessage(msg)
+				continue
+			}
+			p.inbox[msg.Channel] = append(p.inbox[msg.Channel], msg)
+		case demoFramePeerEvent:
+			event, err := decodeDemoPeerEvent(frame.payload)
+			if err != nil {
+				continue
+			}
+			if p.OnPeerEvent != nil {
+				p.OnPeerEvent(event)
+				continue
+			}
+			p.events = append(p.events, event)
+		case demoFrameInput:
+			p.inputs = append(p.inputs, frame.payload)
+		case demoFrameTick:
+			dt, _ := binary.Uvarint(frame.payload)
+			p.elapsed = frame.elapsed
+			return time.Duration(dt), true
+		}
+	}
+	return 0, false
+}
+
+// Seek moves playback to the latest keyframe not after the time and returns the keyframe.
+// Game state must be restored from it, then Tick until Elapsed reaches the time to land on it exactly.
+// Without keyframes playback restarts from the beginning and nil keyframe is returned.
+func (p *DemoPlayer) Seek(at time.Duration) []byte {
+	p.clear()
+	p.cursor = 0
+	p.elapsed = 0
+
+	var keyframe []byte
+	for _, i := range p.keyframes {
+		frame := &p.frames[i]
+		if frame.elapsed > at {
+			break
+		}
+		keyframe = frame.payload
+		p.cursor = i + 1
+		p.elapsed = frame.elapsed
+	}
+	return keyframe
+}
+
+// Elapsed returns playback time at the end of the last played tick
+func (p *DemoPlayer) Elapsed() time.Duration {
+	return p.elapsed
+}
+
+// Duration returns time of the whole demo
+func (p *DemoPlayer) Duration() time.Duration {
+	return p.duration
+}
+
+// Input returns the next recorded input of the current tick
+func (p *DemoPlayer) Input() ([]byte, bool) {
+	if len(p.inputs) == 0 {
+		return nil, false
+	}
+	input := p.inputs[0]
+	p.inputs = p.inputs[1:]
+	return input, true
+}
+
+// PollPeerEvent returns the next recorded peer event of the current tick
+func (p *DemoPlayer) PollPeerEvent() (PeerEvent, bool) {
+	if len(p.events) == 0 {
+		return PeerEvent{}, false
+	}
+	event := p.events[0]
+	p.events = p.events[1:]
+	return event, true
+}
+
+func (p *DemoPlayer) Receive(ch Channel) (Message, bool) {
+	if ch < 0 || ch >= channelsCount || len(p.inbox[ch]) == 0 {
+		return Message{}, false
+	}
+	msg := p.inbox[ch][0]
+	p.inbox[ch] = p.inbox[ch][1:]
+	return msg, true
+}
+
+// Send drops data, there is nobody on the other side of a demo
+func (p *DemoPlayer) Send(ch Channel, data []byte) error {
+	return nil
+}
+
+// Disconnect stops the playback
+func (p *DemoPlayer) Disconnect() {
+	p.clear()
+	p.cursor = len(p.frames)
+}
+
+func (p *DemoPlayer) clear() {
+	for i := range p.inbox {
+		p.inbox[i] = p.inbox[i][:0]
+	}
+	p.events = p.events[:0]
+	p.inputs = p.inputs[:0]
+}
+
+func decodeDemoMessage(payload []byte) (Message, error) {
+	ch, n := binary.Uvarint(payload)
+	if n <= 0 {
+		return Message{}, ErrMalformedDemo
+	}
+	payload = payload[n:]
+
+	peer, n := binary.Varint(payload)
+	if n <= 0 {
+		return Message{}, ErrMalformedDemo
+	}
+
+	return Message{Peer: PeerId(peer), Channel: Channel(ch), Data: payload[n:]}, nil
+}
+
+func appendDemoPeerEvent(dst []byte, event PeerEvent) []byte {
+	dst = append(dst, byte(event.Type))
+	dst = binary.AppendVarint(dst, int64(event.Peer))
+
+	// Rejections keep their reason, other errors only their text
+	var reason RejectReason
+	var text string
+	var reject *RejectError
+	switch {
+	case errors.As(event.Err, &reject):
+		reason = reject.Reason
+	case event.Err != nil:
+		text = event.Err.Error()
+	}
+	dst = append(dst, byte(reason))
+	return append(dst, text...)
+}
+
+func decodeDemoPeerEvent(payload []byte) (PeerEvent, error) {
+	if len(payload) == 0 {
+		return PeerEvent{}, ErrMalformedDemo
+	}
+	event := PeerEvent{Type: PeerEventType(payload[0])}
+	payload = payload[1:]
+
+	peer, n := binary.Varint(payload)
+	if n <= 0 || len(payload) == n {
+		return PeerEvent{}, ErrMalformedDemo
+	}
+	event.Peer = PeerId(peer)
+	payload = payload[n:]
+
+	reason, text := RejectReason(payload[0]), string(payload[1:])
+	switch {
+	case reason != RejectNone:
+		event.Err = &RejectError{Reason: reason}
+	case event.Type == PeerTimedOut:
+		event.Err = ErrPeerTimeout
+	case text != "":
+		event.Err = errors.New(text)
+	}
+	return event, nil
+}
@@ -0,0 +1,169 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package network
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// demoMatch is a tiny game which state depends on received messages, handled messages,
+// peer events and local input
+type demoMatch struct {
+	score   uint64
+	handled uint64
+	events  []PeerEvent
+}
+
+func newDemoMatchNetwork(match *demoMatch) *QuicNetwork {
+	n := &QuicNetwork{}
+	n.Handle(MessageRPC, func(msg Message) error {
+		match.handled += uint64(msg.Data[1])
+		return nil
+	})
+	return n
+}
+
+func (m *demoMatch) update(n *QuicNetwork, input []byte) {
+	n.Dispatch()
+	for event, ok := n.PollPeerEvent(); ok; event, ok = n.PollPeerEvent() {
+		m.score = m.score*13 + uint64(event.Type) + uint64(event.Peer)
+		m.events = append(m.events, event)
+	}
+	for ch := Channel(0); ch < channelsCount; ch++ {
+		for msg, ok := n.Receive(ch); ok; msg, ok = n.Receive(ch) {
+			m.score = m.score*31 + uint64(msg.Peer) + uint64(len(msg.Data))*uint64(ch+1)
+		}
+	}
+	m.score = m.score*5 + m.handled
+	m.handled = 0
+	for _, b := range input {
+		m.score = m.score*7 + uint64(b)
+	}
+}
+
+func TestDemoRecordPlayback(t *testing.T) {
+	const ticks = 50
+	const dt = 100 * time.Millisecond
+
+	var file bytes.Buffer
+	recorder, err := NewDemoRecorder(&file)
+	require.NoError(t, err)
+
+	match := demoMatch{}
+	recorder.KeyframeInterval = time.Second
+	recorder.Snapshot = func() ([]byte, error) {
+		return binary.AppendUvarint(nil, match.score), nil
+	}
+	network := newDemoMatchNetwork(&match)
+	network.Record(recorder)
+
+	recorded := make([]uint64, 0, ticks)
+	for i := 0; i < ticks; i++ {
+		// Messages arrive from network goroutines, handled ones never reach Receive
+		network.receive(Message{Peer: ServerPeerId, Channel: ChannelReliableOrdered, Data: make([]byte, i)})
+		network.receive(Message{Peer: ServerPeerId, Channel: ChannelUnreliableSequenced, Data: []byte{0, byte(i)}})
+		network.receive(Message{Peer: ServerPeerId, Channel: ChannelReliableOrdered, Data: []byte{byte(MessageRPC), byte(i)}})
+		switch i {
+		case 10:
+			network.events.push(PeerEvent{Peer: 3, Type: PeerRejected, Err: &RejectError{Reason: RejectServerFull}})
+		case 20:
+			network.events.push(PeerEvent{Peer: ServerPeerId, Type: PeerConnected})
+		case 30:
+			network.events.push(PeerEvent{Peer: ServerPeerId, Type: PeerTimedOut, Err: ErrPeerTimeout})
+		}
+
+		input := []byte{byte(i * 3)}
+		recorder.RecordInput(input)
+		match.update(network, input)
+		// Arrived after the game read this tick, so it belongs to the next one
+		network.receive(Message{Peer: ServerPeerId, Channel: ChannelReliableOrdered, Data: []byte{byte(MessageRPC), byte(i * 5)}})
+		network.receive(Message{Peer: ServerPeerId, Channel: ChannelReliableUnordered, Data: make([]byte, i%7)})
+		recorder.Tick(dt)
+		recorded = append(recorded, match.score)
+	}
+	network.Record(nil)
+	require.NoError(t, recorder.Close())
+
+	player, err := LoadDemo(bytes.NewReader(file.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, ticks*dt, player.Duration())
+
+	replayed := demoMatch{}
+	playback := newDemoMatchNetwork(&replayed)
+	playback.Play(player)
+
+	replay := func(match *demoMatch) uint64 {
+		tickDt, ok := player.Tick()
+		require.True(t, ok)
+		require.Equal(t, dt, tickDt)
+		input, ok := player.Input()
+		require.True(t, ok)
+		match.update(playback, input)
+		return match.score
+	}
+
+	for i := 0; i < ticks; i++ {
+		require.Equal(t, recorded[i], replay(&replayed), "tick %d", i)
+	}
+	_, ok := player.Tick()
+	require.False(t, ok)
+	require.Equal(t, match.events, replayed.events)
+
+	// Seeking lands on the keyframe, then ticks forward to the exact time
+	keyframe := player.Seek(2500 * time.Millisecond)
+	require.NotNil(t, keyframe)
+	require.Equal(t, 2100*time.Millisecond, player.Elapsed())
+
+	score, _ := binary.Uvarint(keyframe)
+	replayed = demoMatch{score: score}
+	for player.Elapsed() < 2500*time.Millisecond {
+		replay(&replayed)
+	}
+	require.Equal(t, recorded[24], replayed.score)
+}
+
+func TestDemoMalformed(t *testing.T) {
+	_, err := LoadDemo(bytes.NewReader([]byte("NOT A DEMO")))
+	require.ErrorIs(t, err, ErrMalformedDemo)
+
+	var file bytes.Buffer
+	recorder, err := NewDemoRecorder(&file)
+	require.NoError(t, err)
+	recorder.RecordInput([]byte{1})
+	recorder.Tick(time.Millisecond)
+	recorder.RecordInput([]byte{2})
+	require.NoError(t, recorder.Close())
+
+	// Frames of the unfinished tick are dropped
+	player, err := LoadDemo(bytes.NewReader(file.Bytes()))
+	require.NoError(t, err)
+	_, ok := player.Tick()
+	require.True(t, ok)
+	_, ok = player.Tick()
+	require.False(t, ok)
+}
+
+func TestQuicNetworkInboxLimit(t *testing.T) {
+	n := &QuicNetwork{InboxLimit: 2}
+	for i := range 3 {
+		n.receive(Message{Channel: ChannelReliableOrdered, Data: []byte{0, byte(i)}})
+	}
+
+	// Messages over the limit are dropped until the inbox is read
+	for i := range 2 {
+		msg, ok := n.Receive(ChannelReliableOrdered)
+		require.True(t, ok)
+		require.Equal(t, byte(i), msg.Data[1])
+	}
+	_, ok := n.Receive(ChannelReliableOrdered)
+	require.False(t, ok)
+}
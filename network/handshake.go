@@ -0,0 +1,267 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package network
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/quic-go/quic-go"
+)
+
+// ProtocolVersion is bumped on every incompatible change of the wire format
+const ProtocolVersion uint16 = 1
+
+const (
+	DefaultHandshakeTimeout = 10 * time.Second
+
+	maxGameVersionLength = 64
+	maxTokenLength       = 4096
+)
+
+// RejectReason tells the client why the server refused the connection.
+// It is also used as QUIC application error code of the closed connection.
+type RejectReason uint8
+
+const (
+	RejectNone RejectReason = iota
+	RejectMalformed
+	RejectProtocolVersion
+	RejectGameVersion
+	RejectUnauthorized
+	RejectServerFull
+)
+
+func (r RejectReason) String() string {
+	switch r {
+	case RejectNone:
+		return "accepted"
+	case RejectMalformed:
+		return "malformed handshake"
+	case RejectProtocolVersion:
+		return "protocol version mismatch"
+	case RejectGameVersion:
+		return "game version mismatch"
+	case RejectUnauthorized:
+		return "unauthorized"
+	case RejectServerFull:
+		return "server is full"
+	default:
+		return fmt.Sprintf("reject reason %d", uint8(r))
+	}
+}
+
+// RejectError is returned when the handshake is refused
+type RejectError struct {
+	Reason RejectReason
+}
+
+func (e *RejectError) Error() string {
+	return "connection rejected: " + e.Reason.String()
+}
+
+// Hello is the first message sent by the client over the reliable ordered channel
+type Hello struct {
+	ProtocolVersion uint16
+	GameVersion     string
+	Token           []byte
+}
+
+func (h *Hello) MarshalBinary() ([]byte, error) {
+	if len(h.GameVersion) > maxGameVersionLength || len(h.Token) > maxTokenLength {
+		return nil, ErrMessageTooLarge
+	}
+
+	data := binary.BigEndian.AppendUint16(nil, h.ProtocolVersion)
+	data = binary.AppendUvarint(data, uint64(len(h.GameVersion)))
+	data = append(data, h.GameVersion...)
+	data = binary.AppendUvarint(data, uint64(len(h.Token)))
+	data = append(data, h.Token...)
+	return data, nil
+}
+
+func (h *Hello) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 {
+		return ErrMalformedMessage
+	}
+	h.ProtocolVersion = binary.BigEndian.Uint16(data)
+	data = data[2:]
+
+	version, data, err := readBytes(data, maxGameVersionLength)
+	if err != nil {
+		return err
+	}
+	token, data, err := readBytes(data, maxTokenLength)
+	if err != nil {
+		return err
+	}
+	if len(data) != 0 {
+		return ErrMalformedMessage
+	}
+
+	h.GameVersion = string(version)
+	h.Token = token
+	return nil
+}
+
+func readBytes(data []byte, limit int) ([]byte, []byte, error) {
+	length, n := binary.Uvarint(data)
+	if n <= 0 || length > uint64(limit) || length > uint64(len(data)-n) {
+		return nil, data, ErrMalformedMessage
+	}
+	data = data[n:]
+	return data[:length:length], data[length:], nil
+}
+
+// Authenticator decides whether a client is allowed to join.
+// Returning *RejectError picks the reason sent to the client, any other error means RejectUnauthorized.
+type Authenticator interface {
+	Authenticate(hello *Hello) error
+}
+
+// SharedSecretAuthenticator accepts clients which token equals to the secret
+type SharedSecretAuthenticator struct {
+	Secret []byte
+}
+
+func (a *SharedSecretAuthenticator) Authenticate(hello *Hello) error {
+	if subtle.ConstantTimeCompare(hello.Token, a.Secret) != 1 {
+		return &RejectError{Reason: RejectUnauthorized}
+	}
+	return nil
+}
+
+// SignedTokenAuthenticator accepts tokens issued by NewSignedToken with the same key,
+// e.g. by a matchmaking service, until they expire
+type SignedTokenAuthenticator struct {
+	Key []byte
+	Now func() time.Time // time.Now if nil
+}
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+)
+
+func (a *SignedTokenAuthenticator) Authenticate(hello *Hello) error {
+	_, err := a.Verify(hello.Token)
+	return err
+}
+
+// Verify checks the token and returns its subject
+func (a *SignedTokenAuthenticator) Verify(token []byte) (string, error) {
+	if len(token) < 8+sha256.Size {
+		return "", ErrInvalidToken
+	}
+
+	payload, mac := token[:len(token)-sha256.Size], token[len(token)-sha256.Size:]
+	if !hmac.Equal(mac, signToken(a.Key, payload)) {
+		return "", ErrInvalidToken
+	}
+
+	expiresAt := time.Unix(int64(binary.BigEndian.Uint64(payload)), 0)
+	now := time.Now
+	if a.Now != nil {
+		now = a.Now
+	}
+	if now().After(expiresAt) {
+		return "", ErrTokenExpired
+	}
+
+	return string(payload[8:]), nil
+}
+
+// NewSignedToken issues a token for the subject, usually a player id, valid until expiresAt
+func NewSignedToken(key []byte, subject string, expiresAt time.Time) []byte {
+	token := binary.BigEndian.AppendUint64(nil, uint64(expiresAt.Unix()))
+	token = append(token, subject...)
+	return append(token, signToken(key, token)...)
+}
+
+func signToken(key, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// welcome is the server reply to Hello
+type welcome struct {
+	Reason RejectReason
+	Peer   PeerId
+}
+
+func (w *welcome) marshal() []byte {
+	return binary.AppendVarint([]byte{byte(w.Reason)}, int64(w.Peer))
+}
+
+func (w *welcome) unmarshal(data []byte) error {
+	if len(data) < 2 {
+		return ErrMalformedMessage
+	}
+	peer, n := binary.Varint(data[1:])
+	if n <= 0 {
+		return ErrMalformedMessage
+	}
+	w.Reason = RejectReason(data[0])
+	w.Peer = PeerId(peer)
+	return nil
+}
+
+// writeFrame writes a frame in reliable ordered channel format
+func writeFrame(w io.Writer, data []byte) error {
+	frame := binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64+len(data)), uint64(len(data)))
+	_, err := w.Write(append(frame, data...))
+	return err
+}
+
+// readFrame reads exactly one frame without buffering, so the stream can be handed over to channelConn
+func readFrame(r io.Reader, limit int) ([]byte, error) {
+	length, err := binary.ReadUvarint(byteReader{r})
+	if err != nil {
+		return nil, err
+	}
+	if length > uint64(limit) {
+		return nil, ErrMessageTooLarge
+	}
+
+	data := make([]byte, length)
+	_, err = io.ReadFull(r, data)
+	return data, err
+}
+
+type byteReader struct {
+	io.Reader
+}
+
+func (r byteReader) ReadByte() (byte, error) {
+	var b [1]byte
+	_, err := io.ReadFull(r.Reader, b[:])
+	return b[0], err
+}
+
+// rejectReasonOf extracts the reason from the error of a connection closed by the server
+func rejectReasonOf(err error) (RejectReason, bool) {
+	var appErr *quic.ApplicationError
+	if errors.As(err, &appErr) && appErr.Remote && appErr.ErrorCode != 0 && appErr.ErrorCode <= 0xff {
+		return RejectReason(appErr.ErrorCode), true
+	}
+	return RejectNone, false
+}
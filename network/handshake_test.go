@@ -0,0 +1,96 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHelloEncoding(t *testing.T) {
+	hello := Hello{ProtocolVersion: ProtocolVersion, GameVersion: "1.2.3", Token: []byte("secret")}
+	data, err := hello.MarshalBinary()
+	require.NoError(t, err)
+
+	var decoded Hello
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	require.Equal(t, hello, decoded)
+
+	require.ErrorIs(t, decoded.UnmarshalBinary(data[:len(data)-1]), ErrMalformedMessage)
+}
+
+func TestSignedToken(t *testing.T) {
+	key := []byte("matchmaker key")
+	now := time.Unix(1_700_000_000, 0)
+	auth := SignedTokenAuthenticator{Key: key, Now: func() time.Time { return now }}
+
+	token := NewSignedToken(key, "player-42", now.Add(time.Minute))
+	subject, err := auth.Verify(token)
+	require.NoError(t, err)
+	require.Equal(t, "player-42", subject)
+
+	tampered := append([]byte{}, token...)
+	tampered[9] ^= 1
+	_, err = auth.Verify(tampered)
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = auth.Verify(NewSignedToken([]byte("other key"), "player-42", now.Add(time.Minute)))
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = auth.Verify(NewSignedToken(key, "player-42", now.Add(-time.Second)))
+	require.ErrorIs(t, err, ErrTokenExpired)
+}
+
+func TestHandshakeRejects(t *testing.T) {
+	server, serverEvents, _ := startTestServer(t, func(s *QuicServer) {
+		s.GameVersion = "1.0"
+		s.Authenticator = &SharedSecretAuthenticator{Secret: []byte("secret")}
+	})
+
+	tests := []struct {
+		name    string
+		version string
+		token   string
+		reason  RejectReason
+	}{
+		{name: "game version", version: "0.9", token: "secret", reason: RejectGameVersion},
+		{name: "token", version: "1.0", token: "guess", reason: RejectUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := make(chan PeerEvent, 4)
+			client := NewQuicClient()
+			client.Config = DevQuicClientConfig()
+			client.GameVersion = tt.version
+			client.Token = []byte(tt.token)
+			client.OnPeerEvent = func(event PeerEvent) { events <- event }
+
+			client.Connect(server.Addr().String())
+
+			event := waitEvent(t, events)
+			require.Equal(t, PeerRejected, event.Type)
+			var reject *RejectError
+			require.ErrorAs(t, event.Err, &reject)
+			require.Equal(t, tt.reason, reject.Reason)
+		})
+	}
+
+	// Rejected clients are never exposed
+	require.Empty(t, serverEvents)
+	require.Empty(t, server.Peers())
+
+	client, _, _ := connectTestClient(t, server, func(c *QuicClient) {
+		c.GameVersion = "1.0"
+		c.Token = []byte("secret")
+	})
+	connected := waitEvent(t, serverEvents)
+	require.Equal(t, PeerConnected, connected.Type)
+	require.Equal(t, connected.Peer, client.PeerId())
+}
@@ -0,0 +1,404 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package network
+
+import (
+	"cmp"
+	"encoding/binary"
+	"slices"
+	"sync"
+)
+
+const (
+	DefaultInputDelay       = 3
+	DefaultChecksumInterval = 30
+
+	lockstepChecksumHistory = 256 // Ticks own checksums are kept for late reports
+)
+
+// LockstepInput is input of a peer on a tick, the server peer is ServerPeerId
+type LockstepInput struct {
+	Peer PeerId
+	Data []byte
+}
+
+// Lockstep exchanges only inputs, every peer simulates the same ticks with the same inputs.
+// Clients send their input to the server, the server broadcasts inputs of a tick to everyone
+// once all players sent theirs. Peers stall until inputs of the next tick are there.
+//
+// Local input is applied InputDelay ticks later, which hides latency up to InputDelay fixed updates.
+// Players must join before the first tick, late joiners need a state snapshot which is out of scope here.
+type Lockstep struct {
+	InputDelay       uint32
+	ChecksumInterval uint32 // Ticks between checksums, 0 disables them
+
+	// Checksum hashes the game state, e.g. ecs.EntityManager.Checksum. Desyncs are not detected if nil.
+	Checksum func() uint64
+	// OnDesync is called on the server when a peer state differs after the tick.
+	// It may be called from network goroutines.
+	OnDesync func(peer PeerId, tick uint32)
+
+	transport RPCTransport
+
+	mx        sync.Mutex
+	tick      uint32 // Last simulated tick
+	input     []byte
+	sentInput uint32 // Last tick local input was submitted for
+	checked   uint32 // Last tick state was checksummed after
+	frames    map[uint32][]LockstepInput
+	inputs    []LockstepInput
+
+	// Server-side
+	players   map[PeerId]uint32 // First tick inputs of the peer are required for
+	pending   map[uint32]map[PeerId][]byte
+	checksums map[uint32]uint64
+	reports   map[uint32]map[PeerId]uint64
+}
+
+func NewLockstep(transport RPCTransport) *Lockstep {
+	l := &Lockstep{
+		InputDelay:       DefaultInputDelay,
+		ChecksumInterval: DefaultChecksumInterval,
+		transport:        transport,
+		frames:           make(map[uint32][]LockstepInput),
+		players:          make(map[PeerId]uint32),
+		pending:          make(map[uint32]map[PeerId][]byte),
+		checksums:        make(map[uint32]uint64),
+		reports:          make(map[uint32]map[PeerId]uint64),
+	}
+	return l
+}
+
+// NewQuicLockstep runs lockstep over network.Quic. It must be called before Host or Connect,
+// messages reach it on network.Quic.Dispatch.
+// On the server, peer events have to be passed to HandlePeerEvent.
+func NewQuicLockstep() *Lockstep {
+	l := NewLockstep(Quic)
+	Quic.Handle(MessageLockstepInput, l.HandleMessage)
+	Quic.Handle(MessageLockstepFrame, l.HandleMessage)
+	Quic.Handle(MessageLockstepChecksum, l.HandleMessage)
+	return l
+}
+
+// SetInput sets local input of the next tick it is submitted for, the latest call wins
+func (l *Lockstep) SetInput(data []byte) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	l.input = data
+}
+
+// Tick returns the last simulated tick
+func (l *Lockstep) Tick() uint32 {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	return l.tick
+}
+
+// Inputs returns inputs of the current tick ordered by peer. Peers without input on the tick are omitted.
+func (l *Lockstep) Inputs() []LockstepInput {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	return l.inputs
+}
+
+// Advance submits local input and reports whether the next tick may be simulated.
+// When it returns true, the tick becomes current and its Inputs are available.
+func (l *Lockstep) Advance() bool {
+	var (
+		messages [][]byte
+		desyncs  []PeerId
+	)
+
+	l.mx.Lock()
+	server := l.transport.Mode() == ModeServer
+
+	// State after the last tick
+	if l.Checksum != nil && l.ChecksumInterval > 0 && l.tick > 0 && l.tick%l.ChecksumInterval == 0 && l.checked != l.tick {
+		l.checked = l.tick
+		sum := l.Checksum()
+		if server {
+			desyncs = l.recordChecksum(l.tick, sum)
+		} else {
+			messages = append(messages, appendLockstepChecksum(nil, l.tick, sum))
+		}
+	}
+
+	next := l.tick + 1
+	if target := next + l.InputDelay; l.sentInput < target {
+		l.sentInput = target
+		if server {
+			l.addInput(ServerPeerId, target, l.input)
+		} else {
+			messages = append(messages, appendLockstepInput(nil, target, l.input))
+		}
+		l.input = nil
+	}
+
+	var broadcast []byte
+	if server {
+		broadcast = l.complete(next)
+	}
+
+	frame, ok := l.frames[next]
+	if ok {
+		delete(l.frames, next)
+		l.tick = next
+		l.inputs = frame
+	}
+	checked := l.checked
+	l.mx.Unlock()
+
+	for _, msg := range messages {
+		_ = l.transport.Send(ChannelReliableOrdered, msg)
+	}
+	if broadcast != nil {
+		_ = l.transport.Send(ChannelReliableOrdered, broadcast)
+	}
+	l.desync(desyncs, checked)
+	return ok
+}
+
+// AddPeer makes the server wait for inputs of the peer starting from ticks it can reach in time
+func (l *Lockstep) AddPeer(peer PeerId) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	l.players[peer] = l.tick + l.InputDelay + 1
+}
+
+// RemovePeer stops waiting for inputs of the peer
+func (l *Lockstep) RemovePeer(peer PeerId) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	delete(l.players, peer)
+}
+
+// HandlePeerEvent adds connected peers and removes disconnected ones
+func (l *Lockstep) HandlePeerEvent(event PeerEvent) {
+	switch event.Type {
+	case PeerConnected:
+		l.AddPeer(event.Peer)
+	case PeerDisconnected, PeerTimedOut:
+		l.RemovePeer(event.Peer)
+	}
+}
+
+// HandleMessage handles inputs and checksums on the server and frames on clients
+func (l *Lockstep) HandleMessage(msg Message) error {
+	if len(msg.Data) == 0 {
+		return ErrMalformedMessage
+	}
+
+	switch MessageType(msg.Data[0]) {
+	case MessageLockstepInput:
+		tick, data, err := readLockstepInput(msg.Data)
+		if err != nil {
+			return err
+		}
+		l.mx.Lock()
+		defer l.mx.Unlock()
+		// Clients never run ahead of the server, so input is either in time or useless
+		if _, ok := l.players[msg.Peer]; ok && tick > l.tick && tick <= l.tick+l.InputDelay+1 {
+			l.addInput(msg.Peer, tick, data)
+		}
+		return nil
+
+	case MessageLockstepFrame:
+		tick, inputs, err := readLockstepFrame(msg.Data)
+		if err != nil {
+			return err
+		}
+		l.mx.Lock()
+		defer l.mx.Unlock()
+		if tick > l.tick {
+			l.frames[tick] = inputs
+		}
+		return nil
+
+	case MessageLockstepChecksum:
+		tick, sum, err := readLockstepChecksum(msg.Data)
+		if err != nil {
+			return err
+		}
+		l.mx.Lock()
+		var desynced bool
+		if own, ok := l.checksums[tick]; ok {
+			desynced = own != sum
+		} else if tick > l.checked && tick <= l.tick {
+			// The server simulated the tick, but has not hashed it yet
+			if l.reports[tick] == nil {
+				l.reports[tick] = make(map[PeerId]uint64)
+			}
+			l.reports[tick][msg.Peer] = sum
+		}
+		l.mx.Unlock()
+
+		if desynced {
+			l.desync([]PeerId{msg.Peer}, tick)
+		}
+		return nil
+
+	default:
+		return ErrMalformedMessage
+	}
+}
+
+func (l *Lockstep) desync(peers []PeerId, tick uint32) {
+	if l.OnDesync == nil {
+		return
+	}
+	for _, peer := range peers {
+		l.OnDesync(peer, tick)
+	}
+}
+
+// addInput is called with the lock held
+func (l *Lockstep) addInput(peer PeerId, tick uint32, data []byte) {
+	inputs := l.pending[tick]
+	if inputs == nil {
+		inputs = make(map[PeerId][]byte)
+		l.pending[tick] = inputs
+	}
+	inputs[peer] = data
+}
+
+// complete builds the frame of the tick when all players sent their inputs and returns it encoded
+func (l *Lockstep) complete(tick uint32) []byte {
+	if _, ok := l.frames[tick]; ok {
+		return nil
+	}
+
+	inputs := l.pending[tick]
+	if tick > l.InputDelay {
+		if _, ok := inputs[ServerPeerId]; !ok {
+			return nil
+		}
+	}
+	for peer, since := range l.players {
+		if _, ok := inputs[peer]; !ok && since <= tick {
+			return nil
+		}
+	}
+
+	frame := make([]LockstepInput, 0, len(inputs))
+	for peer, data := range inputs {
+		if len(data) > 0 {
+			frame = append(frame, LockstepInput{Peer: peer, Data: data})
+		}
+	}
+	slices.SortFunc(frame, func(a, b LockstepInput) int {
+		return cmp.Compare(a.Peer, b.Peer)
+	})
+
+	delete(l.pending, tick)
+	l.frames[tick] = frame
+	return appendLockstepFrame(nil, tick, frame)
+}
+
+// recordChecksum stores own checksum of the tick and returns peers which reported another one
+func (l *Lockstep) recordChecksum(tick uint32, sum uint64) []PeerId {
+	l.checksums[tick] = sum
+	for t := range l.checksums {
+		if t+lockstepChecksumHistory < tick {
+			delete(l.checksums, t)
+		}
+	}
+
+	var desynced []PeerId
+	for peer, reported := range l.reports[tick] {
+		if reported != sum {
+			desynced = append(desynced, peer)
+		}
+	}
+	delete(l.reports, tick)
+	slices.Sort(desynced)
+	return desynced
+}
+
+func appendLockstepInput(dst []byte, tick uint32, data []byte) []byte {
+	dst = append(dst, byte(MessageLockstepInput))
+	dst = binary.AppendUvarint(dst, uint64(tick))
+	return append(dst, data...)
+}
+
+func readLockstepInput(data []byte) (uint32, []byte, error) {
+	tick, n := binary.Uvarint(data[1:])
+	if n <= 0 || tick > uint64(^uint32(0)) {
+		return 0, nil, ErrMalformedMessage
+	}
+	return uint32(tick), data[1+n:], nil
+}
+
+func appendLockstepFrame(dst []byte, tick uint32, inputs []LockstepInput) []byte {
+	dst = append(dst, byte(MessageLockstepFrame))
+	dst = binary.AppendUvarint(dst, uint64(tick))
+	dst = binary.AppendUvarint(dst, uint64(len(inputs)))
+	for _, input := range inputs {
+		dst = binary.AppendVarint(dst, int64(input.Peer))
+		dst = binary.AppendUvarint(dst, uint64(len(input.Data)))
+		dst = append(dst, input.Data...)
+	}
+	return dst
+}
+
+func readLockstepFrame(data []byte) (uint32, []LockstepInput, error) {
+	data = data[1:]
+	tick, n := binary.Uvarint(data)
+	if n <= 0 || tick > uint64(^uint32(0)) {
+		return 0, nil, ErrMalformedMessage
+	}
+	data = data[n:]
+
+	count, n := binary.Uvarint(data)
+	if n <= 0 || count > uint64(len(data)) {
+		return 0, nil, ErrMalformedMessage
+	}
+	data = data[n:]
+
+	inputs := make([]LockstepInput, count)
+	for i := range inputs {
+		peer, n := binary.Varint(data)
+		if n <= 0 {
+			return 0, nil, ErrMalformedMessage
+		}
+		data = data[n:]
+
+		size, n := binary.Uvarint(data)
+		if n <= 0 || size > uint64(len(data)-n) {
+			return 0, nil, ErrMalformedMessage
+		}
+		data = data[n:]
+
+		inputs[i] = LockstepInput{Peer: PeerId(peer), Data: data[:size:size]}
+		data = data[size:]
+	}
+	if len(data) != 0 {
+		return 0, nil, ErrMalformedMessage
+	}
+	return uint32(tick), inputs, nil
+}
+
+func appendLockstepChecksum(dst []byte, tick uint32, sum uint64) []byte {
+	dst = append(dst, byte(MessageLockstepChecksum))
+	dst = binary.AppendUvarint(dst, uint64(tick))
+	return binary.BigEndian.AppendUint64(dst, sum)
+}
+
+func readLockstepChecksum(data []byte) (uint32, uint64, error) {
+	tick, n := binary.Uvarint(data[1:])
+	if n <= 0 || tick > uint64(^uint32(0)) || len(data) != 1+n+8 {
+		return 0, 0, ErrMalformedMessage
+	}
+	return uint32(tick), binary.BigEndian.Uint64(data[1+n:]), nil
+}
@@ -0,0 +1,145 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package network
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// lockstepHub delivers messages between lockstep peers synchronously
+type lockstepHub struct {
+	server  *Lockstep
+	clients map[PeerId]*Lockstep
+}
+
+type lockstepTransport struct {
+	hub  *lockstepHub
+	peer PeerId
+}
+
+func (t *lockstepTransport) Mode() Mode {
+	if t.peer == ServerPeerId {
+		return ModeServer
+	}
+	return ModeClient
+}
+
+func (t *lockstepTransport) Send(ch Channel, data []byte) error {
+	if t.peer != ServerPeerId {
+		return t.hub.server.HandleMessage(Message{Peer: t.peer, Channel: ch, Data: data})
+	}
+	for peer := range t.hub.clients {
+		if err := t.SendTo(peer, ch, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *lockstepTransport) SendTo(peer PeerId, ch Channel, data []byte) error {
+	return t.hub.clients[peer].HandleMessage(Message{Peer: ServerPeerId, Channel: ch, Data: data})
+}
+
+func newLockstepHub(clients ...PeerId) *lockstepHub {
+	hub := &lockstepHub{clients: make(map[PeerId]*Lockstep)}
+	hub.server = NewLockstep(&lockstepTransport{hub: hub, peer: ServerPeerId})
+	for _, peer := range clients {
+		hub.clients[peer] = NewLockstep(&lockstepTransport{hub: hub, peer: peer})
+		hub.server.AddPeer(peer)
+	}
+	return hub
+}
+
+type lockstepHistory map[uint32][]LockstepInput
+
+func (h lockstepHistory) advance(l *Lockstep, input byte) bool {
+	l.SetInput([]byte{input})
+	if !l.Advance() {
+		return false
+	}
+	h[l.Tick()] = l.Inputs()
+	return true
+}
+
+func TestLockstep(t *testing.T) {
+	hub := newLockstepHub(1, 2)
+	server, a, b := hub.server, hub.clients[1], hub.clients[2]
+	serverHistory, aHistory, bHistory := lockstepHistory{}, lockstepHistory{}, lockstepHistory{}
+
+	// Nobody has input on the first InputDelay ticks, so they never stall
+	for range DefaultInputDelay {
+		require.True(t, serverHistory.advance(server, 'S'))
+	}
+	require.True(t, aHistory.advance(a, 'A'))
+
+	// Tick 4 waits for input of b
+	require.False(t, serverHistory.advance(server, 'S'))
+	require.True(t, bHistory.advance(b, 'B'))
+	require.True(t, serverHistory.advance(server, 'S'))
+	require.Equal(t, uint32(4), server.Tick())
+	require.Equal(t, []LockstepInput{
+		{Peer: ServerPeerId, Data: []byte{'S'}},
+		{Peer: 1, Data: []byte{'A'}},
+		{Peer: 2, Data: []byte{'B'}},
+	}, server.Inputs())
+
+	// Clients catch up and simulate the same ticks
+	for range 3 {
+		aHistory.advance(a, 'A')
+		bHistory.advance(b, 'B')
+	}
+	require.Equal(t, uint32(4), a.Tick())
+	require.Equal(t, serverHistory, aHistory)
+	require.Equal(t, serverHistory, bHistory)
+
+	// Disconnected peers are not waited for
+	hub.server.HandlePeerEvent(PeerEvent{Peer: 2, Type: PeerDisconnected})
+	delete(hub.clients, 2)
+	for range 10 {
+		aHistory.advance(a, 'A')
+		serverHistory.advance(server, 'S')
+	}
+	require.Equal(t, uint32(14), server.Tick())
+}
+
+func TestLockstepDesync(t *testing.T) {
+	hub := newLockstepHub(0, 1)
+	var desyncs []PeerId
+	hub.server.ChecksumInterval = 2
+	hub.server.Checksum = func() uint64 { return 42 }
+	hub.server.OnDesync = func(peer PeerId, tick uint32) {
+		require.Equal(t, uint32(2), tick)
+		desyncs = append(desyncs, peer)
+	}
+	for peer, client := range hub.clients {
+		client.ChecksumInterval = 2
+		client.Checksum = func() uint64 { return 42 + uint64(peer) }
+	}
+
+	for range 3 {
+		for _, l := range []*Lockstep{hub.server, hub.clients[0], hub.clients[1]} {
+			l.Advance()
+		}
+	}
+	require.Equal(t, []PeerId{1}, desyncs)
+}
+
+func TestLockstepMessages(t *testing.T) {
+	inputs := []LockstepInput{{Peer: ServerPeerId, Data: []byte("jump")}, {Peer: 7, Data: []byte{1}}}
+	tick, decoded, err := readLockstepFrame(appendLockstepFrame(nil, 300, inputs))
+	require.NoError(t, err)
+	require.Equal(t, uint32(300), tick)
+	require.Equal(t, inputs, decoded)
+
+	frame := appendLockstepFrame(nil, 1, inputs)
+	_, _, err = readLockstepFrame(frame[:len(frame)-1])
+	require.ErrorIs(t, err, ErrMalformedMessage)
+	_, _, err = readLockstepChecksum(appendLockstepChecksum(nil, 5, 9)[:5])
+	require.ErrorIs(t, err, ErrMalformedMessage)
+}
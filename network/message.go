@@ -0,0 +1,75 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package network
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// MessageType is the first byte of every game message
+type MessageType uint8
+
+const (
+	MessageInvalid MessageType = iota
+	MessageSpawn
+	MessageDespawn
+	MessageRPC
+	MessageRoomJoin
+	MessageRoomLeave
+	MessageRoomStatus
+	MessageLockstepInput
+	MessageLockstepFrame
+	MessageLockstepChecksum
+	MessagePatch // Followed by ecs.Patch binary
+)
+
+var ErrMalformedMessage = errors.New("malformed message")
+
+// AppendIdsMessage encodes a message carrying a list of network entity ids, e.g. MessageSpawn
+func AppendIdsMessage[T ~int32](dst []byte, messageType MessageType, ids []T) []byte {
+	dst = append(dst, byte(messageType))
+	dst = binary.AppendUvarint(dst, uint64(len(ids)))
+	for _, id := range ids {
+		dst = binary.AppendVarint(dst, int64(id))
+	}
+	return dst
+}
+
+// ReadIdsMessage decodes a message encoded with AppendIdsMessage, ids are appended to dst
+func ReadIdsMessage[T ~int32](data []byte, dst []T) (MessageType, []T, error) {
+	if len(data) == 0 {
+		return MessageInvalid, dst, ErrMalformedMessage
+	}
+	messageType := MessageType(data[0])
+	data = data[1:]
+
+	count, n := binary.Uvarint(data)
+	if n <= 0 || count > uint64(len(data)) {
+		return messageType, dst, ErrMalformedMessage
+	}
+	data = data[n:]
+
+	for range count {
+		id, n := binary.Varint(data)
+		if n <= 0 {
+			return messageType, dst, ErrMalformedMessage
+		}
+		data = data[n:]
+		dst = append(dst, T(id))
+	}
+
+	return messageType, dst, nil
+}
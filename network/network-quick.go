@@ -17,87 +17,335 @@ package network
 import (
 	"github.com/negrel/assert"
 	"github.com/quic-go/quic-go"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 var Quic = &QuicNetwork{}
 
+// DefaultInboxLimit is how many messages a channel keeps until Receive reads them
+const DefaultInboxLimit = 4096
+
 type QuicNetwork struct {
+	// mx guards mode, server and client, they are read by stats handlers on other goroutines
+	mx   sync.RWMutex
 	mode Mode
 
+	// Settings applied on Host and Connect, defaults are used for nil configs
+	ServerConfig  *QuicServerConfig
+	ClientConfig  *QuicClientConfig
+	GameVersion   string
+	Token         []byte
+	Authenticator Authenticator
+	// InboxLimit caps messages kept per channel for Receive and kept for Dispatch, DefaultInboxLimit if 0.
+	// Messages arriving to a full inbox are dropped, so unread messages do not grow forever.
+	InboxLimit int
+
 	// Server-side
 	server *QuicServer
 	client *QuicClient
+
+	components componentStats
+
+	inbox    [channelsCount]queue[Message]
+	handled  queue[Message] // Messages waiting for Dispatch to invoke their handlers
+	events   queue[PeerEvent]
+	handlers [256]func(Message) error
+	recorder atomic.Pointer[DemoRecorder]
+}
+
+// queue collects values produced on network goroutines until game code reads them
+type queue[T any] struct {
+	mx     sync.Mutex
+	values []T
+	head   int
+}
+
+func (q *queue[T]) push(value T) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	q.values = append(q.values, value)
+}
+
+// pushLimited pushes the value unless limit values are already waiting
+func (q *queue[T]) pushLimited(value T, limit int) bool {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	if len(q.values)-q.head >= limit {
+		return false
+	}
+	q.values = append(q.values, value)
+	return true
+}
+
+func (q *queue[T]) pop() (value T, ok bool) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+
+	if q.head == len(q.values) {
+		return value, false
+	}
+
+	value = q.values[q.head]
+	q.values[q.head] = *new(T)
+	q.head++
+	if q.head == len(q.values) {
+		q.values = q.values[:0]
+		q.head = 0
+	}
+	return value, true
 }
 
 // Host is Server-side method to host the server
 func (n *QuicNetwork) Host(addr string) {
+	n.mx.Lock()
+	defer n.mx.Unlock()
 	assert.True(n.mode == ModeNone, "QuicNetwork is already in server mode")
 
-	n.server = NewQuicServer()
-	go n.server.Run(addr)
+	server := NewQuicServer()
+	server.OnMessage = n.receive
+	server.OnPeerEvent = n.events.push
+	server.GameVersion = n.GameVersion
+	server.Authenticator = n.Authenticator
+	if n.ServerConfig != nil {
+		server.Config = *n.ServerConfig
+	}
+	err := server.Start(addr)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	n.server = server
 	n.mode = ModeServer
 }
 
 // Stop is Server-side method to Stop the server
 func (n *QuicNetwork) Stop() {
+	n.mx.Lock()
 	assert.True(n.mode == ModeServer, "QuicNetwork is not in server mode")
-
-	n.server.Stop()
+	server := n.server
 	n.server = nil
 	n.mode = ModeNone
+	n.mx.Unlock()
+
+	if server != nil {
+		server.Stop()
+	}
 }
 
 // Connect is Client-side method to connect to the server
 func (n *QuicNetwork) Connect(addr string) {
+	n.mx.Lock()
+	defer n.mx.Unlock()
 	assert.True(n.mode == ModeNone, "QuicNetwork is already in use.")
 
-	n.client = NewQuicClient()
-	go n.client.Connect(addr)
+	client := NewQuicClient()
+	client.OnMessage = n.receive
+	client.OnPeerEvent = n.events.push
+	client.GameVersion = n.GameVersion
+	client.Token = n.Token
+	if n.ClientConfig != nil {
+		client.Config = *n.ClientConfig
+	}
+	n.client = client
 	n.mode = ModeClient
+	go func() {
+		client.Connect(addr)
+		n.clientClosed(client)
+	}()
+}
+
+// clientClosed leaves client mode once the connection of the client ended or failed, so Connect can be called again
+func (n *QuicNetwork) clientClosed(client *QuicClient) {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+	if n.client != client {
+		return
+	}
+	n.client = nil
+	n.mode = ModeNone
 }
 
 // Disconnect is Client-side method to disconnect from the server
 func (n *QuicNetwork) Disconnect() {
+	n.mx.Lock()
 	assert.True(n.mode == ModeClient, "QuicNetwork is not in client mode")
-
-	n.client.Disconnect()
+	client := n.client
 	n.client = nil
 	n.mode = ModeNone
+	n.mx.Unlock()
+
+	if client != nil {
+		client.Disconnect()
+	}
 }
 
 func (n *QuicNetwork) Mode() Mode {
-	return n.mode
+	mode, _, _ := n.state()
+	return mode
+}
+
+// state reads mode, server and client at once, the returned ones stay usable after Stop or Disconnect
+func (n *QuicNetwork) state() (Mode, *QuicServer, *QuicClient) {
+	n.mx.RLock()
+	defer n.mx.RUnlock()
+	return n.mode, n.server, n.client
 }
 
 // Send is both Server-side and Client-side method to send data to all peers
-func (n *QuicNetwork) Send(data []byte, streamId StreamId, streamIds ...StreamId) error {
-	assert.True(n.mode != ModeNone, "QuicNetwork is not in use")
+func (n *QuicNetwork) Send(ch Channel, data []byte) error {
+	mode, server, client := n.state()
+	assert.True(mode != ModeNone, "QuicNetwork is not in use")
 
-	switch n.mode {
+	switch mode {
 	case ModeNone:
 		return quic.ErrTransportClosed
 	case ModeServer:
-		n.server.Broadcast(data, streamId, streamIds...)
+		server.Broadcast(ch, data)
 		return nil
 	case ModeClient:
-		n.client.Send(data, streamId, streamIds...)
-		return nil
+		return client.Send(ch, data)
 	default:
 		panic("QuicNetwork is in unknown mode")
 	}
 }
 
 // SendTo is Server-side method to send data to a specific peer
-func (n *QuicNetwork) SendTo(peer PeerId, data []byte, streamId StreamId, streamIds ...StreamId) error {
-	assert.True(n.mode == ModeServer, "QuicNetwork is not in server mode")
+func (n *QuicNetwork) SendTo(peer PeerId, ch Channel, data []byte) error {
+	mode, server, _ := n.state()
+	assert.True(mode == ModeServer, "QuicNetwork is not in server mode")
+	if server == nil {
+		return quic.ErrTransportClosed
+	}
 
-	n.server.Send(data, peer, streamId, streamIds...)
+	return server.Send(peer, ch, data)
+}
 
-	return nil
+// Peers is Server-side method returning connected peers
+func (n *QuicNetwork) Peers() []PeerId {
+	mode, server, _ := n.state()
+	if mode != ModeServer {
+		return nil
+	}
+	return server.Peers()
 }
 
-// Receive is both Server-side and Client-side method to receive data from all peers
-func (n *QuicNetwork) Receive(ch Channel) ([]byte, error) {
-	//TODO implement me
-	panic("implement me")
+// Receive is both Server-side and Client-side method to receive data from all peers.
+// Returns false when there are no more messages on the channel.
+func (n *QuicNetwork) Receive(ch Channel) (Message, bool) {
+	if ch < 0 || ch >= channelsCount {
+		return Message{}, false
+	}
+	msg, ok := n.inbox[ch].pop()
+	if ok {
+		n.record(msg)
+	}
+	return msg, ok
+}
+
+// Dispatch invokes handlers of received messages. It should be called once per tick on the game goroutine,
+// so handlers run on the tick they are recorded on.
+func (n *QuicNetwork) Dispatch() {
+	for {
+		msg, ok := n.handled.pop()
+		if !ok {
+			return
+		}
+		n.record(msg)
+		if err := n.handlers[msg.Data[0]](msg); err != nil {
+			log.Printf("Message from peer %d: %v", msg.Peer, err)
+		}
+	}
+}
+
+// Handle routes messages of the type to the handler called by Dispatch instead of Receive.
+// It must be set before Host or Connect.
+func (n *QuicNetwork) Handle(messageType MessageType, handler func(Message) error) {
+	n.handlers[messageType] = handler
+}
+
+// Record writes received messages and peer events to the recorder on the tick game code reads them,
+// by Receive, Dispatch and PollPeerEvent. Nil stops recording.
+func (n *QuicNetwork) Record(recorder *DemoRecorder) {
+	n.recorder.Store(recorder)
+}
+
+// Play routes messages and peer events released by player.Tick through handlers,
+// Receive and PollPeerEvent as if they came from the network
+func (n *QuicNetwork) Play(player *DemoPlayer) {
+	player.OnMessage = n.receive
+	player.OnPeerEvent = n.events.push
+}
+
+func (n *QuicNetwork) receive(msg Message) {
+	if msg.Channel < 0 || msg.Channel >= channelsCount {
+		return
+	}
+
+	limit := n.InboxLimit
+	if limit == 0 {
+		limit = DefaultInboxLimit
+	}
+
+	if len(msg.Data) > 0 && n.handlers[msg.Data[0]] != nil {
+		n.handled.pushLimited(msg, limit)
+		return
+	}
+	n.inbox[msg.Channel].pushLimited(msg, limit)
+}
+
+func (n *QuicNetwork) record(msg Message) {
+	if recorder := n.recorder.Load(); recorder != nil {
+		recorder.RecordMessage(msg)
+	}
+}
+
+// PollPeerEvent returns the next peer connection event, false when there are no more events
+func (n *QuicNetwork) PollPeerEvent() (PeerEvent, bool) {
+	event, ok := n.events.pop()
+	if recorder := n.recorder.Load(); ok && recorder != nil {
+		recorder.RecordPeerEvent(event)
+	}
+	return event, ok
+}
+
+// Stats returns traffic counters of connected peers and bandwidth of replicated components
+func (n *QuicNetwork) Stats() Stats {
+	var stats Stats
+	switch mode, server, client := n.state(); mode {
+	case ModeServer:
+		stats = server.Stats()
+	case ModeClient:
+		stats = client.Stats()
+	}
+	stats.Components = n.components.snapshot()
+	return stats
+}
+
+// RecordComponentSent accounts encoded bytes of a component sent by replication systems
+func (n *QuicNetwork) RecordComponentSent(component uint16, bytes int) {
+	n.components.get(component).bytesSent.Add(uint64(bytes))
+}
+
+// RecordComponentReceived accounts encoded bytes of a component received by replication systems
+func (n *QuicNetwork) RecordComponentReceived(component uint16, bytes int) {
+	n.components.get(component).bytesReceived.Add(uint64(bytes))
+}
+
+// RTT returns round trip time to the peer, on client side the peer is always the server
+func (n *QuicNetwork) RTT(peer PeerId) time.Duration {
+	switch mode, server, client := n.state(); mode {
+	case ModeServer:
+		p, ok := server.Peer(peer)
+		if !ok {
+			return 0
+		}
+		return p.RTT()
+	case ModeClient:
+		return client.RTT()
+	default:
+		return 0
+	}
 }
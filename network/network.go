@@ -30,13 +30,13 @@ type AnyNetwork interface {
 	Disconnect()
 	Mode() Mode
 	Send(ch Channel, data []byte) error
-	Receive(ch Channel) ([]byte, error)
+	Receive(ch Channel) (Message, bool)
 }
 
 type AnyClient interface {
 	Disconnect()
 	Send(ch Channel, data []byte) error
-	Receive(ch Channel) ([]byte, error)
+	Receive(ch Channel) (Message, bool)
 }
 
 type AnyServer struct {
@@ -0,0 +1,53 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package network
+
+import "time"
+
+const (
+	DefaultHeartbeatInterval = time.Second
+	DefaultPeerTimeout       = 10 * time.Second
+)
+
+type PeerEventType uint8
+
+const (
+	PeerConnected PeerEventType = iota + 1
+	PeerDisconnected
+	PeerTimedOut
+	PeerRejected // Client-side only, Err is *RejectError
+)
+
+func (t PeerEventType) String() string {
+	switch t {
+	case PeerConnected:
+		return "connected"
+	case PeerDisconnected:
+		return "disconnected"
+	case PeerTimedOut:
+		return "timed out"
+	case PeerRejected:
+		return "rejected"
+	default:
+		return "unknown"
+	}
+}
+
+// PeerEvent notifies about peer connection lifecycle
+type PeerEvent struct {
+	Peer PeerId
+	Type PeerEventType
+	Err  error // Reason of disconnection, if any
+}
@@ -16,46 +16,131 @@ package network
 
 import (
 	"context"
-	"crypto/tls"
+	"errors"
 	"github.com/quic-go/quic-go"
-	"github.com/quic-go/quic-go/qlog"
-	"io"
 	"log"
+	"net"
+	"sync/atomic"
+	"time"
 )
 
+// ServerPeerId is a peer id of messages received by QuicClient. It is the zero value,
+// so zero value ownership fields mean the server. QuicServer gives clients ids from 1.
+const ServerPeerId PeerId = 0
+
 type QuicClient struct {
-	conn    quic.Connection
-	streams []quic.Stream
+	conn    atomic.Pointer[quic.Connection]
+	channel atomic.Pointer[channelConn]
+	id      atomic.Int64
+
+	Config            QuicClientConfig
+	Channels          ChannelConfigs
+	HeartbeatInterval time.Duration
+	Timeout           time.Duration // Connection is dropped if nothing was received from the server for this long
+	HandshakeTimeout  time.Duration
+
+	GameVersion string
+	Token       []byte // Checked by server Authenticator
 
-	stopSignal chan empty
+	// OnMessage and OnPeerEvent are called from network goroutines
+	OnMessage   func(Message)
+	OnPeerEvent func(PeerEvent)
 }
 
 func NewQuicClient() *QuicClient {
-	return &QuicClient{}
+	return &QuicClient{
+		Config:            DefaultQuicClientConfig(),
+		Channels:          DefaultChannelConfigs(),
+		HeartbeatInterval: DefaultHeartbeatInterval,
+		Timeout:           DefaultPeerTimeout,
+		HandshakeTimeout:  DefaultHandshakeTimeout,
+		OnMessage: func(msg Message) {
+			log.Printf("Message from server on channel %d: %d bytes", msg.Channel, len(msg.Data))
+		},
+		OnPeerEvent: func(event PeerEvent) {
+			log.Printf("Server %s", event.Type)
+		},
+	}
 }
 
 func (c *QuicClient) Disconnect() {
-	err := c.conn.CloseWithError(0, "Connection closed")
+	conn := c.conn.Load()
+	if conn == nil {
+		return
+	}
+
+	err := (*conn).CloseWithError(0, "Connection closed")
 	if err != nil {
 		log.Println(err)
 		return
 	}
 }
 
+// RTT returns round trip time to the server measured by heartbeats
+func (c *QuicClient) RTT() time.Duration {
+	channel := c.channel.Load()
+	if channel == nil {
+		return 0
+	}
+	return channel.RTT()
+}
+
+// Stats returns traffic counters of the connection to the server, empty when not connected
+func (c *QuicClient) Stats() Stats {
+	channel := c.channel.Load()
+	if channel == nil {
+		return Stats{}
+	}
+	return Stats{Peers: []PeerStats{channel.Stats()}}
+}
+
+// Connect blocks until the connection is closed.
+// A connection which could not be established is reported as PeerDisconnected with the error.
 func (c *QuicClient) Connect(addr string) {
-	quicCfg := quic.Config{
-		Tracer: qlog.DefaultConnectionTracer,
+	tlsConf, err := c.Config.tlsConfig(addr)
+	if err != nil {
+		c.fail(err)
+		return
+	}
+
+	conn, err := quic.DialAddr(context.Background(), addr, tlsConf, c.Config.Quic.config())
+	if err != nil {
+		c.fail(err)
+		return
 	}
-	tlsConf := &tls.Config{
-		InsecureSkipVerify: true,
-		NextProtos:         []string{"quic-echo-ebiten-ecs"},
+	c.run(conn)
+}
+
+// ConnectOn is like Connect, but dials from the provided connection, e.g. SimulatedPacketConn.
+// The connection is not closed on disconnect.
+func (c *QuicClient) ConnectOn(packetConn net.PacketConn, addr string) {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		c.fail(err)
+		return
 	}
 
-	conn, err := quic.DialAddr(context.Background(), addr, tlsConf, &quicCfg)
+	tlsConf, err := c.Config.tlsConfig(addr)
 	if err != nil {
-		log.Println(err)
+		c.fail(err)
+		return
+	}
+
+	conn, err := quic.Dial(context.Background(), packetConn, udpAddr, tlsConf, c.Config.Quic.config())
+	if err != nil {
+		c.fail(err)
 		return
 	}
+	c.run(conn)
+}
+
+// fail reports a connection which was never established
+func (c *QuicClient) fail(err error) {
+	log.Println(err)
+	c.OnPeerEvent(PeerEvent{Peer: ServerPeerId, Type: PeerDisconnected, Err: err})
+}
+
+func (c *QuicClient) run(conn quic.Connection) {
 	defer func(c quic.Connection) {
 		err := c.CloseWithError(0, "Connection closed")
 		if err != nil {
@@ -63,60 +148,115 @@ func (c *QuicClient) Connect(addr string) {
 		}
 	}(conn)
 
-	c.conn = conn
+	c.conn.Store(&conn)
+	defer c.conn.Store(nil)
 
-	// Opening Main stream
-	mainStream, err := conn.OpenStreamSync(conn.Context())
+	// Opening reliable ordered stream
+	orderedStream, err := conn.OpenStreamSync(conn.Context())
 	if err != nil {
-		panic(err)
+		c.fail(err)
+		return
 	}
 	defer func(str quic.Stream) {
 		err := str.Close()
 		if err != nil {
 			log.Println(err)
 		}
-	}(mainStream)
+	}(orderedStream)
 
-	c.streams = append(c.streams, mainStream)
+	id, err := c.handshake(orderedStream)
+	if err != nil {
+		var reject *RejectError
+		if errors.As(err, &reject) {
+			log.Println(err)
+			c.OnPeerEvent(PeerEvent{Peer: ServerPeerId, Type: PeerRejected, Err: err})
+		} else {
+			c.fail(err)
+		}
+		return
+	}
+	c.id.Store(int64(id))
 
-	c.streamHandler(mainStream)
-}
+	channel := newChannelConn(conn, orderedStream, ServerPeerId, &c.Channels, c.OnMessage)
+	c.channel.Store(channel)
 
-func (c *QuicClient) Send(msg []byte, streamId StreamId, streamIds ...StreamId) {
-	streams := c.streams
+	go channel.receiveUnordered(conn.Context())
+	go channel.receiveDatagrams(conn.Context())
+	go func() {
+		err := channel.heartbeat(conn.Context(), c.HeartbeatInterval, c.Timeout)
+		if errors.Is(err, ErrPeerTimeout) {
+			_ = conn.CloseWithError(0, "Timed out")
+		}
+	}()
 
-	_, err := streams[streamId].Write(msg)
-	if err != nil {
-		log.Println(err)
+	log.Println("Connected to " + conn.RemoteAddr().String())
+	defer log.Println("Disconnected from " + conn.RemoteAddr().String())
+	c.OnPeerEvent(PeerEvent{Peer: ServerPeerId, Type: PeerConnected})
+
+	err = channel.receiveOrdered()
+
+	event := PeerEvent{Peer: ServerPeerId, Type: PeerDisconnected, Err: err}
+	if channel.timedOut.Load() {
+		event.Type = PeerTimedOut
+		event.Err = ErrPeerTimeout
 	}
+	c.channel.Store(nil)
+	c.OnPeerEvent(event)
+}
 
-	for _, id := range streamIds {
-		_, err := streams[id].Write(msg)
-		if err != nil {
-			log.Println(err)
-		}
+// Send sends data to the server over the channel
+func (c *QuicClient) Send(ch Channel, data []byte) error {
+	channel := c.channel.Load()
+	if channel == nil {
+		return ErrNotConnected
 	}
+
+	return channel.send(ch, data)
 }
 
-func (c *QuicClient) streamHandler(stream quic.Stream) {
-	defer func(str quic.Stream) {
-		err := str.Close()
-		if err != nil {
-			log.Println(err)
-		}
-	}(stream)
+// PeerId returns id assigned to this client by the server
+func (c *QuicClient) PeerId() PeerId {
+	return PeerId(c.id.Load())
+}
 
-	log.Println("New stream from " + c.conn.RemoteAddr().String())
-	defer log.Println("Closing stream from " + c.conn.RemoteAddr().String())
+func (c *QuicClient) handshake(stream quic.Stream) (PeerId, error) {
+	hello := Hello{
+		ProtocolVersion: ProtocolVersion,
+		GameVersion:     c.GameVersion,
+		Token:           c.Token,
+	}
+	data, err := hello.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
 
-	// Reader
-	for {
-		msg, err := io.ReadAll(stream)
-		if err != nil {
-			log.Println(err)
-			return
+	// Stream becomes visible to the server only after something is written
+	err = writeFrame(stream, data)
+	if err != nil {
+		return 0, err
+	}
+
+	err = stream.SetReadDeadline(time.Now().Add(c.HandshakeTimeout))
+	if err != nil {
+		return 0, err
+	}
+
+	data, err = readFrame(stream, 16)
+	if err != nil {
+		if reason, ok := rejectReasonOf(err); ok {
+			return 0, &RejectError{Reason: reason}
 		}
+		return 0, err
+	}
 
-		log.Println(string(msg))
+	var reply welcome
+	err = reply.unmarshal(data)
+	if err != nil {
+		return 0, err
 	}
+	if reply.Reason != RejectNone {
+		return 0, &RejectError{Reason: reply.Reason}
+	}
+
+	return reply.Peer, stream.SetReadDeadline(time.Time{})
 }
@@ -0,0 +1,303 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package network
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/logging"
+)
+
+// DefaultALPN is the application protocol negotiated by QuicServer and QuicClient
+const DefaultALPN = "quic-echo-ebiten-ecs"
+
+const (
+	devCertFile = "dev-cert.pem"
+	devKeyFile  = "dev-key.pem"
+)
+
+var ErrCertificatePin = errors.New("server certificate does not match any pinned fingerprint")
+
+// QuicParams are transport parameters shared by server and client. Zero values mean quic-go defaults.
+type QuicParams struct {
+	MaxIdleTimeout        time.Duration
+	KeepAlivePeriod       time.Duration
+	HandshakeIdleTimeout  time.Duration
+	MaxIncomingStreams    int64
+	MaxIncomingUniStreams int64
+
+	// Tracer is disabled by default, set it to qlog.DefaultConnectionTracer to record qlog files
+	Tracer func(context.Context, logging.Perspective, quic.ConnectionID) *logging.ConnectionTracer
+}
+
+func (p *QuicParams) config() *quic.Config {
+	return &quic.Config{
+		MaxIdleTimeout:        p.MaxIdleTimeout,
+		KeepAlivePeriod:       p.KeepAlivePeriod,
+		HandshakeIdleTimeout:  p.HandshakeIdleTimeout,
+		MaxIncomingStreams:    p.MaxIncomingStreams,
+		MaxIncomingUniStreams: p.MaxIncomingUniStreams,
+		Tracer:                p.Tracer,
+		EnableDatagrams:       true,
+	}
+}
+
+// QuicServerConfig sets up server certificate and transport.
+// Certificate is taken from PEM bytes, then from files. Without any of them
+// a self-signed certificate is generated, and persisted to DevCertDir in DevMode.
+type QuicServerConfig struct {
+	CertPEM, KeyPEM   []byte
+	CertFile, KeyFile string
+
+	// DevMode keeps generated certificate between runs, so clients can pin its fingerprint
+	DevMode    bool
+	DevCertDir string
+
+	ALPN []string
+	Quic QuicParams
+}
+
+func DefaultQuicServerConfig() QuicServerConfig {
+	return QuicServerConfig{
+		DevCertDir: ".gomp",
+		ALPN:       []string{DefaultALPN},
+	}
+}
+
+func (c *QuicServerConfig) tlsConfig() (*tls.Config, error) {
+	cert, err := c.certificate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   c.alpn(),
+	}, nil
+}
+
+func (c *QuicServerConfig) certificate() (tls.Certificate, error) {
+	switch {
+	case c.CertPEM != nil || c.KeyPEM != nil:
+		return tls.X509KeyPair(c.CertPEM, c.KeyPEM)
+	case c.CertFile != "" || c.KeyFile != "":
+		return tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	case c.DevMode:
+		return c.devCertificate()
+	}
+
+	certPEM, keyPEM, err := GenerateCertificate()
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+// devCertificate loads the certificate from DevCertDir, generating it on the first run
+func (c *QuicServerConfig) devCertificate() (tls.Certificate, error) {
+	certPath := filepath.Join(c.DevCertDir, devCertFile)
+	keyPath := filepath.Join(c.DevCertDir, devKeyFile)
+
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return tls.Certificate{}, err
+		}
+
+		certPEM, keyPEM, err := GenerateCertificate()
+		if err != nil {
+			return tls.Certificate{}, err
+		}
+		if err = os.MkdirAll(c.DevCertDir, 0o700); err != nil {
+			return tls.Certificate{}, err
+		}
+		if err = os.WriteFile(certPath, certPEM, 0o644); err != nil {
+			return tls.Certificate{}, err
+		}
+		if err = os.WriteFile(keyPath, keyPEM, 0o600); err != nil {
+			return tls.Certificate{}, err
+		}
+
+		cert, err = tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return tls.Certificate{}, err
+		}
+	}
+
+	log.Printf("Dev certificate %s, fingerprint %s", certPath, CertificateFingerprint(cert.Certificate[0]))
+	return cert, nil
+}
+
+func (c *QuicServerConfig) alpn() []string {
+	if len(c.ALPN) == 0 {
+		return []string{DefaultALPN}
+	}
+	return c.ALPN
+}
+
+// QuicClientConfig sets up verification of the server certificate and transport.
+// When PinnedFingerprints are set, server certificate must match one of them and the chain is not verified,
+// this is the way to trust self-signed certificates of QuicServerConfig.DevMode.
+type QuicClientConfig struct {
+	ServerName         string         // Taken from the dialed address if empty
+	RootCAs            *x509.CertPool // System pool if nil
+	PinnedFingerprints []string       // SHA-256 of server certificates, as printed by CertificateFingerprint
+	InsecureSkipVerify bool           // Trust any server, never use it outside of development
+
+	ALPN []string
+	Quic QuicParams
+}
+
+// DefaultQuicClientConfig verifies the server certificate against the system pool.
+// Servers with self-signed certificates need PinnedFingerprints, RootCAs or DevQuicClientConfig.
+func DefaultQuicClientConfig() QuicClientConfig {
+	return QuicClientConfig{
+		ALPN: []string{DefaultALPN},
+	}
+}
+
+// DevQuicClientConfig trusts any server, so it connects to generated certificates of local servers.
+// Never use it outside of development.
+func DevQuicClientConfig() QuicClientConfig {
+	config := DefaultQuicClientConfig()
+	config.InsecureSkipVerify = true
+	return config
+}
+
+func (c *QuicClientConfig) tlsConfig(addr string) (*tls.Config, error) {
+	serverName := c.ServerName
+	if serverName == "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		serverName = host
+	}
+
+	alpn := c.ALPN
+	if len(alpn) == 0 {
+		alpn = []string{DefaultALPN}
+	}
+
+	conf := &tls.Config{
+		ServerName:         serverName,
+		RootCAs:            c.RootCAs,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+		NextProtos:         alpn,
+	}
+
+	if len(c.PinnedFingerprints) > 0 {
+		pins := make([][]byte, 0, len(c.PinnedFingerprints))
+		for _, fingerprint := range c.PinnedFingerprints {
+			pin, err := parseFingerprint(fingerprint)
+			if err != nil {
+				return nil, err
+			}
+			pins = append(pins, pin)
+		}
+
+		// Chain verification is replaced with the pin check
+		conf.InsecureSkipVerify = true
+		conf.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return ErrCertificatePin
+			}
+			sum := sha256.Sum256(rawCerts[0])
+			for _, pin := range pins {
+				if string(pin) == string(sum[:]) {
+					return nil
+				}
+			}
+			return ErrCertificatePin
+		}
+	}
+
+	return conf, nil
+}
+
+// CertificateFingerprint returns SHA-256 of DER encoded certificate as colon separated hex
+func CertificateFingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = hex.EncodeToString([]byte{b})
+	}
+	return strings.Join(parts, ":")
+}
+
+func parseFingerprint(fingerprint string) ([]byte, error) {
+	pin, err := hex.DecodeString(strings.ReplaceAll(fingerprint, ":", ""))
+	if err != nil || len(pin) != sha256.Size {
+		return nil, fmt.Errorf("invalid certificate fingerprint %q", fingerprint)
+	}
+	return pin, nil
+}
+
+// GenerateCertificate creates a self-signed certificate for localhost valid for a year
+func GenerateCertificate() (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	template := x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "gomp dev server"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.AddDate(1, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
@@ -0,0 +1,83 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package network
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuicServerConfigDevMode(t *testing.T) {
+	config := DefaultQuicServerConfig()
+	config.DevMode = true
+	config.DevCertDir = t.TempDir()
+
+	first, err := config.certificate()
+	require.NoError(t, err)
+	require.FileExists(t, filepath.Join(config.DevCertDir, devKeyFile))
+
+	// Second run reuses the persisted certificate
+	second, err := config.certificate()
+	require.NoError(t, err)
+	require.Equal(t, first.Certificate[0], second.Certificate[0])
+}
+
+func TestQuicServerConfigFiles(t *testing.T) {
+	certPEM, keyPEM, err := GenerateCertificate()
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	config := DefaultQuicServerConfig()
+	config.CertFile = filepath.Join(dir, "cert.pem")
+	config.KeyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(config.CertFile, certPEM, 0o600))
+	require.NoError(t, os.WriteFile(config.KeyFile, keyPEM, 0o600))
+
+	cert, err := config.certificate()
+	require.NoError(t, err)
+
+	block, _ := pem.Decode(certPEM)
+	require.Equal(t, block.Bytes, cert.Certificate[0])
+
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	require.Contains(t, parsed.DNSNames, "localhost")
+}
+
+func TestQuicClientPinning(t *testing.T) {
+	certPEM, keyPEM, err := GenerateCertificate()
+	require.NoError(t, err)
+	block, _ := pem.Decode(certPEM)
+	fingerprint := CertificateFingerprint(block.Bytes)
+
+	server, serverEvents, _ := startTestServer(t, func(s *QuicServer) {
+		s.Config.CertPEM = certPEM
+		s.Config.KeyPEM = keyPEM
+	})
+
+	// Wrong pin fails the TLS handshake, so the client returns without connecting
+	wrong := NewQuicClient()
+	wrong.Config.PinnedFingerprints = []string{CertificateFingerprint([]byte("other"))}
+	wrong.Connect(server.Addr().String())
+	require.Empty(t, serverEvents)
+
+	// Default verification rejects self-signed certificate
+	strict := NewQuicClient()
+	strict.Connect(server.Addr().String())
+	require.Empty(t, serverEvents)
+
+	connectTestClient(t, server, func(c *QuicClient) {
+		c.Config = DefaultQuicClientConfig()
+		c.Config.PinnedFingerprints = []string{fingerprint}
+	})
+	require.Equal(t, PeerConnected, waitEvent(t, serverEvents).Type)
+}
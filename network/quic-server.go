@@ -15,139 +15,230 @@ Thank you for your support!
 package network
 
 import (
+	"cmp"
 	"context"
-	"crypto/rand"
-	"crypto/rsa"
-	"crypto/tls"
-	"crypto/x509"
-	"encoding/pem"
-	"github.com/negrel/assert"
+	"errors"
 	"github.com/quic-go/quic-go"
-	"github.com/quic-go/quic-go/qlog"
-	"io"
 	"log"
-	"math/big"
+	"net"
+	"slices"
+	"sync"
 	"time"
 )
 
-type empty struct{}
-type StreamId int
-
 type QuicServerPeer struct {
 	Id      PeerId
 	conn    quic.Connection
-	streams []quic.Stream
+	channel *channelConn
+}
+
+// RTT returns round trip time to the peer measured by heartbeats
+func (p *QuicServerPeer) RTT() time.Duration {
+	return p.channel.RTT()
+}
+
+// Stats returns traffic counters of the peer
+func (p *QuicServerPeer) Stats() PeerStats {
+	return p.channel.Stats()
 }
 
 type QuicServer struct {
-	listener   *quic.Listener
-	stopSignal chan empty
+	listener *quic.Listener
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	stopOnce sync.Once
 
+	mx         sync.RWMutex
 	nextPeerId PeerId
-
-	peers map[PeerId]*QuicServerPeer
+	peers      map[PeerId]*QuicServerPeer
+	reserved   int // Connected peers and accepted handshakes counted against MaxPeers
+
+	Config            QuicServerConfig
+	Channels          ChannelConfigs
+	HeartbeatInterval time.Duration
+	Timeout           time.Duration // Peer is disconnected if nothing was received from it for this long
+	HandshakeTimeout  time.Duration
+
+	GameVersion   string        // Clients with another version are rejected, any version is accepted if empty
+	Authenticator Authenticator // Every client is accepted if nil
+	MaxPeers      int           // 0 means unlimited
+
+	// OnMessage and OnPeerEvent are called from network goroutines
+	OnMessage   func(Message)
+	OnPeerEvent func(PeerEvent)
 }
 
 func NewQuicServer() *QuicServer {
 	return &QuicServer{
-		stopSignal: make(chan empty),
-		peers:      make(map[PeerId]*QuicServerPeer),
+		peers:             make(map[PeerId]*QuicServerPeer),
+		Config:            DefaultQuicServerConfig(),
+		Channels:          DefaultChannelConfigs(),
+		HeartbeatInterval: DefaultHeartbeatInterval,
+		Timeout:           DefaultPeerTimeout,
+		HandshakeTimeout:  DefaultHandshakeTimeout,
+		OnMessage: func(msg Message) {
+			log.Printf("Message from peer %d on channel %d: %d bytes", msg.Peer, msg.Channel, len(msg.Data))
+		},
+		OnPeerEvent: func(event PeerEvent) {
+			log.Printf("Peer %d %s", event.Peer, event.Type)
+		},
 	}
 }
 
+// Run starts the server and blocks until it is stopped
 func (s *QuicServer) Run(addr string) {
-	quicCfg := quic.Config{
-		Tracer: qlog.DefaultConnectionTracer,
-	}
-
-	listener, err := quic.ListenAddr(addr, generateTLSConfig(), &quicCfg)
+	err := s.Start(addr)
 	if err != nil {
 		log.Println(err)
 		return
 	}
-	defer func(l *quic.Listener) {
-		err := l.Close()
-		if err != nil {
-			log.Println(err)
-		}
-	}(listener)
+	<-s.ctx.Done()
+}
+
+// Start begins listening on addr and accepts peers in background
+func (s *QuicServer) Start(addr string) error {
+	tlsConf, err := s.Config.tlsConfig()
+	if err != nil {
+		return err
+	}
+
+	listener, err := quic.ListenAddr(addr, tlsConf, s.Config.Quic.config())
+	if err != nil {
+		return err
+	}
+
+	s.start(listener)
+	return nil
+}
+
+// StartOn is like Start, but serves on the provided connection, e.g. SimulatedPacketConn.
+// The connection is not closed by Stop.
+func (s *QuicServer) StartOn(conn net.PacketConn) error {
+	tlsConf, err := s.Config.tlsConfig()
+	if err != nil {
+		return err
+	}
+
+	listener, err := quic.Listen(conn, tlsConf, s.Config.Quic.config())
+	if err != nil {
+		return err
+	}
+
+	s.start(listener)
+	return nil
+}
+
+func (s *QuicServer) start(listener *quic.Listener) {
+	s.listener = listener
+	s.ctx, s.cancel = context.WithCancel(context.Background())
 
 	log.Println("Listening on " + listener.Addr().String())
-	defer log.Println("Stopped listening on " + listener.Addr().String())
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	s.wg.Add(1)
+	go s.accept()
+}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-s.stopSignal:
-			return
-		default:
-			conn, err := listener.Accept(ctx)
-			if err != nil {
-				log.Println(err)
-				continue
-			}
+// Addr returns the address server listens on
+func (s *QuicServer) Addr() net.Addr {
+	return s.listener.Addr()
+}
 
-			id := s.createPeerId()
-			peer := &QuicServerPeer{
-				Id:      id,
-				conn:    conn,
-				streams: make([]quic.Stream, 0),
-			}
+// Stop closes the listener and all peers, waiting until their handlers are done
+func (s *QuicServer) Stop() {
+	if s.listener == nil {
+		return
+	}
 
-			s.peers[id] = peer
+	s.stopOnce.Do(func() {
+		// Peers are closed by their handlers, listener goes last as it owns the socket
+		s.cancel()
+		s.wg.Wait()
 
-			go s.peerHandler(peer)
+		err := s.listener.Close()
+		if err != nil {
+			log.Println(err)
 		}
+		log.Println("Stopped listening on " + s.listener.Addr().String())
+	})
+}
+
+// Peers returns ids of all connected peers
+func (s *QuicServer) Peers() []PeerId {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	ids := make([]PeerId, 0, len(s.peers))
+	for id := range s.peers {
+		ids = append(ids, id)
 	}
+	return ids
 }
 
-func (s *QuicServer) Stop() {
-	s.stopSignal <- empty{}
+// Peer returns a connected peer
+func (s *QuicServer) Peer(id PeerId) (*QuicServerPeer, bool) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	peer, ok := s.peers[id]
+	return peer, ok
 }
 
-func (s *QuicServer) Broadcast(msg []byte, streamId StreamId, streamIds ...StreamId) {
+// Stats returns traffic counters of all connected peers
+func (s *QuicServer) Stats() Stats {
+	s.mx.RLock()
+	stats := Stats{Peers: make([]PeerStats, 0, len(s.peers))}
 	for _, peer := range s.peers {
-		streams := peer.streams
+		stats.Peers = append(stats.Peers, peer.Stats())
+	}
+	s.mx.RUnlock()
+
+	slices.SortFunc(stats.Peers, func(a, b PeerStats) int {
+		return cmp.Compare(a.Peer, b.Peer)
+	})
+	return stats
+}
 
-		_, err := streams[streamId].Write(msg)
+// Broadcast sends data to every connected peer
+func (s *QuicServer) Broadcast(ch Channel, data []byte) {
+	for _, id := range s.Peers() {
+		err := s.Send(id, ch, data)
 		if err != nil {
 			log.Println(err)
 		}
-
-		for _, id := range streamIds {
-			_, err := streams[id].Write(msg)
-			if err != nil {
-				log.Println(err)
-			}
-		}
 	}
 }
 
-func (s *QuicServer) Send(msg []byte, peerId PeerId, streamId StreamId, streamIds ...StreamId) {
-	peer, ok := s.peers[peerId]
-	assert.True(ok, "Peer not found")
+// Send sends data to the peer over the channel
+func (s *QuicServer) Send(peerId PeerId, ch Channel, data []byte) error {
+	peer, ok := s.Peer(peerId)
+	if !ok {
+		return ErrNotConnected
+	}
 
-	streams := peer.streams
+	return peer.channel.send(ch, data)
+}
 
-	_, err := streams[streamId].Write(msg)
-	if err != nil {
-		log.Println(err)
-	}
+func (s *QuicServer) accept() {
+	defer s.wg.Done()
 
-	for _, id := range streamIds {
-		_, err := streams[id].Write(msg)
+	for {
+		conn, err := s.listener.Accept(s.ctx)
 		if err != nil {
+			if s.ctx.Err() != nil || errors.Is(err, quic.ErrServerClosed) {
+				return
+			}
 			log.Println(err)
+			continue
 		}
+
+		s.wg.Add(1)
+		go s.peerHandler(conn)
 	}
 }
 
-func (s *QuicServer) peerHandler(peer *QuicServerPeer) {
-	conn := peer.conn
+func (s *QuicServer) peerHandler(conn quic.Connection) {
+	defer s.wg.Done()
 	defer func(c quic.Connection) {
 		err := c.CloseWithError(0, "Sever closed the connection")
 		if err != nil {
@@ -158,78 +249,166 @@ func (s *QuicServer) peerHandler(peer *QuicServerPeer) {
 	log.Println("New connection from " + conn.RemoteAddr().String())
 	defer log.Println("Closing connection from " + conn.RemoteAddr().String())
 
-	ctxWithTimeout, cancel := context.WithTimeout(conn.Context(), 10*time.Second)
+	stop := context.AfterFunc(s.ctx, func() {
+		_ = conn.CloseWithError(0, "Server stopped")
+	})
+	defer stop()
+
+	ctxWithTimeout, cancel := context.WithTimeout(conn.Context(), s.HandshakeTimeout)
 	defer cancel()
 
-	mainStream, err := conn.AcceptStream(ctxWithTimeout)
+	// Client opens the reliable ordered stream right after connecting and sends Hello over it
+	orderedStream, err := conn.AcceptStream(ctxWithTimeout)
 	if err != nil {
 		log.Println(err)
 		return
 	}
-	peer.streams = append(peer.streams, mainStream)
 	cancel()
 
-	go s.streamHandler(conn, mainStream)
+	id, err := s.handshake(conn, orderedStream)
+	if err != nil {
+		log.Printf("Handshake with %s failed: %v", conn.RemoteAddr(), err)
+		return
+	}
+
+	peer := s.addPeer(id, conn, orderedStream)
 
-	for {
-		newStream, err := conn.AcceptStream(conn.Context())
-		if err != nil {
-			log.Println(err)
-			return
+	channel := peer.channel
+	go channel.receiveUnordered(conn.Context())
+	go channel.receiveDatagrams(conn.Context())
+	go func() {
+		err := channel.heartbeat(conn.Context(), s.HeartbeatInterval, s.Timeout)
+		if errors.Is(err, ErrPeerTimeout) {
+			_ = conn.CloseWithError(0, "Timed out")
 		}
+	}()
+
+	err = channel.receiveOrdered()
+	s.removePeer(peer)
 
-		peer.streams = append(peer.streams, newStream)
-		go s.streamHandler(conn, newStream)
+	event := PeerEvent{Peer: peer.Id, Type: PeerDisconnected, Err: err}
+	if channel.timedOut.Load() {
+		event.Type = PeerTimedOut
+		event.Err = ErrPeerTimeout
 	}
+	s.OnPeerEvent(event)
 }
 
-func (s *QuicServer) streamHandler(conn quic.Connection, stream quic.Stream) {
-	defer func(str quic.Stream) {
-		err := str.Close()
-		if err != nil {
-			log.Println(err)
-		}
-	}(stream)
+// handshake reads Hello and replies to it. Peer id is reserved only for accepted clients.
+func (s *QuicServer) handshake(conn quic.Connection, stream quic.Stream) (PeerId, error) {
+	err := stream.SetReadDeadline(time.Now().Add(s.HandshakeTimeout))
+	if err != nil {
+		return 0, err
+	}
 
-	log.Println("New stream from " + conn.RemoteAddr().String())
-	defer log.Println("Closing stream from " + conn.RemoteAddr().String())
+	data, err := readFrame(stream, maxGameVersionLength+maxTokenLength+32)
+	if err != nil {
+		return 0, err
+	}
 
-	for {
-		msg, err := io.ReadAll(stream)
-		if err != nil {
-			log.Println(err)
-			return
-		}
+	reason := s.authenticate(data)
+	if reason != RejectNone {
+		return 0, reject(conn, stream, reason)
+	}
 
-		log.Println(string(msg))
+	// Concurrent handshakes may have taken the last slot after authenticate
+	id, ok := s.createPeerId()
+	if !ok {
+		return 0, reject(conn, stream, RejectServerFull)
 	}
+
+	reply := welcome{Peer: id}
+	err = writeFrame(stream, reply.marshal())
+	if err == nil {
+		err = stream.SetReadDeadline(time.Time{})
+	}
+	if err != nil {
+		s.releasePeerId()
+		return 0, err
+	}
+	return id, nil
 }
 
-func (s *QuicServer) createPeerId() PeerId {
-	id := s.nextPeerId
-	s.nextPeerId++
-	return id
+func reject(conn quic.Connection, stream quic.Stream, reason RejectReason) error {
+	reply := welcome{Reason: reason}
+	_ = writeFrame(stream, reply.marshal())
+	_ = conn.CloseWithError(quic.ApplicationErrorCode(reason), reason.String())
+	return &RejectError{Reason: reason}
 }
 
-func generateTLSConfig() *tls.Config {
-	key, err := rsa.GenerateKey(rand.Reader, 1024)
-	if err != nil {
-		panic(err)
+func (s *QuicServer) authenticate(data []byte) RejectReason {
+	var hello Hello
+	if err := hello.UnmarshalBinary(data); err != nil {
+		return RejectMalformed
 	}
-	template := x509.Certificate{SerialNumber: big.NewInt(1)}
-	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
-	if err != nil {
-		panic(err)
+	if hello.ProtocolVersion != ProtocolVersion {
+		return RejectProtocolVersion
+	}
+	if s.GameVersion != "" && hello.GameVersion != s.GameVersion {
+		return RejectGameVersion
 	}
-	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
-	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	// Full servers do not bother the authenticator, the slot itself is taken by createPeerId
+	if s.isFull() {
+		return RejectServerFull
+	}
+	if s.Authenticator != nil {
+		err := s.Authenticator.Authenticate(&hello)
+		if err != nil {
+			var reject *RejectError
+			if errors.As(err, &reject) {
+				return reject.Reason
+			}
+			return RejectUnauthorized
+		}
+	}
+	return RejectNone
+}
 
-	tlsCert, err := tls.X509KeyPair(certPEM, keyPEM)
-	if err != nil {
-		panic(err)
+func (s *QuicServer) isFull() bool {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.MaxPeers > 0 && s.reserved >= s.MaxPeers
+}
+
+// createPeerId reserves a slot of MaxPeers, it is given back by removePeer or releasePeerId
+func (s *QuicServer) createPeerId() (PeerId, bool) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if s.MaxPeers > 0 && s.reserved >= s.MaxPeers {
+		return 0, false
 	}
-	return &tls.Config{
-		Certificates: []tls.Certificate{tlsCert},
-		NextProtos:   []string{"quic-echo-ebiten-ecs"},
+	s.reserved++
+
+	// Ids start at 1, 0 is ServerPeerId
+	s.nextPeerId++
+	return s.nextPeerId, true
+}
+
+// releasePeerId gives back the slot of a client which failed the handshake after createPeerId
+func (s *QuicServer) releasePeerId() {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.reserved--
+}
+
+func (s *QuicServer) addPeer(id PeerId, conn quic.Connection, ordered quic.Stream) *QuicServerPeer {
+	s.mx.Lock()
+	peer := &QuicServerPeer{
+		Id:      id,
+		conn:    conn,
+		channel: newChannelConn(conn, ordered, id, &s.Channels, s.OnMessage),
 	}
+	s.peers[id] = peer
+	s.mx.Unlock()
+
+	s.OnPeerEvent(PeerEvent{Peer: id, Type: PeerConnected})
+	return peer
+}
+
+func (s *QuicServer) removePeer(peer *QuicServerPeer) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	delete(s.peers, peer.Id)
+	s.reserved--
 }
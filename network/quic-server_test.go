@@ -0,0 +1,212 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/stretchr/testify/require"
+)
+
+const testTimeout = 5 * time.Second
+
+func startTestServer(t *testing.T, configure ...func(*QuicServer)) (*QuicServer, chan PeerEvent, chan Message) {
+	t.Helper()
+
+	events := make(chan PeerEvent, 16)
+	messages := make(chan Message, 16)
+
+	server := NewQuicServer()
+	server.OnPeerEvent = func(event PeerEvent) { events <- event }
+	server.OnMessage = func(msg Message) { messages <- msg }
+	for _, c := range configure {
+		c(server)
+	}
+
+	require.NoError(t, server.Start("127.0.0.1:0"))
+	t.Cleanup(server.Stop)
+	return server, events, messages
+}
+
+func connectTestClient(t *testing.T, server *QuicServer, configure ...func(*QuicClient)) (*QuicClient, chan PeerEvent, chan Message) {
+	t.Helper()
+
+	events := make(chan PeerEvent, 16)
+	messages := make(chan Message, 16)
+
+	client := NewQuicClient()
+	client.Config = DevQuicClientConfig()
+	client.OnPeerEvent = func(event PeerEvent) { events <- event }
+	client.OnMessage = func(msg Message) { messages <- msg }
+	for _, c := range configure {
+		c(client)
+	}
+
+	go client.Connect(server.Addr().String())
+	t.Cleanup(client.Disconnect)
+
+	require.Equal(t, PeerConnected, waitEvent(t, events).Type)
+	return client, events, messages
+}
+
+func waitEvent(t *testing.T, events chan PeerEvent) PeerEvent {
+	t.Helper()
+
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for peer event")
+		return PeerEvent{}
+	}
+}
+
+func waitMessage(t *testing.T, messages chan Message) Message {
+	t.Helper()
+
+	select {
+	case msg := <-messages:
+		return msg
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for message")
+		return Message{}
+	}
+}
+
+func TestQuicServerPeerLifecycle(t *testing.T) {
+	server, serverEvents, serverMessages := startTestServer(t)
+	client, _, clientMessages := connectTestClient(t, server)
+
+	connected := waitEvent(t, serverEvents)
+	require.Equal(t, PeerConnected, connected.Type)
+	require.Equal(t, []PeerId{connected.Peer}, server.Peers())
+
+	require.NoError(t, client.Send(ChannelReliableOrdered, []byte("hello")))
+	msg := waitMessage(t, serverMessages)
+	require.Equal(t, connected.Peer, msg.Peer)
+	require.Equal(t, "hello", string(msg.Data))
+
+	require.NoError(t, server.Send(connected.Peer, ChannelReliableUnordered, []byte("world")))
+	msg = waitMessage(t, clientMessages)
+	require.Equal(t, ServerPeerId, msg.Peer)
+	require.Equal(t, ChannelReliableUnordered, msg.Channel)
+	require.Equal(t, "world", string(msg.Data))
+
+	client.Disconnect()
+
+	disconnected := waitEvent(t, serverEvents)
+	require.Equal(t, PeerDisconnected, disconnected.Type)
+	require.Equal(t, connected.Peer, disconnected.Peer)
+	require.Empty(t, server.Peers())
+	require.ErrorIs(t, server.Send(connected.Peer, ChannelReliableOrdered, nil), ErrNotConnected)
+}
+
+func TestQuicServerHeartbeat(t *testing.T) {
+	server, serverEvents, _ := startTestServer(t, func(s *QuicServer) {
+		s.HeartbeatInterval = 20 * time.Millisecond
+	})
+	connectTestClient(t, server, func(c *QuicClient) {
+		c.HeartbeatInterval = time.Hour
+	})
+
+	connected := waitEvent(t, serverEvents)
+	require.Eventually(t, func() bool {
+		peer, ok := server.Peer(connected.Peer)
+		return ok && peer.RTT() > 0
+	}, testTimeout, 10*time.Millisecond)
+}
+
+func TestQuicServerTimeout(t *testing.T) {
+	server, serverEvents, _ := startTestServer(t, func(s *QuicServer) {
+		s.HeartbeatInterval = 20 * time.Millisecond
+		s.Timeout = 200 * time.Millisecond
+	})
+
+	// Raw connection which never answers heartbeats
+	conn, err := quic.DialAddr(
+		context.Background(),
+		server.Addr().String(),
+		&tls.Config{InsecureSkipVerify: true, NextProtos: []string{DefaultALPN}},
+		&quic.Config{EnableDatagrams: true},
+	)
+	require.NoError(t, err)
+	defer conn.CloseWithError(0, "")
+
+	stream, err := conn.OpenStreamSync(context.Background())
+	require.NoError(t, err)
+	hello, err := (&Hello{ProtocolVersion: ProtocolVersion}).MarshalBinary()
+	require.NoError(t, err)
+	require.NoError(t, writeFrame(stream, hello))
+
+	connected := waitEvent(t, serverEvents)
+	require.Equal(t, PeerConnected, connected.Type)
+
+	timedOut := waitEvent(t, serverEvents)
+	require.Equal(t, PeerTimedOut, timedOut.Type)
+	require.Equal(t, connected.Peer, timedOut.Peer)
+	require.Empty(t, server.Peers())
+}
+
+func TestQuicServerStop(t *testing.T) {
+	server, serverEvents, _ := startTestServer(t)
+	_, clientEvents, _ := connectTestClient(t, server)
+	require.Equal(t, PeerConnected, waitEvent(t, serverEvents).Type)
+
+	server.Stop()
+
+	require.Equal(t, PeerDisconnected, waitEvent(t, serverEvents).Type)
+	require.Equal(t, PeerDisconnected, waitEvent(t, clientEvents).Type)
+	require.Empty(t, server.Peers())
+}
+
+func TestQuicServerMaxPeersReserved(t *testing.T) {
+	server := NewQuicServer()
+	server.MaxPeers = 2
+
+	var wg sync.WaitGroup
+	var accepted atomic.Int32
+	for range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, ok := server.createPeerId(); ok {
+				accepted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int32(2), accepted.Load())
+	require.True(t, server.isFull())
+
+	// Failed handshake gives the slot back
+	server.releasePeerId()
+	_, ok := server.createPeerId()
+	require.True(t, ok)
+}
+
+func TestQuicNetworkConnectFailed(t *testing.T) {
+	n := &QuicNetwork{}
+	n.Connect("127.0.0.1:notaport")
+
+	var event PeerEvent
+	require.Eventually(t, func() bool {
+		var ok bool
+		event, ok = n.PollPeerEvent()
+		return ok
+	}, testTimeout, time.Millisecond)
+	require.Equal(t, PeerEvent{Peer: ServerPeerId, Type: PeerDisconnected, Err: event.Err}, event)
+	require.Error(t, event.Err)
+
+	// Failed connection can be retried
+	require.Eventually(t, func() bool { return n.Mode() == ModeNone }, testTimeout, time.Millisecond)
+}
@@ -0,0 +1,324 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package network
+
+import (
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// RPCId identifies a registered RPC on the wire, it is a hash of the RPC name
+type RPCId uint32
+
+type RPCDirection uint8
+
+const (
+	// RPCToServer is called by clients and handled by the server
+	RPCToServer RPCDirection = iota
+	// RPCToClient is called by the server for a single peer or broadcast to every peer
+	RPCToClient
+)
+
+const rpcHeaderSize = 5 // message type, rpc id
+
+var (
+	ErrUnknownRPC   = errors.New("unknown rpc")
+	ErrRateLimited  = errors.New("rpc rate limit exceeded")
+	ErrRPCDirection = errors.New("rpc can not be called in this direction")
+)
+
+// RPCTransport is a part of a network used to send RPC calls, QuicNetwork implements it
+type RPCTransport interface {
+	Mode() Mode
+	Send(ch Channel, data []byte) error
+	SendTo(peer PeerId, ch Channel, data []byte) error
+}
+
+type RPCOption func(*rpcEntry)
+
+// WithRPCDirection sets who handles the RPC, RPCToServer by default
+func WithRPCDirection(direction RPCDirection) RPCOption {
+	return func(e *rpcEntry) {
+		e.direction = direction
+	}
+}
+
+// WithRPCChannel sets channel calls are sent over, ChannelReliableOrdered by default.
+// Use ChannelUnreliableSequenced for calls that are fine to lose, e.g. aiming.
+func WithRPCChannel(ch Channel) RPCOption {
+	return func(e *rpcEntry) {
+		e.channel = ch
+	}
+}
+
+// WithRPCRateLimit limits calls per second from every peer. Calls above the limit are dropped by the receiver
+// and rejected with ErrRateLimited by the sender.
+func WithRPCRateLimit(limit rate.Limit, burst int) RPCOption {
+	return func(e *rpcEntry) {
+		e.limit = limit
+		e.burst = burst
+	}
+}
+
+type rpcEntry struct {
+	id        RPCId
+	name      string
+	direction RPCDirection
+	channel   Channel
+	limit     rate.Limit
+	burst     int
+
+	decode func(data []byte) (any, error)
+	invoke func(peer PeerId, req any)
+
+	limitersMx  sync.Mutex
+	limiters    map[PeerId]*rate.Limiter
+	sendLimiter *rate.Limiter
+}
+
+func (e *rpcEntry) allow(peer PeerId) bool {
+	if e.limit == 0 {
+		return true
+	}
+
+	e.limitersMx.Lock()
+	defer e.limitersMx.Unlock()
+
+	limiter, ok := e.limiters[peer]
+	if !ok {
+		limiter = rate.NewLimiter(e.limit, e.burst)
+		e.limiters[peer] = limiter
+	}
+	return limiter.Allow()
+}
+
+type rpcCall struct {
+	entry *rpcEntry
+	peer  PeerId
+	req   any
+}
+
+func NewRPCRegistry(transport RPCTransport) *RPCRegistry {
+	return &RPCRegistry{
+		transport: transport,
+		entries:   make(map[RPCId]*rpcEntry),
+	}
+}
+
+// RPCRegistry keeps registered RPCs. Calls are decoded on network goroutines
+// and handlers are invoked by Dispatch on the game goroutine.
+type RPCRegistry struct {
+	transport RPCTransport
+
+	mx      sync.RWMutex
+	entries map[RPCId]*rpcEntry
+
+	calls queue[rpcCall]
+}
+
+// DefaultRPC is the registry used by RegisterRPC, it sends and receives calls over Quic
+var DefaultRPC = newQuicRPCRegistry()
+
+func newQuicRPCRegistry() *RPCRegistry {
+	registry := NewRPCRegistry(Quic)
+	Quic.Handle(MessageRPC, registry.Receive)
+	return registry
+}
+
+// RPC is a typed handle of a registered RPC
+type RPC[Req any] struct {
+	registry *RPCRegistry
+	entry    *rpcEntry
+}
+
+// RegisterRPC registers an RPC in DefaultRPC. The handler is invoked on the game goroutine.
+func RegisterRPC[Req any](name string, handler func(peer PeerId, req *Req), opts ...RPCOption) RPC[Req] {
+	return RegisterRPCIn(DefaultRPC, name, handler, opts...)
+}
+
+// RegisterRPCIn registers an RPC in the registry. Registering the same name twice panics.
+// Req is encoded with encoding.BinaryMarshaler when *Req implements it, with JSON otherwise.
+func RegisterRPCIn[Req any](registry *RPCRegistry, name string, handler func(peer PeerId, req *Req), opts ...RPCOption) RPC[Req] {
+	entry := &rpcEntry{
+		id:       rpcIdOf(name),
+		name:     name,
+		channel:  ChannelReliableOrdered,
+		limiters: make(map[PeerId]*rate.Limiter),
+		decode: func(data []byte) (any, error) {
+			req := new(Req)
+			if u, ok := any(req).(encoding.BinaryUnmarshaler); ok {
+				return req, u.UnmarshalBinary(data)
+			}
+			return req, json.Unmarshal(data, req)
+		},
+		invoke: func(peer PeerId, req any) {
+			handler(peer, req.(*Req))
+		},
+	}
+	for _, opt := range opts {
+		opt(entry)
+	}
+	if entry.limit != 0 {
+		entry.sendLimiter = rate.NewLimiter(entry.limit, entry.burst)
+	}
+
+	registry.mx.Lock()
+	defer registry.mx.Unlock()
+
+	if other, ok := registry.entries[entry.id]; ok {
+		panic(fmt.Sprintf("rpc %q collides with already registered %q", name, other.name))
+	}
+	registry.entries[entry.id] = entry
+
+	return RPC[Req]{registry: registry, entry: entry}
+}
+
+// Id returns wire id of the RPC
+func (r RPC[Req]) Id() RPCId {
+	return r.entry.id
+}
+
+// Call is Client-side method to call RPCToServer RPC
+func (r RPC[Req]) Call(req Req) error {
+	if r.entry.direction != RPCToServer || r.registry.transport.Mode() != ModeClient {
+		return ErrRPCDirection
+	}
+
+	data, err := r.encode(&req)
+	if err != nil {
+		return err
+	}
+	return r.registry.transport.Send(r.entry.channel, data)
+}
+
+// CallPeer is Server-side method to call RPCToClient RPC on a single peer
+func (r RPC[Req]) CallPeer(peer PeerId, req Req) error {
+	if r.entry.direction != RPCToClient || r.registry.transport.Mode() != ModeServer {
+		return ErrRPCDirection
+	}
+
+	data, err := r.encode(&req)
+	if err != nil {
+		return err
+	}
+	return r.registry.transport.SendTo(peer, r.entry.channel, data)
+}
+
+// Broadcast is Server-side method to call RPCToClient RPC on every peer
+func (r RPC[Req]) Broadcast(req Req) error {
+	if r.entry.direction != RPCToClient || r.registry.transport.Mode() != ModeServer {
+		return ErrRPCDirection
+	}
+
+	data, err := r.encode(&req)
+	if err != nil {
+		return err
+	}
+	return r.registry.transport.Send(r.entry.channel, data)
+}
+
+func (r RPC[Req]) encode(req *Req) ([]byte, error) {
+	if r.entry.sendLimiter != nil && !r.entry.sendLimiter.Allow() {
+		return nil, ErrRateLimited
+	}
+
+	data := make([]byte, rpcHeaderSize, 64)
+	data[0] = byte(MessageRPC)
+	binary.BigEndian.PutUint32(data[1:], uint32(r.entry.id))
+
+	if m, ok := any(req).(encoding.BinaryMarshaler); ok {
+		payload, err := m.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		return append(data, payload...), nil
+	}
+
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, payload...), nil
+}
+
+// Receive decodes an RPC message and queues the call for Dispatch.
+// It is safe to call from network goroutines.
+func (r *RPCRegistry) Receive(msg Message) error {
+	if len(msg.Data) < rpcHeaderSize || MessageType(msg.Data[0]) != MessageRPC {
+		return ErrMalformedMessage
+	}
+
+	id := RPCId(binary.BigEndian.Uint32(msg.Data[1:]))
+
+	r.mx.RLock()
+	entry, ok := r.entries[id]
+	r.mx.RUnlock()
+	if !ok {
+		return ErrUnknownRPC
+	}
+
+	mode := r.transport.Mode()
+	if entry.direction == RPCToServer && mode != ModeServer || entry.direction == RPCToClient && mode != ModeClient {
+		return ErrRPCDirection
+	}
+
+	if !entry.allow(msg.Peer) {
+		return ErrRateLimited
+	}
+
+	req, err := entry.decode(msg.Data[rpcHeaderSize:])
+	if err != nil {
+		return fmt.Errorf("%w: rpc %q: %w", ErrMalformedMessage, entry.name, err)
+	}
+
+	r.calls.push(rpcCall{entry: entry, peer: msg.Peer, req: req})
+	return nil
+}
+
+// Dispatch invokes handlers of all received calls, it should be called once per tick on the game goroutine
+func (r *RPCRegistry) Dispatch() {
+	for {
+		call, ok := r.calls.pop()
+		if !ok {
+			return
+		}
+		call.entry.invoke(call.peer, call.req)
+	}
+}
+
+// ForgetPeer releases rate limiters of a disconnected peer
+func (r *RPCRegistry) ForgetPeer(peer PeerId) {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+
+	for _, entry := range r.entries {
+		entry.limitersMx.Lock()
+		delete(entry.limiters, peer)
+		entry.limitersMx.Unlock()
+	}
+}
+
+func rpcIdOf(name string) RPCId {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	return RPCId(h.Sum32())
+}
@@ -0,0 +1,142 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package network
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+// loopbackTransport delivers sent messages straight into the other registry
+type loopbackTransport struct {
+	mode   Mode
+	peer   PeerId
+	remote *RPCRegistry
+	sent   []Channel
+}
+
+func (t *loopbackTransport) Mode() Mode { return t.mode }
+
+func (t *loopbackTransport) Send(ch Channel, data []byte) error {
+	t.sent = append(t.sent, ch)
+	return t.remote.Receive(Message{Peer: t.peer, Channel: ch, Data: data})
+}
+
+func (t *loopbackTransport) SendTo(peer PeerId, ch Channel, data []byte) error {
+	return t.Send(ch, data)
+}
+
+type castAbility struct {
+	Ability string
+	X, Y    float32
+}
+
+type move struct {
+	X, Y float32
+}
+
+func (m *move) MarshalBinary() ([]byte, error) {
+	data := binary.LittleEndian.AppendUint32(nil, uint32(m.X))
+	return binary.LittleEndian.AppendUint32(data, uint32(m.Y)), nil
+}
+
+func (m *move) UnmarshalBinary(data []byte) error {
+	if len(data) != 8 {
+		return errors.New("bad move")
+	}
+	m.X = float32(binary.LittleEndian.Uint32(data))
+	m.Y = float32(binary.LittleEndian.Uint32(data[4:]))
+	return nil
+}
+
+func newLoopbackRegistries() (server, client *RPCRegistry) {
+	serverTransport := &loopbackTransport{mode: ModeServer, peer: ServerPeerId}
+	clientTransport := &loopbackTransport{mode: ModeClient, peer: 7}
+	server = NewRPCRegistry(serverTransport)
+	client = NewRPCRegistry(clientTransport)
+	serverTransport.remote = client
+	clientTransport.remote = server
+	return server, client
+}
+
+func TestRPCCallDispatchedOnTick(t *testing.T) {
+	server, client := newLoopbackRegistries()
+
+	var casts []castAbility
+	var casters []PeerId
+	handler := func(peer PeerId, req *castAbility) {
+		casters = append(casters, peer)
+		casts = append(casts, *req)
+	}
+	RegisterRPCIn(server, "cast_ability", handler)
+	cast := RegisterRPCIn(client, "cast_ability", handler)
+
+	require.NoError(t, cast.Call(castAbility{Ability: "firepizza", X: 1, Y: 2}))
+	require.Empty(t, casts, "handler must wait for Dispatch")
+
+	server.Dispatch()
+	require.Equal(t, []castAbility{{Ability: "firepizza", X: 1, Y: 2}}, casts)
+	require.Equal(t, []PeerId{7}, casters)
+
+	// Client can not handle RPCToServer
+	require.ErrorIs(t, client.Receive(Message{Data: append([]byte{byte(MessageRPC)}, binary.BigEndian.AppendUint32(nil, uint32(cast.Id()))...)}), ErrRPCDirection)
+}
+
+func TestRPCToClientBinary(t *testing.T) {
+	server, client := newLoopbackRegistries()
+
+	var moves []move
+	handler := func(peer PeerId, req *move) {
+		require.Equal(t, ServerPeerId, peer)
+		moves = append(moves, *req)
+	}
+	opts := []RPCOption{WithRPCDirection(RPCToClient), WithRPCChannel(ChannelUnreliableSequenced)}
+	moveRPC := RegisterRPCIn(server, "move", handler, opts...)
+	RegisterRPCIn(client, "move", handler, opts...)
+
+	require.ErrorIs(t, moveRPC.Call(move{}), ErrRPCDirection)
+	require.NoError(t, moveRPC.CallPeer(7, move{X: 3, Y: 4}))
+	require.NoError(t, moveRPC.Broadcast(move{X: 5, Y: 6}))
+	require.Equal(t, []Channel{ChannelUnreliableSequenced, ChannelUnreliableSequenced}, server.transport.(*loopbackTransport).sent)
+
+	client.Dispatch()
+	require.Equal(t, []move{{X: 3, Y: 4}, {X: 5, Y: 6}}, moves)
+}
+
+func TestRPCRateLimit(t *testing.T) {
+	server, _ := newLoopbackRegistries()
+
+	calls := 0
+	ping := RegisterRPCIn(server, "ping", func(peer PeerId, req *struct{}) { calls++ }, WithRPCRateLimit(rate.Every(1<<62), 2))
+
+	data := append([]byte{byte(MessageRPC)}, binary.BigEndian.AppendUint32(nil, uint32(ping.Id()))...)
+	data = append(data, "{}"...)
+
+	require.NoError(t, server.Receive(Message{Peer: 1, Data: data}))
+	require.NoError(t, server.Receive(Message{Peer: 1, Data: data}))
+	require.ErrorIs(t, server.Receive(Message{Peer: 1, Data: data}), ErrRateLimited)
+	// Limit is per peer
+	require.NoError(t, server.Receive(Message{Peer: 2, Data: data}))
+
+	server.ForgetPeer(1)
+	require.NoError(t, server.Receive(Message{Peer: 1, Data: data}))
+
+	server.Dispatch()
+	require.Equal(t, 4, calls)
+}
+
+func TestRPCDuplicateNamePanics(t *testing.T) {
+	server, _ := newLoopbackRegistries()
+	RegisterRPCIn(server, "ping", func(peer PeerId, req *struct{}) {})
+	require.Panics(t, func() {
+		RegisterRPCIn(server, "ping", func(peer PeerId, req *struct{}) {})
+	})
+}
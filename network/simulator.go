@@ -0,0 +1,178 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package network
+
+import (
+	"math/rand/v2"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// simulatorMaxBacklog is how long packets may wait for bandwidth before they are dropped, like in a router queue
+const simulatorMaxBacklog = time.Second
+
+// Conditions describes a bad network. Zero value is a perfect network.
+type Conditions struct {
+	Latency   time.Duration // One way delay added to every packet
+	Jitter    time.Duration // Random delay in [0, Jitter) added on top of Latency
+	Loss      float64       // Percent of dropped packets
+	Duplicate float64       // Percent of packets sent twice
+	Reorder   float64       // Percent of packets held back long enough to arrive after following ones
+	Bandwidth int           // Bytes per second, 0 means unlimited
+}
+
+// SimulatorStats counts what simulator did with outgoing packets
+type SimulatorStats struct {
+	Sent       uint64
+	Dropped    uint64
+	Duplicated uint64
+	Reordered  uint64
+}
+
+// SimulatedPacketConn applies Conditions to packets written to the wrapped connection.
+// Only outgoing packets are affected, wrap both ends to simulate a symmetric network.
+// Pass it to QuicServer.StartOn or QuicClient.ConnectOn.
+type SimulatedPacketConn struct {
+	net.PacketConn
+
+	mx         sync.Mutex
+	conditions Conditions
+	rand       *rand.Rand
+	busyUntil  time.Time // When the simulated link is free to send the next packet
+	closed     bool
+
+	sent       atomic.Uint64
+	dropped    atomic.Uint64
+	duplicated atomic.Uint64
+	reordered  atomic.Uint64
+}
+
+func NewSimulatedPacketConn(conn net.PacketConn, conditions Conditions) *SimulatedPacketConn {
+	seed := uint64(time.Now().UnixNano())
+	return &SimulatedPacketConn{
+		PacketConn: conn,
+		conditions: conditions,
+		rand:       rand.New(rand.NewPCG(seed, seed)),
+	}
+}
+
+// SetConditions changes conditions at runtime, packets already in flight are not affected
+func (c *SimulatedPacketConn) SetConditions(conditions Conditions) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.conditions = conditions
+}
+
+func (c *SimulatedPacketConn) Conditions() Conditions {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.conditions
+}
+
+// Seed makes random decisions reproducible
+func (c *SimulatedPacketConn) Seed(seed uint64) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.rand = rand.New(rand.NewPCG(seed, seed))
+}
+
+func (c *SimulatedPacketConn) Stats() SimulatorStats {
+	return SimulatorStats{
+		Sent:       c.sent.Load(),
+		Dropped:    c.dropped.Load(),
+		Duplicated: c.duplicated.Load(),
+		Reordered:  c.reordered.Load(),
+	}
+}
+
+func (c *SimulatedPacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+
+	cond := c.conditions
+	if cond == (Conditions{}) {
+		c.sent.Add(1)
+		return c.PacketConn.WriteTo(p, addr)
+	}
+
+	if c.chance(cond.Loss) {
+		c.dropped.Add(1)
+		return len(p), nil
+	}
+
+	copies := 1
+	if c.chance(cond.Duplicate) {
+		copies = 2
+		c.duplicated.Add(1)
+	}
+
+	now := time.Now()
+	for range copies {
+		delay := cond.Latency
+		if cond.Jitter > 0 {
+			delay += time.Duration(c.rand.Int64N(int64(cond.Jitter)))
+		}
+		if c.chance(cond.Reorder) {
+			// Long enough to let packets sent right after overtake this one
+			delay += cond.Jitter + max(cond.Latency, 10*time.Millisecond)
+			c.reordered.Add(1)
+		}
+
+		if cond.Bandwidth > 0 {
+			if c.busyUntil.Before(now) {
+				c.busyUntil = now
+			}
+			if c.busyUntil.Sub(now) > simulatorMaxBacklog {
+				c.dropped.Add(1)
+				continue
+			}
+			c.busyUntil = c.busyUntil.Add(time.Duration(len(p)) * time.Second / time.Duration(cond.Bandwidth))
+			delay += c.busyUntil.Sub(now)
+		}
+
+		c.schedule(p, addr, delay)
+	}
+
+	return len(p), nil
+}
+
+func (c *SimulatedPacketConn) Close() error {
+	c.mx.Lock()
+	c.closed = true
+	c.mx.Unlock()
+
+	return c.PacketConn.Close()
+}
+
+func (c *SimulatedPacketConn) schedule(p []byte, addr net.Addr, delay time.Duration) {
+	packet := make([]byte, len(p))
+	copy(packet, p)
+
+	time.AfterFunc(delay, func() {
+		c.sent.Add(1)
+		// Errors of delayed packets are indistinguishable from loss
+		_, _ = c.PacketConn.WriteTo(packet, addr)
+	})
+}
+
+func (c *SimulatedPacketConn) chance(percent float64) bool {
+	return percent > 0 && c.rand.Float64()*100 < percent
+}
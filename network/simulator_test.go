@@ -0,0 +1,131 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package network
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newSimulatedPair(t *testing.T, conditions Conditions) (*SimulatedPacketConn, net.PacketConn) {
+	t.Helper()
+
+	sender, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	receiver, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	simulated := NewSimulatedPacketConn(sender, conditions)
+	simulated.Seed(1)
+	t.Cleanup(func() {
+		_ = simulated.Close()
+		_ = receiver.Close()
+	})
+	return simulated, receiver
+}
+
+// receiveAll reads packets until nothing arrives for the wait duration
+func receiveAll(t *testing.T, conn net.PacketConn, wait time.Duration) []string {
+	t.Helper()
+
+	var packets []string
+	buf := make([]byte, 2048)
+	for {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(wait)))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return packets
+		}
+		packets = append(packets, string(buf[:n]))
+	}
+}
+
+func TestSimulatorLatency(t *testing.T) {
+	simulated, receiver := newSimulatedPair(t, Conditions{Latency: 50 * time.Millisecond})
+
+	start := time.Now()
+	_, err := simulated.WriteTo([]byte("ping"), receiver.LocalAddr())
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"ping"}, receiveAll(t, receiver, 200*time.Millisecond))
+	require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestSimulatorLossAndDuplicate(t *testing.T) {
+	simulated, receiver := newSimulatedPair(t, Conditions{Loss: 100})
+
+	_, err := simulated.WriteTo([]byte("lost"), receiver.LocalAddr())
+	require.NoError(t, err)
+	require.Empty(t, receiveAll(t, receiver, 50*time.Millisecond))
+	require.Equal(t, uint64(1), simulated.Stats().Dropped)
+
+	simulated.SetConditions(Conditions{Duplicate: 100})
+	_, err = simulated.WriteTo([]byte("twice"), receiver.LocalAddr())
+	require.NoError(t, err)
+	require.Equal(t, []string{"twice", "twice"}, receiveAll(t, receiver, 50*time.Millisecond))
+
+	// Zero conditions pass packets through
+	simulated.SetConditions(Conditions{})
+	_, err = simulated.WriteTo([]byte("clean"), receiver.LocalAddr())
+	require.NoError(t, err)
+	require.Equal(t, []string{"clean"}, receiveAll(t, receiver, 50*time.Millisecond))
+}
+
+func TestSimulatorReorder(t *testing.T) {
+	simulated, receiver := newSimulatedPair(t, Conditions{Reorder: 100})
+
+	_, err := simulated.WriteTo([]byte("first"), receiver.LocalAddr())
+	require.NoError(t, err)
+	simulated.SetConditions(Conditions{Latency: time.Millisecond})
+	_, err = simulated.WriteTo([]byte("second"), receiver.LocalAddr())
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"second", "first"}, receiveAll(t, receiver, 100*time.Millisecond))
+}
+
+func TestSimulatorBandwidth(t *testing.T) {
+	// 10 packets of 100 bytes over 10 KB/s take ~100ms
+	simulated, receiver := newSimulatedPair(t, Conditions{Bandwidth: 10_000})
+
+	start := time.Now()
+	packet := make([]byte, 100)
+	for range 10 {
+		_, err := simulated.WriteTo(packet, receiver.LocalAddr())
+		require.NoError(t, err)
+	}
+
+	require.Len(t, receiveAll(t, receiver, 100*time.Millisecond), 10)
+	require.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestSimulatorQuicRTT(t *testing.T) {
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	simulated := NewSimulatedPacketConn(udp, Conditions{Latency: 30 * time.Millisecond})
+	defer simulated.Close()
+
+	events := make(chan PeerEvent, 16)
+	server := NewQuicServer()
+	server.HeartbeatInterval = 20 * time.Millisecond
+	server.OnPeerEvent = func(event PeerEvent) { events <- event }
+	require.NoError(t, server.StartOn(simulated))
+	defer server.Stop()
+
+	client := NewQuicClient()
+	client.Config = DevQuicClientConfig()
+	go client.Connect(server.Addr().String())
+	defer client.Disconnect()
+
+	connected := waitEvent(t, events)
+	require.Eventually(t, func() bool {
+		peer, ok := server.Peer(connected.Peer)
+		return ok && peer.RTT() >= 30*time.Millisecond
+	}, testTimeout, 10*time.Millisecond)
+}
@@ -0,0 +1,269 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package network
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ChannelStats is traffic of a single channel. Bytes are message payloads without framing.
+type ChannelStats struct {
+	BytesSent        uint64
+	BytesReceived    uint64
+	MessagesSent     uint64
+	MessagesReceived uint64
+}
+
+// PeerStats is traffic of a single connection since it was established
+type PeerStats struct {
+	Peer     PeerId
+	RTT      time.Duration
+	Channels [channelsCount]ChannelStats
+
+	// Packets are QUIC datagrams including heartbeats, streams are accounted in Channels only
+	PacketsSent     uint64
+	PacketsReceived uint64
+	// MessagesLost are unreliable messages skipped by sequencing or never completed
+	MessagesLost uint64
+}
+
+func (s *PeerStats) BytesSent() (bytes uint64) {
+	for i := range s.Channels {
+		bytes += s.Channels[i].BytesSent
+	}
+	return bytes
+}
+
+func (s *PeerStats) BytesReceived() (bytes uint64) {
+	for i := range s.Channels {
+		bytes += s.Channels[i].BytesReceived
+	}
+	return bytes
+}
+
+// Loss returns share of unreliable messages which were lost, from 0 to 1
+func (s *PeerStats) Loss() float64 {
+	received := s.Channels[ChannelUnreliableSequenced].MessagesReceived
+	if s.MessagesLost == 0 {
+		return 0
+	}
+	return float64(s.MessagesLost) / float64(s.MessagesLost+received)
+}
+
+// ComponentStats is bandwidth of a replicated component, reported by systems encoding it
+type ComponentStats struct {
+	Component     uint16
+	BytesSent     uint64
+	BytesReceived uint64
+}
+
+// Stats is a snapshot of network counters
+type Stats struct {
+	Peers      []PeerStats      // Ordered by peer id
+	Components []ComponentStats // Ordered by component id
+}
+
+// Total sums traffic of all peers, RTT is the average one
+func (s *Stats) Total() PeerStats {
+	var total PeerStats
+	for i := range s.Peers {
+		peer := &s.Peers[i]
+		total.RTT += peer.RTT
+		total.PacketsSent += peer.PacketsSent
+		total.PacketsReceived += peer.PacketsReceived
+		total.MessagesLost += peer.MessagesLost
+		for ch := range peer.Channels {
+			total.Channels[ch].BytesSent += peer.Channels[ch].BytesSent
+			total.Channels[ch].BytesReceived += peer.Channels[ch].BytesReceived
+			total.Channels[ch].MessagesSent += peer.Channels[ch].MessagesSent
+			total.Channels[ch].MessagesReceived += peer.Channels[ch].MessagesReceived
+		}
+	}
+	if len(s.Peers) > 0 {
+		total.RTT /= time.Duration(len(s.Peers))
+	}
+	return total
+}
+
+// WritePrometheus writes the snapshot in Prometheus text exposition format
+func (s *Stats) WritePrometheus(w io.Writer) error {
+	var b strings.Builder
+
+	metric := func(name, kind, help string, samples func()) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+		samples()
+	}
+	perChannel := func(name, help string, value func(*ChannelStats) uint64) {
+		metric(name, "counter", help, func() {
+			for i := range s.Peers {
+				for ch := range s.Peers[i].Channels {
+					fmt.Fprintf(&b, "%s{peer=\"%d\",channel=\"%s\"} %d\n", name, s.Peers[i].Peer, Channel(ch), value(&s.Peers[i].Channels[ch]))
+				}
+			}
+		})
+	}
+	perPeer := func(name, help string, value func(*PeerStats) uint64) {
+		metric(name, "counter", help, func() {
+			for i := range s.Peers {
+				fmt.Fprintf(&b, "%s{peer=\"%d\"} %d\n", name, s.Peers[i].Peer, value(&s.Peers[i]))
+			}
+		})
+	}
+	perComponent := func(name, help string, value func(*ComponentStats) uint64) {
+		metric(name, "counter", help, func() {
+			for i := range s.Components {
+				fmt.Fprintf(&b, "%s{component=\"%d\"} %d\n", name, s.Components[i].Component, value(&s.Components[i]))
+			}
+		})
+	}
+
+	metric("gomp_network_peers", "gauge", "Connected peers.", func() {
+		fmt.Fprintf(&b, "gomp_network_peers %d\n", len(s.Peers))
+	})
+	metric("gomp_network_rtt_seconds", "gauge", "Smoothed round trip time.", func() {
+		for i := range s.Peers {
+			fmt.Fprintf(&b, "gomp_network_rtt_seconds{peer=\"%d\"} %g\n", s.Peers[i].Peer, s.Peers[i].RTT.Seconds())
+		}
+	})
+	perChannel("gomp_network_bytes_sent_total", "Payload bytes sent.", func(c *ChannelStats) uint64 { return c.BytesSent })
+	perChannel("gomp_network_bytes_received_total", "Payload bytes received.", func(c *ChannelStats) uint64 { return c.BytesReceived })
+	perChannel("gomp_network_messages_sent_total", "Messages sent.", func(c *ChannelStats) uint64 { return c.MessagesSent })
+	perChannel("gomp_network_messages_received_total", "Messages received.", func(c *ChannelStats) uint64 { return c.MessagesReceived })
+	perPeer("gomp_network_packets_sent_total", "Datagrams sent.", func(p *PeerStats) uint64 { return p.PacketsSent })
+	perPeer("gomp_network_packets_received_total", "Datagrams received.", func(p *PeerStats) uint64 { return p.PacketsReceived })
+	perPeer("gomp_network_messages_lost_total", "Unreliable messages lost.", func(p *PeerStats) uint64 { return p.MessagesLost })
+	perComponent("gomp_network_component_bytes_sent_total", "Encoded component bytes sent.", func(c *ComponentStats) uint64 { return c.BytesSent })
+	perComponent("gomp_network_component_bytes_received_total", "Encoded component bytes received.", func(c *ComponentStats) uint64 { return c.BytesReceived })
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// StatsHandler serves snapshots taken by stats in Prometheus text format, e.g. network.StatsHandler(network.Quic.Stats)
+func StatsHandler(stats func() Stats) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		snapshot := stats()
+		if err := snapshot.WritePrometheus(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+type channelCounters struct {
+	bytesSent        atomic.Uint64
+	bytesReceived    atomic.Uint64
+	messagesSent     atomic.Uint64
+	messagesReceived atomic.Uint64
+}
+
+// peerCounters are updated by network goroutines of a connection
+type peerCounters struct {
+	channels        [channelsCount]channelCounters
+	packetsSent     atomic.Uint64
+	packetsReceived atomic.Uint64
+	messagesLost    atomic.Uint64
+}
+
+func (c *peerCounters) sent(ch Channel, bytes int) {
+	if ch < 0 || ch >= channelsCount {
+		return
+	}
+	c.channels[ch].messagesSent.Add(1)
+	c.channels[ch].bytesSent.Add(uint64(bytes))
+}
+
+func (c *peerCounters) received(ch Channel, bytes int) {
+	if ch < 0 || ch >= channelsCount {
+		return
+	}
+	c.channels[ch].messagesReceived.Add(1)
+	c.channels[ch].bytesReceived.Add(uint64(bytes))
+}
+
+func (c *peerCounters) snapshot(peer PeerId, rtt time.Duration) PeerStats {
+	stats := PeerStats{
+		Peer:            peer,
+		RTT:             rtt,
+		PacketsSent:     c.packetsSent.Load(),
+		PacketsReceived: c.packetsReceived.Load(),
+		MessagesLost:    c.messagesLost.Load(),
+	}
+	for ch := range c.channels {
+		stats.Channels[ch] = ChannelStats{
+			BytesSent:        c.channels[ch].bytesSent.Load(),
+			BytesReceived:    c.channels[ch].bytesReceived.Load(),
+			MessagesSent:     c.channels[ch].messagesSent.Load(),
+			MessagesReceived: c.channels[ch].messagesReceived.Load(),
+		}
+	}
+	return stats
+}
+
+type componentCounters struct {
+	bytesSent     atomic.Uint64
+	bytesReceived atomic.Uint64
+}
+
+// componentStats is per-component bandwidth keyed by component id
+type componentStats struct {
+	counters sync.Map // uint16 -> *componentCounters
+}
+
+func (s *componentStats) get(component uint16) *componentCounters {
+	if counters, ok := s.counters.Load(component); ok {
+		return counters.(*componentCounters)
+	}
+	counters, _ := s.counters.LoadOrStore(component, &componentCounters{})
+	return counters.(*componentCounters)
+}
+
+func (s *componentStats) snapshot() []ComponentStats {
+	var stats []ComponentStats
+	s.counters.Range(func(key, value any) bool {
+		counters := value.(*componentCounters)
+		stats = append(stats, ComponentStats{
+			Component:     key.(uint16),
+			BytesSent:     counters.bytesSent.Load(),
+			BytesReceived: counters.bytesReceived.Load(),
+		})
+		return true
+	})
+	slices.SortFunc(stats, func(a, b ComponentStats) int {
+		return cmp.Compare(a.Component, b.Component)
+	})
+	return stats
+}
+
+func (ch Channel) String() string {
+	switch ch {
+	case ChannelReliableOrdered:
+		return "reliable_ordered"
+	case ChannelReliableUnordered:
+		return "reliable_unordered"
+	case ChannelUnreliableSequenced:
+		return "unreliable_sequenced"
+	default:
+		return fmt.Sprintf("channel_%d", int(ch))
+	}
+}
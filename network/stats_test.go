@@ -0,0 +1,91 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package network
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsLoss(t *testing.T) {
+	c := newChannelConn(nil, nil, 3, nil, nil)
+
+	c.handleDatagram(datagram(1, 0, 1, "a"))
+	c.handleDatagram(datagram(3, 0, 2, "incomplete"))
+	c.handleDatagram(datagram(5, 0, 1, "b"))
+
+	// Sequences 2, 3 and 4 are never delivered
+	stats := c.Stats()
+	require.Equal(t, PeerId(3), stats.Peer)
+	require.Equal(t, uint64(3), stats.MessagesLost)
+
+	stats.Channels[ChannelUnreliableSequenced].MessagesReceived = 1
+	require.InDelta(t, 0.75, stats.Loss(), 1e-9)
+}
+
+func TestStatsTraffic(t *testing.T) {
+	server, serverEvents, serverMessages := startTestServer(t, func(s *QuicServer) {
+		s.HeartbeatInterval = 20 * time.Millisecond
+	})
+	client, _, clientMessages := connectTestClient(t, server)
+	peer := waitEvent(t, serverEvents).Peer
+
+	require.NoError(t, client.Send(ChannelReliableOrdered, []byte("hello")))
+	waitMessage(t, serverMessages)
+	require.NoError(t, server.Send(peer, ChannelUnreliableSequenced, make([]byte, 3000)))
+	waitMessage(t, clientMessages)
+
+	clientStats := client.Stats()
+	require.Len(t, clientStats.Peers, 1)
+	require.Equal(t, ServerPeerId, clientStats.Peers[0].Peer)
+	require.Equal(t, ChannelStats{BytesSent: 5, MessagesSent: 1}, clientStats.Peers[0].Channels[ChannelReliableOrdered])
+	require.Equal(t, uint64(3000), clientStats.Peers[0].Channels[ChannelUnreliableSequenced].BytesReceived)
+
+	require.Eventually(t, func() bool {
+		stats := server.Stats()
+		return len(stats.Peers) == 1 && stats.Peers[0].RTT > 0 && stats.Peers[0].PacketsReceived > 0
+	}, testTimeout, 10*time.Millisecond)
+
+	stats := server.Stats()
+	total := stats.Total()
+	require.Equal(t, uint64(5), total.BytesReceived())
+	require.Equal(t, uint64(3000), total.BytesSent())
+	require.GreaterOrEqual(t, total.PacketsSent, uint64(3)) // Fragments of the unreliable message
+
+	stats.Components = []ComponentStats{{Component: 4, BytesSent: 12}}
+	rec := httptest.NewRecorder()
+	StatsHandler(func() Stats { return stats }).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "gomp_network_peers 1\n")
+	require.Contains(t, rec.Body.String(), "gomp_network_bytes_received_total{peer=\"1\",channel=\"reliable_ordered\"} 5\n")
+	require.Contains(t, rec.Body.String(), "gomp_network_component_bytes_sent_total{component=\"4\"} 12\n")
+}
+
+func TestStatsWhileHosting(t *testing.T) {
+	n := &QuicNetwork{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 200 {
+			n.Stats()
+			n.Peers()
+		}
+	}()
+
+	// Stats are read by HTTP handlers while the game hosts and stops
+	for range 3 {
+		n.Host("127.0.0.1:0")
+		require.Equal(t, ModeServer, n.Mode())
+		n.Stop()
+		require.Equal(t, ModeNone, n.Mode())
+	}
+	<-done
+}
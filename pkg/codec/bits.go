@@ -0,0 +1,129 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package codec
+
+// BitWriter packs consecutive bit fields, Flush pads them to a whole byte
+type BitWriter struct {
+	acc byte
+	n   uint
+}
+
+func (w *BitWriter) write(dst []byte, v uint64, bits uint) []byte {
+	for bits > 0 {
+		k := min(bits, 8-w.n)
+		w.acc |= byte(v&(1<<k-1)) << w.n
+		v >>= k
+		bits -= k
+		w.n += k
+		if w.n == 8 {
+			dst = append(dst, w.acc)
+			w.acc, w.n = 0, 0
+		}
+	}
+	return dst
+}
+
+// Flush writes the last incomplete byte
+func (w *BitWriter) Flush(dst []byte) []byte {
+	if w.n > 0 {
+		dst = append(dst, w.acc)
+		w.acc, w.n = 0, 0
+	}
+	return dst
+}
+
+// BitReader reads fields written with BitWriter
+type BitReader struct {
+	data []byte
+	acc  byte
+	n    uint
+}
+
+// Reset starts reading bit fields from data
+func (r *BitReader) Reset(data []byte) {
+	r.data = data
+	r.acc, r.n = 0, 0
+}
+
+// Rest returns data after the bit fields, padding bits are skipped
+func (r *BitReader) Rest() []byte {
+	return r.data
+}
+
+func (r *BitReader) read(bits uint) (uint64, error) {
+	var v uint64
+	var shift uint
+	for bits > 0 {
+		if r.n == 0 {
+			if len(r.data) == 0 {
+				return 0, ErrShortBuffer
+			}
+			r.acc, r.n = r.data[0], 8
+			r.data = r.data[1:]
+		}
+		k := min(bits, r.n)
+		v |= uint64(r.acc&(1<<k-1)) << shift
+		r.acc >>= k
+		r.n -= k
+		bits -= k
+		shift += k
+	}
+	return v, nil
+}
+
+func AppendBitsBool[T ~bool](dst []byte, w *BitWriter, v T) []byte {
+	if v {
+		return w.write(dst, 1, 1)
+	}
+	return w.write(dst, 0, 1)
+}
+
+func ReadBitsBool[T ~bool](r *BitReader, v *T) error {
+	value, err := r.read(1)
+	*v = value != 0
+	return err
+}
+
+// AppendBitsUint writes the lowest bits of v, higher bits are lost
+func AppendBitsUint[T Unsigned](dst []byte, w *BitWriter, v T, bits uint) []byte {
+	return w.write(dst, uint64(v), bits)
+}
+
+func ReadBitsUint[T Unsigned](r *BitReader, v *T, bits uint) error {
+	value, err := r.read(bits)
+	*v = T(value)
+	return err
+}
+
+// AppendBitsInt writes v zigzag encoded, so small negative values take few bits as well
+func AppendBitsInt[T Signed](dst []byte, w *BitWriter, v T, bits uint) []byte {
+	return w.write(dst, zigzag(int64(v)), bits)
+}
+
+func ReadBitsInt[T Signed](r *BitReader, v *T, bits uint) error {
+	value, err := r.read(bits)
+	*v = T(unzigzag(value))
+	return err
+}
+
+func AppendBitsQuant[T Float](dst []byte, w *BitWriter, v T, min, max float64, bits uint) []byte {
+	return w.write(dst, Quantize(float64(v), min, max, bits), bits)
+}
+
+func ReadBitsQuant[T Float](r *BitReader, v *T, min, max float64, bits uint) error {
+	value, err := r.read(bits)
+	*v = T(Dequantize(value, min, max, bits))
+	return err
+}
@@ -0,0 +1,212 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+// Package codec contains primitives of binary component codecs generated by cmd/gompcodec.
+// Append functions append a value to dst, Read functions decode a value into v and return the rest of data.
+// Neither of them allocates, except reading strings and growing slices.
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+var (
+	ErrShortBuffer = errors.New("codec: short buffer")
+	ErrOverflow    = errors.New("codec: value overflows the field")
+)
+
+type Signed interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64
+}
+
+type Unsigned interface {
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+type Float interface {
+	~float32 | ~float64
+}
+
+func AppendBool[T ~bool](dst []byte, v T) []byte {
+	if v {
+		return append(dst, 1)
+	}
+	return append(dst, 0)
+}
+
+func ReadBool[T ~bool](data []byte, v *T) ([]byte, error) {
+	if len(data) < 1 {
+		return data, ErrShortBuffer
+	}
+	*v = data[0] != 0
+	return data[1:], nil
+}
+
+// AppendUint8 writes a single byte, it is used for uint8 and int8 fields
+func AppendUint8[T ~uint8 | ~int8](dst []byte, v T) []byte {
+	return append(dst, byte(v))
+}
+
+func ReadUint8[T ~uint8 | ~int8](data []byte, v *T) ([]byte, error) {
+	if len(data) < 1 {
+		return data, ErrShortBuffer
+	}
+	*v = T(data[0])
+	return data[1:], nil
+}
+
+func AppendUvarint[T Unsigned](dst []byte, v T) []byte {
+	return binary.AppendUvarint(dst, uint64(v))
+}
+
+func ReadUvarint[T Unsigned](data []byte, v *T) ([]byte, error) {
+	value, n := binary.Uvarint(data)
+	if n <= 0 {
+		return data, ErrShortBuffer
+	}
+	if uint64(T(value)) != value {
+		return data, ErrOverflow
+	}
+	*v = T(value)
+	return data[n:], nil
+}
+
+func AppendVarint[T Signed](dst []byte, v T) []byte {
+	return binary.AppendVarint(dst, int64(v))
+}
+
+func ReadVarint[T Signed](data []byte, v *T) ([]byte, error) {
+	value, n := binary.Varint(data)
+	if n <= 0 {
+		return data, ErrShortBuffer
+	}
+	if int64(T(value)) != value {
+		return data, ErrOverflow
+	}
+	*v = T(value)
+	return data[n:], nil
+}
+
+func AppendFloat32[T ~float32](dst []byte, v T) []byte {
+	return binary.LittleEndian.AppendUint32(dst, math.Float32bits(float32(v)))
+}
+
+func ReadFloat32[T ~float32](data []byte, v *T) ([]byte, error) {
+	if len(data) < 4 {
+		return data, ErrShortBuffer
+	}
+	*v = T(math.Float32frombits(binary.LittleEndian.Uint32(data)))
+	return data[4:], nil
+}
+
+func AppendFloat64[T ~float64](dst []byte, v T) []byte {
+	return binary.LittleEndian.AppendUint64(dst, math.Float64bits(float64(v)))
+}
+
+func ReadFloat64[T ~float64](data []byte, v *T) ([]byte, error) {
+	if len(data) < 8 {
+		return data, ErrShortBuffer
+	}
+	*v = T(math.Float64frombits(binary.LittleEndian.Uint64(data)))
+	return data[8:], nil
+}
+
+func AppendString[T ~string](dst []byte, v T) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(v)))
+	return append(dst, v...)
+}
+
+func ReadString[T ~string](data []byte, v *T) ([]byte, error) {
+	var n int
+	data, err := ReadLen(data, &n)
+	if err != nil {
+		return data, err
+	}
+	*v = T(data[:n])
+	return data[n:], nil
+}
+
+func AppendBytes[T ~[]byte](dst []byte, v T) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(v)))
+	return append(dst, v...)
+}
+
+// ReadBytes copies bytes into v reusing its capacity
+func ReadBytes[T ~[]byte](data []byte, v *T) ([]byte, error) {
+	var n int
+	data, err := ReadLen(data, &n)
+	if err != nil {
+		return data, err
+	}
+	*v = append((*v)[:0], data[:n]...)
+	return data[n:], nil
+}
+
+// AppendLen writes length of a slice, elements follow it
+func AppendLen(dst []byte, n int) []byte {
+	return binary.AppendUvarint(dst, uint64(n))
+}
+
+// ReadLen reads length of a slice. Every element takes at least a byte, so length is limited by the rest of data.
+func ReadLen(data []byte, n *int) ([]byte, error) {
+	value, size := binary.Uvarint(data)
+	if size <= 0 {
+		return data, ErrShortBuffer
+	}
+	data = data[size:]
+	if value > uint64(len(data)) {
+		return data, ErrShortBuffer
+	}
+	*n = int(value)
+	return data, nil
+}
+
+// Resize returns slice of length n reusing capacity of s
+func Resize[S ~[]E, E any](s S, n int) S {
+	if cap(s) < n {
+		return make(S, n)
+	}
+	s = s[:n]
+	clear(s)
+	return s
+}
+
+// Quantize maps v from [min, max] range to an integer of the given bits, values outside of the range are clamped
+func Quantize(v, min, max float64, bits uint) uint64 {
+	steps := float64(uint64(1)<<bits - 1)
+	t := (v - min) / (max - min)
+	if !(t > 0) {
+		return 0
+	}
+	if t >= 1 {
+		return uint64(steps)
+	}
+	return uint64(math.Round(t * steps))
+}
+
+// Dequantize is reverse of Quantize, precision is (max - min) / (2^bits - 1)
+func Dequantize(q uint64, min, max float64, bits uint) float64 {
+	steps := float64(uint64(1)<<bits - 1)
+	return min + float64(q)/steps*(max-min)
+}
+
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+func unzigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
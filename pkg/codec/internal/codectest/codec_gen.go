@@ -0,0 +1,136 @@
+// Code generated by gompcodec. DO NOT EDIT.
+
+package codectest
+
+import (
+	"gomp/pkg/codec"
+	"gomp/pkg/ecs"
+)
+
+func init() {
+	ecs.RegisterCodec(AppendSample, ReadSample)
+	ecs.RegisterCodec(AppendSlot, ReadSlot)
+}
+
+func AppendSample(dst []byte, c *Sample) []byte {
+	var bits codec.BitWriter
+	dst = codec.AppendFloat32(dst, c.Position.X)
+	dst = codec.AppendFloat32(dst, c.Position.Y)
+	dst = codec.AppendBitsQuant(dst, &bits, c.Angle, 0.0, 6.2832, 12)
+	dst = codec.AppendBitsBool(dst, &bits, c.Visible)
+	dst = codec.AppendBitsUint(dst, &bits, c.Frame, 5)
+	dst = codec.AppendBitsInt(dst, &bits, c.Offset, 6)
+	dst = bits.Flush(dst)
+	dst = codec.AppendUint8(dst, c.Team)
+	dst = codec.AppendVarint(dst, c.Health)
+	dst = codec.AppendVarint(dst, c.Cooldown)
+	dst = codec.AppendString(dst, c.Name)
+	dst = codec.AppendBytes(dst, c.Payload)
+	dst = codec.AppendLen(dst, len(c.Path))
+	for i0 := range c.Path {
+		dst = codec.AppendFloat32(dst, c.Path[i0].X)
+		dst = codec.AppendFloat32(dst, c.Path[i0].Y)
+	}
+	for i0 := range c.Slots {
+		dst = codec.AppendUvarint(dst, c.Slots[i0].Item)
+		dst = codec.AppendBitsUint(dst, &bits, c.Slots[i0].Count, 7)
+		dst = codec.AppendBitsBool(dst, &bits, c.Slots[i0].Locked)
+		dst = bits.Flush(dst)
+	}
+	return dst
+}
+
+func ReadSample(data []byte, c *Sample) ([]byte, error) {
+	var err error
+	var bits codec.BitReader
+	if data, err = codec.ReadFloat32(data, &c.Position.X); err != nil {
+		return data, err
+	}
+	if data, err = codec.ReadFloat32(data, &c.Position.Y); err != nil {
+		return data, err
+	}
+	bits.Reset(data)
+	if err = codec.ReadBitsQuant(&bits, &c.Angle, 0.0, 6.2832, 12); err != nil {
+		return data, err
+	}
+	if err = codec.ReadBitsBool(&bits, &c.Visible); err != nil {
+		return data, err
+	}
+	if err = codec.ReadBitsUint(&bits, &c.Frame, 5); err != nil {
+		return data, err
+	}
+	if err = codec.ReadBitsInt(&bits, &c.Offset, 6); err != nil {
+		return data, err
+	}
+	data = bits.Rest()
+	if data, err = codec.ReadUint8(data, &c.Team); err != nil {
+		return data, err
+	}
+	if data, err = codec.ReadVarint(data, &c.Health); err != nil {
+		return data, err
+	}
+	if data, err = codec.ReadVarint(data, &c.Cooldown); err != nil {
+		return data, err
+	}
+	if data, err = codec.ReadString(data, &c.Name); err != nil {
+		return data, err
+	}
+	if data, err = codec.ReadBytes(data, &c.Payload); err != nil {
+		return data, err
+	}
+	{
+		var n0 int
+		if data, err = codec.ReadLen(data, &n0); err != nil {
+			return data, err
+		}
+		c.Path = codec.Resize(c.Path, n0)
+		for i0 := range c.Path {
+			if data, err = codec.ReadFloat32(data, &c.Path[i0].X); err != nil {
+				return data, err
+			}
+			if data, err = codec.ReadFloat32(data, &c.Path[i0].Y); err != nil {
+				return data, err
+			}
+		}
+	}
+	for i0 := range c.Slots {
+		if data, err = codec.ReadUvarint(data, &c.Slots[i0].Item); err != nil {
+			return data, err
+		}
+		bits.Reset(data)
+		if err = codec.ReadBitsUint(&bits, &c.Slots[i0].Count, 7); err != nil {
+			return data, err
+		}
+		if err = codec.ReadBitsBool(&bits, &c.Slots[i0].Locked); err != nil {
+			return data, err
+		}
+		data = bits.Rest()
+	}
+	return data, nil
+}
+
+func AppendSlot(dst []byte, c *Slot) []byte {
+	var bits codec.BitWriter
+	dst = codec.AppendUvarint(dst, c.Item)
+	dst = codec.AppendBitsUint(dst, &bits, c.Count, 7)
+	dst = codec.AppendBitsBool(dst, &bits, c.Locked)
+	dst = bits.Flush(dst)
+	return dst
+}
+
+func ReadSlot(data []byte, c *Slot) ([]byte, error) {
+	var err error
+	var bits codec.BitReader
+	if data, err = codec.ReadUvarint(data, &c.Item); err != nil {
+		return data, err
+	}
+	bits.Reset(data)
+	if err = codec.ReadBitsUint(&bits, &c.Count, 7); err != nil {
+		return data, err
+	}
+	if err = codec.ReadBitsBool(&bits, &c.Locked); err != nil {
+		return data, err
+	}
+	data = bits.Rest()
+	return data, nil
+}
@@ -0,0 +1,45 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+// Package codectest holds components covering every feature of cmd/gompcodec
+package codectest
+
+import (
+	"gomp/vectors"
+	"time"
+)
+
+//go:generate go run gomp/cmd/gompcodec -type=Sample,Slot
+
+type Sample struct {
+	Position vectors.Vec2
+	Angle    vectors.Radians `gomp:"quant=0:6.2832,bits=12"`
+	Visible  bool
+	Frame    uint8 `gomp:"bits=5"`
+	Offset   int16 `gomp:"bits=6"`
+	Team     int8
+	Health   int32
+	Cooldown time.Duration
+	Name     string
+	Payload  []byte
+	Path     []vectors.Vec2
+	Slots    [3]Slot
+	Cache    map[int]int `gomp:"-"`
+}
+
+type Slot struct {
+	Item   uint16
+	Count  uint8 `gomp:"bits=7"`
+	Locked bool
+}
@@ -0,0 +1,94 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package codectest
+
+import (
+	"gomp/pkg/codec"
+	"gomp/pkg/ecs"
+	"gomp/vectors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newSample() Sample {
+	return Sample{
+		Position: vectors.Vec2{X: 12.5, Y: -3},
+		Angle:    1.5,
+		Visible:  true,
+		Frame:    27,
+		Offset:   -17,
+		Team:     -2,
+		Health:   -100500,
+		Cooldown: 1500 * time.Millisecond,
+		Name:     "goblin",
+		Payload:  []byte{1, 2, 3},
+		Path:     []vectors.Vec2{{X: 1, Y: 2}, {X: 3, Y: 4}},
+		Slots:    [3]Slot{{Item: 300, Count: 99, Locked: true}, {Item: 1}, {Count: 1}},
+		Cache:    map[int]int{1: 1},
+	}
+}
+
+func TestGeneratedRoundTrip(t *testing.T) {
+	sample := newSample()
+	data := AppendSample(nil, &sample)
+
+	var decoded Sample
+	rest, err := ReadSample(data, &decoded)
+	require.NoError(t, err)
+	require.Empty(t, rest)
+
+	require.InDelta(t, sample.Angle, decoded.Angle, 6.2832/4095)
+	decoded.Angle = sample.Angle
+	require.Nil(t, decoded.Cache)
+	decoded.Cache = sample.Cache
+	require.Equal(t, sample, decoded)
+
+	for i := range data {
+		_, err = ReadSample(data[:i], &decoded)
+		require.ErrorIs(t, err, codec.ErrShortBuffer, "truncated at %d", i)
+	}
+}
+
+func TestGeneratedAllocations(t *testing.T) {
+	sample := newSample()
+	buffer := AppendSample(nil, &sample)
+	decoded := newSample()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		buffer = AppendSample(buffer[:0], &sample)
+	})
+	require.Zero(t, allocs)
+
+	// Only the string is allocated, slices reuse capacity
+	allocs = testing.AllocsPerRun(100, func() {
+		_, _ = ReadSample(buffer, &decoded)
+	})
+	require.LessOrEqual(t, allocs, 1.0)
+}
+
+type codecTestComponents struct {
+	Slots ecs.ComponentManager[Slot]
+}
+
+func TestGeneratedCodecRegistered(t *testing.T) {
+	newWorld := func() *ecs.World[codecTestComponents, struct{}] {
+		world := ecs.NewWorld(codecTestComponents{Slots: ecs.NewComponentManager[Slot](0)}, struct{}{})
+		world.Components.Slots.TrackChanges = true
+		world.Init()
+		return &world
+	}
+
+	// Codec generated for Slot is used without SetEncoder and SetDecoder
+	world := newWorld()
+	world.Components.Slots.Create(world.Entities.Create(), Slot{Item: 7, Count: 3, Locked: true})
+
+	other := newWorld()
+	require.NoError(t, other.Entities.PatchRestore(world.Entities.PatchSnapshot()))
+	require.Equal(t, Slot{Item: 7, Count: 3, Locked: true}, *other.Components.Slots.Get(1))
+}
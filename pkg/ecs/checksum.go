@@ -0,0 +1,57 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package ecs
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"maps"
+	"slices"
+	"unsafe"
+)
+
+// Checksum hashes entities and components of managers with the ids, or of all managers when no ids are given.
+// It detects desyncs in lockstep, so hashed components must be plain data:
+// pointers, slices, maps and strings are hashed by address, not by content.
+func (e *EntityManager) Checksum(ids ...ComponentId) uint64 {
+	if len(ids) == 0 {
+		ids = slices.Sorted(maps.Keys(e.components))
+	}
+
+	h := fnv.New64a()
+	var header [2]byte
+	for _, id := range ids {
+		component := e.components[id]
+		if component == nil {
+			panic(fmt.Sprintf("Component %d does not exist", id))
+		}
+
+		binary.BigEndian.PutUint16(header[:], uint16(id))
+		h.Write(header[:])
+		component.writeChecksum(h)
+	}
+	return h.Sum64()
+}
+
+// writeRaw writes memory of values as is, entities and components are stored in the same order on every peer
+func writeRaw[T any](w io.Writer, values []T) {
+	if len(values) == 0 {
+		return
+	}
+	size := int(unsafe.Sizeof(values[0])) * len(values)
+	w.Write(unsafe.Slice((*byte)(unsafe.Pointer(unsafe.SliceData(values))), size))
+}
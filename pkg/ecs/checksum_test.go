@@ -0,0 +1,28 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package ecs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntityManagerChecksum(t *testing.T) {
+	a, b := newPatchTestWorld(), newPatchTestWorld()
+	for i := range 3 {
+		a.Components.Health.Create(a.Entities.Create(), int32(i))
+		b.Components.Health.Create(b.Entities.Create(), int32(i))
+	}
+	require.Equal(t, a.Entities.Checksum(), b.Entities.Checksum())
+	require.Equal(t, a.Entities.Checksum(), a.Entities.Checksum(0))
+
+	*b.Components.Health.Get(2) = 42
+	require.NotEqual(t, a.Entities.Checksum(), b.Entities.Checksum())
+
+	require.Panics(t, func() { a.Entities.Checksum(7) })
+}
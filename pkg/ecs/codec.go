@@ -0,0 +1,69 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package ecs
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+type componentCodec[T any] struct {
+	encoder func(dst []byte, components []T) []byte
+	decoder func(data []byte) ([]T, error)
+}
+
+var codecs sync.Map // reflect.Type -> componentCodec[T]
+
+// RegisterCodec sets encoder and decoder of every ComponentManager[T] created afterwards.
+// It is called by init functions generated with cmd/gompcodec.
+func RegisterCodec[T any](appendComponent func(dst []byte, component *T) []byte, readComponent func(data []byte, component *T) ([]byte, error)) {
+	codecs.Store(reflect.TypeFor[T](), componentCodec[T]{
+		encoder: func(dst []byte, components []T) []byte {
+			dst = binary.AppendUvarint(dst, uint64(len(components)))
+			for i := range components {
+				dst = appendComponent(dst, &components[i])
+			}
+			return dst
+		},
+		decoder: func(data []byte) ([]T, error) {
+			count, n := binary.Uvarint(data)
+			if n <= 0 {
+				return nil, fmt.Errorf("%w: bad component count", ErrMalformedPatch)
+			}
+			data = data[n:]
+
+			components := make([]T, 0, min(count, uint64(len(data))))
+			for range count {
+				var component T
+				var err error
+				if data, err = readComponent(data, &component); err != nil {
+					return nil, fmt.Errorf("%w: component %d of %d: %w", ErrMalformedPatch, len(components), count, err)
+				}
+				components = append(components, component)
+			}
+			return components, nil
+		},
+	})
+}
+
+func codecOf[T any]() (componentCodec[T], bool) {
+	c, ok := codecs.Load(reflect.TypeFor[T]())
+	if !ok {
+		return componentCodec[T]{}, false
+	}
+	return c.(componentCodec[T]), true
+}
@@ -0,0 +1,68 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package ecs
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type codecTestArmor uint16
+
+func init() {
+	RegisterCodec(
+		func(dst []byte, c *codecTestArmor) []byte {
+			return binary.LittleEndian.AppendUint16(dst, uint16(*c))
+		},
+		func(data []byte, c *codecTestArmor) ([]byte, error) {
+			if len(data) < 2 {
+				return data, errors.New("short armor")
+			}
+			*c = codecTestArmor(binary.LittleEndian.Uint16(data))
+			return data[2:], nil
+		},
+	)
+}
+
+type codecTestComponents struct {
+	Armor ComponentManager[codecTestArmor]
+}
+
+func TestRegisteredCodec(t *testing.T) {
+	newWorld := func() *World[codecTestComponents, struct{}] {
+		armor := NewComponentManager[codecTestArmor](0)
+		armor.TrackChanges = true
+		world := NewWorld(codecTestComponents{Armor: armor}, struct{}{})
+		world.Init()
+		return &world
+	}
+	world := newWorld()
+	entity := world.Entities.Create()
+	world.Components.Armor.Create(entity, 5)
+
+	patch := world.Entities.PatchGet()
+	other := newWorld()
+	require.NoError(t, other.Entities.PatchApply(patch))
+	require.Equal(t, codecTestArmor(5), *other.Components.Armor.Get(entity))
+
+	// Encoding into the patch buffer does not allocate once it has grown
+	components := []codecTestArmor{1, 2, 3}
+	world.Components.Armor.PatchReset()
+	allocs := testing.AllocsPerRun(100, func() {
+		world.Components.Armor.encode(components)
+		world.Components.Armor.PatchReset()
+	})
+	require.Zero(t, allocs)
+
+	// Decode errors are returned and nothing is applied
+	truncated := Patch{{ID: 0, Created: ComponentChanges{Len: 1, Components: []byte{1, 5}, Entities: []Entity{9}}}}
+	require.ErrorIs(t, other.Entities.PatchApply(truncated), ErrMalformedPatch)
+	require.False(t, other.Components.Armor.Has(9))
+}
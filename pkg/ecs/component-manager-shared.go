@@ -7,6 +7,7 @@ with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
 package ecs
 
 import (
+	"io"
 	"sync"
 
 	"github.com/negrel/assert"
@@ -77,7 +78,7 @@ func (c *SharedComponentManager[T]) PatchGet() ComponentPatch {
 	panic("implement me")
 }
 
-func (c *SharedComponentManager[T]) PatchApply(patch ComponentPatch) {
+func (c *SharedComponentManager[T]) PatchApply(patch ComponentPatch) error {
 	//TODO implement me
 	panic("implement me")
 }
@@ -87,6 +88,23 @@ func (c *SharedComponentManager[T]) PatchReset() {
 	panic("implement me")
 }
 
+// PatchSnapshot is empty, shared components are not part of snapshots and EntityManager skips them
+func (c *SharedComponentManager[T]) PatchSnapshot() ComponentPatch {
+	return ComponentPatch{ID: c.id}
+}
+
+// PatchRestore does nothing, see PatchSnapshot
+func (c *SharedComponentManager[T]) PatchRestore(snapshot ComponentPatch) error {
+	return nil
+}
+
+// PatchGetEntities is empty, see PatchSnapshot
+func (c *SharedComponentManager[T]) PatchGetEntities(entities []Entity) ComponentPatch {
+	return ComponentPatch{ID: c.id}
+}
+
+func (c *SharedComponentManager[T]) shared() {}
+
 func (c *SharedComponentManager[T]) Id() ComponentId {
 	return c.id
 }
@@ -277,8 +295,14 @@ func (c *SharedComponentManager[T]) IsTrackingChanges() bool {
 // Utils
 // ========================================================
 
-func (c *SharedComponentManager[T]) RawComponents(ptr []T) {
-	c.components.Raw(ptr)
+func (c *SharedComponentManager[T]) RawComponents(ptr []T) []T {
+	return c.components.Raw(ptr)
+}
+
+func (c *SharedComponentManager[T]) writeChecksum(w io.Writer) {
+	writeRaw(w, c.entities.Raw(nil))
+	writeRaw(w, c.references.Raw(nil))
+	writeRaw(w, c.RawComponents(nil))
 }
 
 func (c *SharedComponentManager[T]) assertBegin() {
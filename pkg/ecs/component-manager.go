@@ -15,6 +15,8 @@ Thank you for your support!
 package ecs
 
 import (
+	"fmt"
+	"io"
 	"sync"
 
 	"github.com/negrel/assert"
@@ -35,10 +37,14 @@ type AnyComponentManagerPtr interface {
 	Has(Entity) bool
 	PatchAdd(Entity)
 	PatchGet() ComponentPatch
-	PatchApply(patch ComponentPatch)
+	PatchApply(patch ComponentPatch) error
 	PatchReset()
+	PatchSnapshot() ComponentPatch
+	PatchRestore(snapshot ComponentPatch) error
+	PatchGetEntities(entities []Entity) ComponentPatch
 	IsTrackingChanges() bool
 	registerEntityManager(*EntityManager)
+	writeChecksum(w io.Writer)
 }
 
 // ================
@@ -60,6 +66,11 @@ func NewComponentManager[T any](id ComponentId) ComponentManager[T] {
 		deletedEntities: NewPagedArray[Entity](),
 	}
 
+	if codec, ok := codecOf[T](); ok {
+		newManager.encoder = codec.encoder
+		newManager.decoder = codec.decoder
+	}
+
 	return newManager
 }
 
@@ -82,8 +93,10 @@ type ComponentManager[T any] struct {
 	patchedEntities PagedArray[Entity]
 	deletedEntities PagedArray[Entity]
 
-	encoder func([]T) []byte
-	decoder func([]byte) []T
+	encoder func(dst []byte, components []T) []byte
+	decoder func(data []byte) ([]T, error)
+	// patchBuffer holds encoded components of PatchGet and PatchGetEntities until PatchReset
+	patchBuffer []byte
 }
 
 // ComponentChanges with byte encoded Components
@@ -282,30 +295,52 @@ func (c *ComponentManager[T]) PatchGet() ComponentPatch {
 	return patch
 }
 
-func (c *ComponentManager[T]) PatchApply(patch ComponentPatch) {
+// PatchApply applies the patch, malformed changes are rejected before any component is changed
+func (c *ComponentManager[T]) PatchApply(patch ComponentPatch) error {
 	assert.True(c.TrackChanges)
 	assert.True(patch.ID == c.id)
 	assert.True(c.decoder != nil)
 
-	var components []T
+	created, err := c.decodeChanges(&patch.Created)
+	if err != nil {
+		return err
+	}
+	patched, err := c.decodeChanges(&patch.Patched)
+	if err != nil {
+		return err
+	}
+	if _, err = c.decodeChanges(&patch.Deleted); err != nil {
+		return err
+	}
 
-	created := patch.Created
-	components = c.decoder(created.Components)
-	for i := range created.Len {
-		c.Create(created.Entities[i], components[i])
+	for i, entity := range patch.Created.Entities[:patch.Created.Len] {
+		c.Create(entity, created[i])
 	}
+	// Patched components of entities without them are created, PatchGetEntities sends full state as patched
+	for i, entity := range patch.Patched.Entities[:patch.Patched.Len] {
+		if c.Set(entity, patched[i]) == nil {
+			c.Create(entity, patched[i])
+		}
+	}
+	for _, entity := range patch.Deleted.Entities[:patch.Deleted.Len] {
+		c.Remove(entity)
+	}
+	return nil
+}
 
-	patched := patch.Patched
-	components = c.decoder(patched.Components)
-	for i := range patched.Len {
-		c.Set(patched.Entities[i], components[i])
+func (c *ComponentManager[T]) decodeChanges(changes *ComponentChanges) ([]T, error) {
+	if changes.Len == 0 {
+		return nil, nil
 	}
 
-	deleted := patch.Deleted
-	components = c.decoder(deleted.Components)
-	for i := range deleted.Len {
-		c.Remove(deleted.Entities[i])
+	components, err := c.decoder(changes.Components)
+	if err != nil {
+		return nil, fmt.Errorf("component %d: %w", c.id, err)
 	}
+	if changes.Len < 0 || len(components) != changes.Len || len(changes.Entities) < changes.Len {
+		return nil, fmt.Errorf("%w: component %d has %d of %d components", ErrMalformedPatch, c.id, len(components), changes.Len)
+	}
+	return components, nil
 }
 
 func (c *ComponentManager[T]) PatchReset() {
@@ -313,6 +348,85 @@ func (c *ComponentManager[T]) PatchReset() {
 	c.createdEntities.Reset()
 	c.patchedEntities.Reset()
 	c.deletedEntities.Reset()
+	c.patchBuffer = c.patchBuffer[:0]
+}
+
+// PatchSnapshot returns all components as created, it is used for keyframes and late joins
+func (c *ComponentManager[T]) PatchSnapshot() ComponentPatch {
+	assert.True(c.encoder != nil)
+
+	entities := c.RawEntities(make([]Entity, 0, c.Len()))
+	components := c.RawComponents(make([]T, 0, c.Len()))
+
+	return ComponentPatch{
+		ID: c.id,
+		Created: ComponentChanges{
+			Len:        len(entities),
+			Components: c.encoder(nil, components),
+			Entities:   entities,
+		},
+	}
+}
+
+// PatchRestore makes components equal to the snapshot made with PatchSnapshot.
+// Malformed snapshots are rejected before any component is changed.
+func (c *ComponentManager[T]) PatchRestore(snapshot ComponentPatch) error {
+	assert.True(snapshot.ID == c.id)
+	assert.True(c.decoder != nil)
+
+	created := snapshot.Created
+	components, err := c.decoder(created.Components)
+	if err != nil {
+		return fmt.Errorf("component %d snapshot: %w", c.id, err)
+	}
+	if created.Len < 0 || len(components) != created.Len || len(created.Entities) < created.Len {
+		return fmt.Errorf("%w: component %d snapshot has %d of %d components", ErrMalformedPatch, c.id, len(components), created.Len)
+	}
+
+	keep := make(map[Entity]struct{}, created.Len)
+	for _, entity := range created.Entities[:created.Len] {
+		keep[entity] = struct{}{}
+	}
+	for _, entity := range c.RawEntities(make([]Entity, 0, c.Len())) {
+		if _, ok := keep[entity]; !ok {
+			c.Remove(entity)
+		}
+	}
+
+	for i, entity := range created.Entities[:created.Len] {
+		if c.Has(entity) {
+			c.Set(entity, components[i])
+		} else {
+			c.Create(entity, components[i])
+		}
+	}
+	return nil
+}
+
+// PatchGetEntities returns current components of the entities as patched, entities without the component are skipped.
+// It is used to replicate a part of the world, e.g. entities relevant to a single peer.
+func (c *ComponentManager[T]) PatchGetEntities(entities []Entity) ComponentPatch {
+	assert.True(c.encoder != nil)
+
+	components := make([]T, 0, len(entities))
+	patched := make([]Entity, 0, len(entities))
+	for _, entity := range entities {
+		component := c.Get(entity)
+		if component == nil {
+			continue
+		}
+		components = append(components, *component)
+		patched = append(patched, entity)
+	}
+
+	return ComponentPatch{
+		ID: c.id,
+		Patched: ComponentChanges{
+			Len:        len(patched),
+			Components: c.encode(components),
+			Entities:   patched,
+		},
+	}
 }
 
 func (c *ComponentManager[T]) getChangesBinary(source *PagedArray[Entity]) ComponentChanges {
@@ -332,7 +446,7 @@ func (c *ComponentManager[T]) getChangesBinary(source *PagedArray[Entity]) Compo
 
 	assert.True(c.encoder != nil)
 
-	componentsBinary := c.encoder(components)
+	componentsBinary := c.encode(components)
 
 	return ComponentChanges{
 		Len:        changesLen,
@@ -341,13 +455,24 @@ func (c *ComponentManager[T]) getChangesBinary(source *PagedArray[Entity]) Compo
 	}
 }
 
+// encode appends components to patchBuffer, so patches made every tick do not allocate once the buffer has grown
+func (c *ComponentManager[T]) encode(components []T) []byte {
+	start := len(c.patchBuffer)
+	c.patchBuffer = c.encoder(c.patchBuffer, components)
+	return c.patchBuffer[start:len(c.patchBuffer):len(c.patchBuffer)]
+}
+
 func (c *ComponentManager[T]) SetEncoder(function func(components []T) []byte) *ComponentManager[T] {
-	c.encoder = function
+	c.encoder = func(dst []byte, components []T) []byte {
+		return append(dst, function(components)...)
+	}
 	return c
 }
 
 func (c *ComponentManager[T]) SetDecoder(function func(data []byte) []T) *ComponentManager[T] {
-	c.decoder = function
+	c.decoder = func(data []byte) ([]T, error) {
+		return function(data), nil
+	}
 	return c
 }
 
@@ -367,6 +492,11 @@ func (c *ComponentManager[T]) RawEntities(ptr []Entity) []Entity {
 	return c.entities.Raw(ptr)
 }
 
+func (c *ComponentManager[T]) writeChecksum(w io.Writer) {
+	writeRaw(w, c.RawEntities(nil))
+	writeRaw(w, c.RawComponents(nil))
+}
+
 func (c *ComponentManager[T]) assertBegin() {
 	assert.True(c.isInitialized, "ComponentManager should be created with NewComponentManager()")
 	assert.True(c.components.Len() == c.lookup.Len(), "Lookup Count must always be the same as the number of components!")
@@ -107,6 +107,12 @@ func (e *EntityManager) LastId() Entity {
 	return e.lastId
 }
 
+// HasComponent reports whether a component manager with the id is registered
+func (e *EntityManager) HasComponent(id ComponentId) bool {
+	_, ok := e.components[id]
+	return ok
+}
+
 func (e *EntityManager) Destroy() {
 	e.Clean()
 }
@@ -125,19 +131,73 @@ func (e *EntityManager) PatchGet() Patch {
 	return patch
 }
 
-func (e *EntityManager) PatchApply(patch Patch) {
+// PatchApply applies changes of components tracking changes.
+// Components applied before a malformed one keep the applied changes.
+func (e *EntityManager) PatchApply(patch Patch) error {
 	for _, componentPatch := range patch {
 		component := e.components[componentPatch.ID]
 		if component == nil {
-			panic(fmt.Sprintf("Component %d does not exist", componentPatch.ID))
+			return fmt.Errorf("%w: component %d does not exist", ErrMalformedPatch, componentPatch.ID)
 		}
 
 		if !component.IsTrackingChanges() {
 			continue
 		}
 
-		component.PatchApply(componentPatch)
+		if err := component.PatchApply(componentPatch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PatchSnapshot returns full state of components tracking changes, shared components are not included
+func (e *EntityManager) PatchSnapshot() Patch {
+	patch := make(Patch, 0, len(e.components))
+	for _, component := range e.components {
+		if !component.IsTrackingChanges() || isShared(component) {
+			continue
+		}
+		patch = append(patch, component.PatchSnapshot())
+	}
+	return patch
+}
+
+// PatchRestore brings components tracking changes to the state of the snapshot made with PatchSnapshot.
+// Components restored before a malformed one keep the restored state.
+func (e *EntityManager) PatchRestore(snapshot Patch) error {
+	for _, componentPatch := range snapshot {
+		component := e.components[componentPatch.ID]
+		if component == nil {
+			return fmt.Errorf("%w: component %d does not exist", ErrMalformedPatch, componentPatch.ID)
+		}
+
+		if !component.IsTrackingChanges() || isShared(component) {
+			continue
+		}
+
+		if err := component.PatchRestore(componentPatch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PatchGetEntities returns current state of the entities in components tracking changes.
+// Components none of the entities have are left out.
+func (e *EntityManager) PatchGetEntities(entities []Entity) Patch {
+	patch := make(Patch, 0, len(e.components))
+	for _, component := range e.components {
+		if !component.IsTrackingChanges() || isShared(component) {
+			continue
+		}
+		componentPatch := component.PatchGetEntities(entities)
+		if componentPatch.Patched.Len == 0 {
+			continue
+		}
+		patch = append(patch, componentPatch)
 	}
+	return patch
 }
 
 func (e *EntityManager) PatchReset() {
@@ -154,6 +214,12 @@ func (e *EntityManager) PatchReset() {
 	}
 }
 
+// isShared reports whether the manager holds shared components, which are not patched yet
+func isShared(component AnyComponentManagerPtr) bool {
+	_, ok := component.(interface{ shared() })
+	return ok
+}
+
 func (e *EntityManager) init() {
 	e.componentBitSet = NewComponentBitSet()
 	e.patch = make(Patch, len(e.components))
@@ -60,6 +60,7 @@ func NewSpatialGrid(cellSize float64) SpatialGrid {
 		lookupByKey:    make(map[GridKey]int, PREALLOC_DEFAULT),
 		lookupByEntity: make(map[Entity]int, PREALLOC_DEFAULT),
 		cellSize:       cellSize,
+		updated:        make(map[GridKey]struct{}),
 	}
 }
 
@@ -93,6 +94,7 @@ func (g *SpatialGrid) AddEntity(entity Entity, positionX, positionY float64) {
 			lookup:   make(map[Entity]int, PREALLOC_DEFAULT),
 			Key:      key,
 		})
+		g.keys = append(g.keys, key)
 	}
 	cell := &g.cells[cellIndex]
 
@@ -148,7 +150,7 @@ func (g *SpatialGrid) RemoveEntity(entity Entity) {
 	delete(g.lookupByEntity, entity)
 
 	if len(cell.Entities) == 0 {
-		delete(g.lookupByKey, GridKey{cellIndex, cellIndex})
+		delete(g.lookupByKey, g.keys[cellIndex])
 		g.cells[cellIndex] = GridCell{} // Release memory
 		g.keys[cellIndex] = GridKey{}
 	}
@@ -186,6 +188,7 @@ func (g *SpatialGrid) MoveEntity(entity Entity, positionX, positionY float64) {
 		g.cells = append(g.cells, GridCell{
 			Entities: make([]Entity, 0, PREALLOC_DEFAULT),
 			lookup:   make(map[Entity]int, PREALLOC_DEFAULT),
+			Key:      newKey,
 		})
 		g.lookupByKey[newKey] = newCellIndex
 		g.keys = append(g.keys, newKey)
@@ -217,6 +220,43 @@ func (g *SpatialGrid) GetNearbyEntities(key GridKey) []Entity {
 	return entities
 }
 
+// QueryRect appends Entities of all cells overlapping the rectangle to result.
+// Cells are matched as a whole, so an exact bounds check is up to the caller.
+func (g *SpatialGrid) QueryRect(minX, minY, maxX, maxY float64, result []Entity) []Entity {
+	xLo := int(math.Floor(minX / g.cellSize))
+	xHi := int(math.Floor(maxX / g.cellSize))
+	yLo := int(math.Floor(minY / g.cellSize))
+	yHi := int(math.Floor(maxY / g.cellSize))
+
+	// Huge rectangle on a sparse grid - cheaper to walk active cells
+	if (xHi-xLo+1)*(yHi-yLo+1) > len(g.lookupByKey) {
+		for key, cellIndex := range g.lookupByKey {
+			if key[0] < xLo || key[0] > xHi || key[1] < yLo || key[1] > yHi {
+				continue
+			}
+			result = append(result, g.cells[cellIndex].Entities...)
+		}
+		return result
+	}
+
+	for y := yLo; y <= yHi; y++ {
+		for x := xLo; x <= xHi; x++ {
+			cellIndex, ok := g.lookupByKey[GridKey{x, y}]
+			if !ok {
+				continue
+			}
+			result = append(result, g.cells[cellIndex].Entities...)
+		}
+	}
+	return result
+}
+
+// Has reports whether the entity was added to the grid
+func (g *SpatialGrid) Has(entity Entity) bool {
+	_, ok := g.lookupByEntity[entity]
+	return ok
+}
+
 func (g *SpatialGrid) Compact() {
 	g.mx.Lock()
 	defer g.mx.Unlock()
@@ -0,0 +1,40 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package ecs
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpatialGridQueryRect(t *testing.T) {
+	grid := NewSpatialGrid(10)
+
+	grid.AddEntity(1, 5, 5)
+	grid.AddEntity(2, 15, 5)
+	grid.AddEntity(3, 95, 95)
+
+	result := grid.QueryRect(0, 0, 19, 9, nil)
+	slices.Sort(result)
+	require.Equal(t, []Entity{1, 2}, result)
+
+	grid.MoveEntity(2, 96, 96)
+	result = grid.QueryRect(90, 90, 99, 99, nil)
+	slices.Sort(result)
+	require.Equal(t, []Entity{2, 3}, result)
+
+	grid.RemoveEntity(1)
+	require.False(t, grid.Has(1))
+	require.Empty(t, grid.QueryRect(0, 0, 9, 9, nil))
+
+	// Rectangle bigger than the number of active cells
+	result = grid.QueryRect(-1000, -1000, 1000, 1000, nil)
+	slices.Sort(result)
+	require.Equal(t, []Entity{2, 3}, result)
+}
@@ -0,0 +1,150 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package ecs
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var ErrMalformedPatch = errors.New("malformed patch")
+
+// MarshalBinary encodes the patch for storage or transfer.
+// Components stay in the format of component encoders.
+func (p Patch) MarshalBinary() ([]byte, error) {
+	return p.AppendBinary(nil)
+}
+
+func (p Patch) AppendBinary(dst []byte) ([]byte, error) {
+	dst = binary.AppendUvarint(dst, uint64(len(p)))
+	for i := range p {
+		componentPatch := &p[i]
+		dst = binary.BigEndian.AppendUint16(dst, uint16(componentPatch.ID))
+		dst = componentPatch.Created.appendBinary(dst)
+		dst = componentPatch.Patched.appendBinary(dst)
+		dst = componentPatch.Deleted.appendBinary(dst)
+	}
+	return dst, nil
+}
+
+func (p *Patch) UnmarshalBinary(data []byte) error {
+	count, n := binary.Uvarint(data)
+	if n <= 0 || count > uint64(len(data)) {
+		return ErrMalformedPatch
+	}
+	data = data[n:]
+
+	patch := make(Patch, count)
+	for i := range patch {
+		if len(data) < 2 {
+			return ErrMalformedPatch
+		}
+		patch[i].ID = ComponentId(binary.BigEndian.Uint16(data))
+		data = data[2:]
+
+		var err error
+		if data, err = patch[i].Created.readBinary(data); err != nil {
+			return err
+		}
+		if data, err = patch[i].Patched.readBinary(data); err != nil {
+			return err
+		}
+		if data, err = patch[i].Deleted.readBinary(data); err != nil {
+			return err
+		}
+	}
+	if len(data) != 0 {
+		return ErrMalformedPatch
+	}
+
+	*p = patch
+	return nil
+}
+
+// Size returns bytes the component patch takes in MarshalBinary output, e.g. for bandwidth accounting
+func (p *ComponentPatch) Size() int {
+	return 2 + p.Created.size() + p.Patched.size() + p.Deleted.size()
+}
+
+func (c *ComponentChanges) size() int {
+	size := uvarintSize(uint64(c.Len)) + uvarintSize(uint64(len(c.Components))) + len(c.Components)
+	size += uvarintSize(uint64(len(c.Entities)))
+	for _, entity := range c.Entities {
+		size += uvarintSize(uint64(entity))
+	}
+	return size
+}
+
+func uvarintSize(v uint64) int {
+	size := 1
+	for ; v >= 0x80; v >>= 7 {
+		size++
+	}
+	return size
+}
+
+func (c *ComponentChanges) appendBinary(dst []byte) []byte {
+	dst = binary.AppendUvarint(dst, uint64(c.Len))
+	dst = binary.AppendUvarint(dst, uint64(len(c.Components)))
+	dst = append(dst, c.Components...)
+	dst = binary.AppendUvarint(dst, uint64(len(c.Entities)))
+	for _, entity := range c.Entities {
+		dst = binary.AppendUvarint(dst, uint64(entity))
+	}
+	return dst
+}
+
+func (c *ComponentChanges) readBinary(data []byte) ([]byte, error) {
+	length, n := binary.Uvarint(data)
+	if n <= 0 {
+		return data, ErrMalformedPatch
+	}
+	data = data[n:]
+
+	size, n := binary.Uvarint(data)
+	if n <= 0 || size > uint64(len(data)-n) {
+		return data, ErrMalformedPatch
+	}
+	data = data[n:]
+	var components []byte
+	if size > 0 {
+		components = data[:size:size]
+	}
+	data = data[size:]
+
+	count, n := binary.Uvarint(data)
+	if n <= 0 || count > uint64(len(data)-n) || length > count {
+		return data, ErrMalformedPatch
+	}
+	data = data[n:]
+
+	var entities []Entity
+	if count > 0 {
+		entities = make([]Entity, count)
+	}
+	for i := range entities {
+		entity, n := binary.Uvarint(data)
+		if n <= 0 || entity > uint64(^entityType(0)) {
+			return data, ErrMalformedPatch
+		}
+		entities[i] = Entity(entity)
+		data = data[n:]
+	}
+
+	c.Len = int(length)
+	c.Components = components
+	c.Entities = entities
+	return data, nil
+}
@@ -0,0 +1,93 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package ecs
+
+import (
+	"encoding/binary"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type patchTestComponents struct {
+	Health ComponentManager[int32]
+}
+
+func newPatchTestWorld() *World[patchTestComponents, struct{}] {
+	health := NewComponentManager[int32](0)
+	health.TrackChanges = true
+	health.SetEncoder(func(components []int32) []byte {
+		data := make([]byte, 0, len(components)*4)
+		for _, c := range components {
+			data = binary.LittleEndian.AppendUint32(data, uint32(c))
+		}
+		return data
+	})
+	health.SetDecoder(func(data []byte) []int32 {
+		components := make([]int32, len(data)/4)
+		for i := range components {
+			components[i] = int32(binary.LittleEndian.Uint32(data[i*4:]))
+		}
+		return components
+	})
+
+	// World registers pointers to its components, so it must not be copied after Init
+	world := NewWorld(patchTestComponents{Health: health}, struct{}{})
+	world.Init()
+	return &world
+}
+
+func TestPatchSnapshotRestore(t *testing.T) {
+	world := newPatchTestWorld()
+	for i := range 3 {
+		world.Components.Health.Create(world.Entities.Create(), int32(100+i))
+	}
+
+	snapshot := world.Entities.PatchSnapshot()
+	data, err := snapshot.MarshalBinary()
+	require.NoError(t, err)
+
+	var decoded Patch
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	require.Equal(t, snapshot, decoded)
+
+	_, err = decoded.MarshalBinary()
+	require.NoError(t, err)
+	require.Error(t, new(Patch).UnmarshalBinary(data[:len(data)-1]))
+
+	// Diverge and restore
+	other := newPatchTestWorld()
+	other.Components.Health.Create(10, 1)
+	other.Components.Health.Create(2, 5)
+	require.NoError(t, other.Entities.PatchRestore(decoded))
+
+	entities := other.Components.Health.RawEntities(nil)
+	slices.Sort(entities)
+	require.Equal(t, []Entity{1, 2, 3}, entities)
+	require.Equal(t, int32(101), *other.Components.Health.Get(2))
+
+	// Truncated keyframe is rejected without touching the components
+	truncated := slices.Clone(decoded)
+	truncated[0].Created.Components = truncated[0].Created.Components[:8]
+	broken := newPatchTestWorld()
+	broken.Components.Health.Create(10, 1)
+	require.ErrorIs(t, broken.Entities.PatchRestore(truncated), ErrMalformedPatch)
+	require.Equal(t, []Entity{10}, broken.Components.Health.RawEntities(nil))
+}
+
+func TestComponentPatchSize(t *testing.T) {
+	patch := Patch{{
+		ID:      3,
+		Created: ComponentChanges{Len: 2, Components: make([]byte, 200), Entities: []Entity{1, 300}},
+		Deleted: ComponentChanges{Len: 1, Entities: []Entity{7}},
+	}}
+
+	data, err := patch.MarshalBinary()
+	require.NoError(t, err)
+	require.Equal(t, len(data)-1, patch[0].Size()) // Without patch length prefix
+}
@@ -0,0 +1,75 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package render
+
+import (
+	"gomp/vectors"
+	"math"
+)
+
+// Camera maps world to screen like raylib Camera2D: Target is drawn at Offset,
+// the world is rotated by Rotation degrees clockwise around it and scaled by Zoom.
+// Drawing is clipped to Viewport, an empty Viewport clips nothing.
+type Camera struct {
+	Offset   vectors.Vec2
+	Target   vectors.Vec2
+	Rotation float32
+	Zoom     float32
+	Viewport Rectangle
+}
+
+func (c Camera) WorldToScreen(point vectors.Vec2) vectors.Vec2 {
+	sin, cos := c.sincos()
+	x, y := float64(point.X-c.Target.X), float64(point.Y-c.Target.Y)
+	zoom := float64(c.Zoom)
+	return vectors.Vec2{
+		X: c.Offset.X + float32((x*cos-y*sin)*zoom),
+		Y: c.Offset.Y + float32((x*sin+y*cos)*zoom),
+	}
+}
+
+func (c Camera) ScreenToWorld(point vectors.Vec2) vectors.Vec2 {
+	if c.Zoom == 0 {
+		return c.Target
+	}
+	sin, cos := c.sincos()
+	zoom := float64(c.Zoom)
+	x, y := float64(point.X-c.Offset.X)/zoom, float64(point.Y-c.Offset.Y)/zoom
+	return vectors.Vec2{
+		X: c.Target.X + float32(x*cos+y*sin),
+		Y: c.Target.Y + float32(-x*sin+y*cos),
+	}
+}
+
+// VisibleRect is the world space bounding box of Viewport
+func (c Camera) VisibleRect() Rectangle {
+	v := c.Viewport
+	corners := [4]vectors.Vec2{
+		c.ScreenToWorld(vectors.Vec2{X: v.X, Y: v.Y}),
+		c.ScreenToWorld(vectors.Vec2{X: v.X + v.Width, Y: v.Y}),
+		c.ScreenToWorld(vectors.Vec2{X: v.X, Y: v.Y + v.Height}),
+		c.ScreenToWorld(vectors.Vec2{X: v.X + v.Width, Y: v.Y + v.Height}),
+	}
+	minX, minY, maxX, maxY := corners[0].X, corners[0].Y, corners[0].X, corners[0].Y
+	for _, corner := range corners[1:] {
+		minX, minY = min(minX, corner.X), min(minY, corner.Y)
+		maxX, maxY = max(maxX, corner.X), max(maxY, corner.Y)
+	}
+	return Rectangle{X: minX, Y: minY, Width: maxX - minX, Height: maxY - minY}
+}
+
+func (c Camera) sincos() (sin, cos float64) {
+	return math.Sincos(float64(c.Rotation) * math.Pi / 180)
+}
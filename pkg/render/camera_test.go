@@ -0,0 +1,31 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package render
+
+import (
+	"github.com/stretchr/testify/require"
+	"gomp/vectors"
+	"testing"
+)
+
+func TestCameraVisibleRect(t *testing.T) {
+	camera := Camera{
+		Offset:   vectors.Vec2{X: 50, Y: 25},
+		Target:   vectors.Vec2{X: 1000, Y: 500},
+		Zoom:     2,
+		Viewport: NewRectangle(0, 0, 100, 50),
+	}
+	require.Equal(t, NewRectangle(975, 487.5, 50, 25), camera.VisibleRect())
+
+	// Rotated by 90 degrees the visible area turns sideways
+	camera.Rotation = 90
+	visible := camera.VisibleRect()
+	require.InDelta(t, 987.5, visible.X, 0.001)
+	require.InDelta(t, 475, visible.Y, 0.001)
+	require.InDelta(t, 25, visible.Width, 0.001)
+	require.InDelta(t, 50, visible.Height, 0.001)
+}
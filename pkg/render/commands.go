@@ -0,0 +1,125 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package render
+
+import "gomp/vectors"
+
+type CommandKind uint8
+
+const (
+	CommandClear CommandKind = iota
+	CommandTexture
+	CommandRectangle
+	CommandText
+	CommandBeginCamera
+	CommandEndCamera
+)
+
+// Command is a single draw call. Fields not used by Kind are zero.
+type Command struct {
+	Kind     CommandKind
+	Texture  Texture
+	Source   Rectangle
+	Dest     Rectangle
+	Origin   vectors.Vec2 // Rotation pivot relative to Dest position
+	Rotation float32      // Degrees, clockwise
+	Color    Color        // Tint for textures, fill for the rest
+	Text     string
+	FontSize float32
+	Camera   Camera
+}
+
+// CommandList records draw calls in submission order, later commands are drawn on top.
+// The backing array is kept between frames by Reset.
+type CommandList struct {
+	commands []Command
+}
+
+func NewCommandList(capacity int) CommandList {
+	return CommandList{commands: make([]Command, 0, capacity)}
+}
+
+func (l *CommandList) Clear(color Color) {
+	l.commands = append(l.commands, Command{Kind: CommandClear, Color: color})
+}
+
+func (l *CommandList) DrawTexture(texture Texture, source, dest Rectangle, origin vectors.Vec2, rotation float32, tint Color) {
+	l.commands = append(l.commands, Command{
+		Kind:     CommandTexture,
+		Texture:  texture,
+		Source:   source,
+		Dest:     dest,
+		Origin:   origin,
+		Rotation: rotation,
+		Color:    tint,
+	})
+}
+
+func (l *CommandList) DrawRectangle(dest Rectangle, origin vectors.Vec2, rotation float32, color Color) {
+	l.commands = append(l.commands, Command{
+		Kind:     CommandRectangle,
+		Dest:     dest,
+		Origin:   origin,
+		Rotation: rotation,
+		Color:    color,
+	})
+}
+
+func (l *CommandList) DrawText(text string, x, y, fontSize float32, color Color) {
+	l.commands = append(l.commands, Command{
+		Kind:     CommandText,
+		Dest:     Rectangle{X: x, Y: y},
+		Text:     text,
+		FontSize: fontSize,
+		Color:    color,
+	})
+}
+
+// DrawRectangleLines records the outline of dest, thickness is in the units of dest and drawn inside it
+func (l *CommandList) DrawRectangleLines(dest Rectangle, thickness float32, color Color) {
+	var origin vectors.Vec2
+	l.DrawRectangle(Rectangle{X: dest.X, Y: dest.Y, Width: dest.Width, Height: thickness}, origin, 0, color)
+	l.DrawRectangle(Rectangle{X: dest.X, Y: dest.Y + dest.Height - thickness, Width: dest.Width, Height: thickness}, origin, 0, color)
+	l.DrawRectangle(Rectangle{X: dest.X, Y: dest.Y, Width: thickness, Height: dest.Height}, origin, 0, color)
+	l.DrawRectangle(Rectangle{X: dest.X + dest.Width - thickness, Y: dest.Y, Width: thickness, Height: dest.Height}, origin, 0, color)
+}
+
+// BeginCamera draws the following commands through camera until EndCamera.
+// Clear inside a camera only clears its viewport.
+func (l *CommandList) BeginCamera(camera Camera) {
+	l.commands = append(l.commands, Command{Kind: CommandBeginCamera, Camera: camera})
+}
+
+func (l *CommandList) EndCamera() {
+	l.commands = append(l.commands, Command{Kind: CommandEndCamera})
+}
+
+// Append records the commands of other after the ones of l
+func (l *CommandList) Append(other *CommandList) {
+	l.commands = append(l.commands, other.commands...)
+}
+
+func (l *CommandList) Commands() []Command {
+	return l.commands
+}
+
+func (l *CommandList) Len() int {
+	return len(l.commands)
+}
+
+func (l *CommandList) Reset() {
+	clear(l.commands)
+	l.commands = l.commands[:0]
+}
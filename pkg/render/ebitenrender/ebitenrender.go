@@ -0,0 +1,269 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+// Package ebitenrender is the ebiten render.Backend.
+//
+// Ebiten owns its main loop, so Open runs ebiten.RunGame on a separate goroutine
+// and Present hands the command list over to it. The last presented list is drawn
+// on every ebiten frame. On macOS ebiten must run on the main thread, there the
+// engine itself has to be moved to a goroutine instead.
+package ebitenrender
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"gomp/pkg/render"
+	"gomp/vectors"
+	"image"
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+var _ render.Backend = (*Backend)(nil)
+
+func New() *Backend {
+	pixel := ebiten.NewImage(1, 1)
+	pixel.Fill(render.White)
+	return &Backend{
+		textures:       make(map[uint32]*ebiten.Image),
+		pixel:          pixel,
+		keysDown:       make(map[render.Key]bool),
+		keysPressed:    make(map[render.Key]bool),
+		buttonsDown:    make(map[render.MouseButton]bool),
+		buttonsPressed: make(map[render.MouseButton]bool),
+	}
+}
+
+type Backend struct {
+	mx       sync.Mutex
+	config   render.WindowConfig
+	textures map[uint32]*ebiten.Image
+	nextId   uint32
+	pixel    *ebiten.Image // Scaled to draw rectangles
+	frame    []render.Command
+
+	closing atomic.Bool
+	closed  atomic.Bool
+	err     error
+
+	keysDown       map[render.Key]bool
+	keysPressed    map[render.Key]bool
+	buttonsDown    map[render.MouseButton]bool
+	buttonsPressed map[render.MouseButton]bool
+	mouse          vectors.Vec2
+}
+
+func (b *Backend) Open(config render.WindowConfig) error {
+	b.config = config
+	ebiten.SetWindowSize(config.Width, config.Height)
+	ebiten.SetWindowTitle(config.Title)
+	ebiten.SetVsyncEnabled(config.VSync)
+	if config.TargetFPS > 0 {
+		ebiten.SetTPS(config.TargetFPS)
+	}
+	go func() {
+		err := ebiten.RunGame((*game)(b))
+		b.mx.Lock()
+		b.err = err
+		b.mx.Unlock()
+		b.closed.Store(true)
+	}()
+	return nil
+}
+
+func (b *Backend) Close() {
+	b.closing.Store(true)
+}
+
+func (b *Backend) ShouldClose() bool {
+	return b.closed.Load()
+}
+
+// Err is the error ebiten.RunGame returned, if any
+func (b *Backend) Err() error {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.err
+}
+
+func (b *Backend) Size() (width, height int) {
+	return b.config.Width, b.config.Height
+}
+
+func (b *Backend) LoadTexture(img image.Image) render.Texture {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	bounds := img.Bounds()
+	b.nextId++
+	b.textures[b.nextId] = ebiten.NewImageFromImage(img)
+	return render.Texture{ID: b.nextId, Width: int32(bounds.Dx()), Height: int32(bounds.Dy())}
+}
+
+func (b *Backend) UnloadTexture(texture render.Texture) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	if img, ok := b.textures[texture.ID]; ok {
+		img.Deallocate()
+		delete(b.textures, texture.ID)
+	}
+}
+
+// Present copies the list, it is drawn by ebiten until the next Present
+func (b *Backend) Present(list *render.CommandList) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	b.frame = append(b.frame[:0], list.Commands()...)
+	clear(b.keysPressed)
+	clear(b.buttonsPressed)
+}
+
+func (b *Backend) IsKeyDown(key render.Key) bool {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.keysDown[key]
+}
+
+func (b *Backend) IsKeyPressed(key render.Key) bool {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.keysPressed[key]
+}
+
+func (b *Backend) IsMouseButtonDown(button render.MouseButton) bool {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.buttonsDown[button]
+}
+
+func (b *Backend) IsMouseButtonPressed(button render.MouseButton) bool {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.buttonsPressed[button]
+}
+
+func (b *Backend) MousePosition() vectors.Vec2 {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.mouse
+}
+
+// game is the ebiten.Game side of Backend, it runs on the ebiten goroutine
+type game Backend
+
+func (g *game) Update() error {
+	if g.closing.Load() {
+		return ebiten.Termination
+	}
+
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	// Pressed keys accumulate until Present, the engine may run slower than ebiten ticks
+	for key, ebitenKey := range keys {
+		down := ebiten.IsKeyPressed(ebitenKey)
+		if down && !g.keysDown[key] {
+			g.keysPressed[key] = true
+		}
+		g.keysDown[key] = down
+	}
+	for button, ebitenButton := range buttons {
+		down := ebiten.IsMouseButtonPressed(ebitenButton)
+		if down && !g.buttonsDown[button] {
+			g.buttonsPressed[button] = true
+		}
+		g.buttonsDown[button] = down
+	}
+	x, y := ebiten.CursorPosition()
+	g.mouse = vectors.Vec2{X: float32(x), Y: float32(y)}
+	return nil
+}
+
+func (g *game) Draw(screen *ebiten.Image) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	target, view := screen, ebiten.GeoM{}
+	for i := range g.frame {
+		command := &g.frame[i]
+		switch command.Kind {
+		case render.CommandClear:
+			target.Fill(command.Color)
+		case render.CommandTexture:
+			texture, ok := g.textures[command.Texture.ID]
+			if !ok {
+				continue
+			}
+			g.drawImage(target, texture, command, view)
+		case render.CommandRectangle:
+			g.drawImage(target, g.pixel, command, view)
+		case render.CommandText:
+			// Debug font only, size and color are ignored
+			x, y := view.Apply(float64(command.Dest.X), float64(command.Dest.Y))
+			ebitenutil.DebugPrintAt(target, command.Text, int(x), int(y))
+		case render.CommandBeginCamera:
+			camera := command.Camera
+			view = ebiten.GeoM{}
+			view.Translate(-float64(camera.Target.X), -float64(camera.Target.Y))
+			view.Rotate(float64(camera.Rotation) * math.Pi / 180)
+			view.Scale(float64(camera.Zoom), float64(camera.Zoom))
+			view.Translate(float64(camera.Offset.X), float64(camera.Offset.Y))
+			// Sub images keep screen coordinates and clip to their bounds
+			if viewport := camera.Viewport; viewport.Width > 0 && viewport.Height > 0 {
+				target = screen.SubImage(image.Rect(int(viewport.X), int(viewport.Y),
+					int(viewport.X+viewport.Width), int(viewport.Y+viewport.Height))).(*ebiten.Image)
+			}
+		case render.CommandEndCamera:
+			target, view = screen, ebiten.GeoM{}
+		}
+	}
+}
+
+func (g *game) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return g.config.Width, g.config.Height
+}
+
+// drawImage places the source rectangle of img the same way raylib DrawTexturePro does, then applies the camera view
+func (g *game) drawImage(screen, img *ebiten.Image, command *render.Command, view ebiten.GeoM) {
+	source := command.Source
+	if img == g.pixel {
+		source = render.Rectangle{Width: 1, Height: 1}
+	}
+	width, height := math.Abs(float64(source.Width)), math.Abs(float64(source.Height))
+	if width == 0 || height == 0 {
+		return
+	}
+	// Negative sizes flip the region starting at X, Y, as in raylib
+	x, y := float64(source.X), float64(source.Y)
+	sub := img.SubImage(image.Rect(int(x), int(y), int(x+width), int(y+height))).(*ebiten.Image)
+
+	var op ebiten.DrawImageOptions
+	if source.Width < 0 {
+		op.GeoM.Scale(-1, 1)
+		op.GeoM.Translate(width, 0)
+	}
+	if source.Height < 0 {
+		op.GeoM.Scale(1, -1)
+		op.GeoM.Translate(0, height)
+	}
+	op.GeoM.Scale(float64(command.Dest.Width)/width, float64(command.Dest.Height)/height)
+	op.GeoM.Translate(-float64(command.Origin.X), -float64(command.Origin.Y))
+	op.GeoM.Rotate(float64(command.Rotation) * math.Pi / 180)
+	op.GeoM.Translate(float64(command.Dest.X), float64(command.Dest.Y))
+	op.GeoM.Concat(view)
+
+	// Ebiten colors are premultiplied
+	c := command.Color
+	a := float32(c.A) / 255
+	op.ColorScale.Scale(float32(c.R)/255*a, float32(c.G)/255*a, float32(c.B)/255*a, a)
+	screen.DrawImage(sub, &op)
+}
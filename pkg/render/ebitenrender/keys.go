@@ -0,0 +1,105 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package ebitenrender
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"gomp/pkg/render"
+)
+
+var keys = map[render.Key]ebiten.Key{
+	render.KeySpace:      ebiten.KeySpace,
+	render.KeyApostrophe: ebiten.KeyQuote,
+	render.KeyComma:      ebiten.KeyComma,
+	render.KeyMinus:      ebiten.KeyMinus,
+	render.KeyPeriod:     ebiten.KeyPeriod,
+	render.KeySlash:      ebiten.KeySlash,
+	render.Key0:          ebiten.KeyDigit0,
+	render.Key1:          ebiten.KeyDigit1,
+	render.Key2:          ebiten.KeyDigit2,
+	render.Key3:          ebiten.KeyDigit3,
+	render.Key4:          ebiten.KeyDigit4,
+	render.Key5:          ebiten.KeyDigit5,
+	render.Key6:          ebiten.KeyDigit6,
+	render.Key7:          ebiten.KeyDigit7,
+	render.Key8:          ebiten.KeyDigit8,
+	render.Key9:          ebiten.KeyDigit9,
+	render.KeySemicolon:  ebiten.KeySemicolon,
+	render.KeyEqual:      ebiten.KeyEqual,
+	render.KeyA:          ebiten.KeyA,
+	render.KeyB:          ebiten.KeyB,
+	render.KeyC:          ebiten.KeyC,
+	render.KeyD:          ebiten.KeyD,
+	render.KeyE:          ebiten.KeyE,
+	render.KeyF:          ebiten.KeyF,
+	render.KeyG:          ebiten.KeyG,
+	render.KeyH:          ebiten.KeyH,
+	render.KeyI:          ebiten.KeyI,
+	render.KeyJ:          ebiten.KeyJ,
+	render.KeyK:          ebiten.KeyK,
+	render.KeyL:          ebiten.KeyL,
+	render.KeyM:          ebiten.KeyM,
+	render.KeyN:          ebiten.KeyN,
+	render.KeyO:          ebiten.KeyO,
+	render.KeyP:          ebiten.KeyP,
+	render.KeyQ:          ebiten.KeyQ,
+	render.KeyR:          ebiten.KeyR,
+	render.KeyS:          ebiten.KeyS,
+	render.KeyT:          ebiten.KeyT,
+	render.KeyU:          ebiten.KeyU,
+	render.KeyV:          ebiten.KeyV,
+	render.KeyW:          ebiten.KeyW,
+	render.KeyX:          ebiten.KeyX,
+	render.KeyY:          ebiten.KeyY,
+	render.KeyZ:          ebiten.KeyZ,
+	render.KeyEscape:     ebiten.KeyEscape,
+	render.KeyEnter:      ebiten.KeyEnter,
+	render.KeyTab:        ebiten.KeyTab,
+	render.KeyBackspace:  ebiten.KeyBackspace,
+	render.KeyInsert:     ebiten.KeyInsert,
+	render.KeyDelete:     ebiten.KeyDelete,
+	render.KeyRight:      ebiten.KeyArrowRight,
+	render.KeyLeft:       ebiten.KeyArrowLeft,
+	render.KeyDown:       ebiten.KeyArrowDown,
+	render.KeyUp:         ebiten.KeyArrowUp,
+	render.KeyPageUp:     ebiten.KeyPageUp,
+	render.KeyPageDown:   ebiten.KeyPageDown,
+	render.KeyHome:       ebiten.KeyHome,
+	render.KeyEnd:        ebiten.KeyEnd,
+	render.KeyF1:         ebiten.KeyF1,
+	render.KeyF2:         ebiten.KeyF2,
+	render.KeyF3:         ebiten.KeyF3,
+	render.KeyF4:         ebiten.KeyF4,
+	render.KeyF5:         ebiten.KeyF5,
+	render.KeyF6:         ebiten.KeyF6,
+	render.KeyF7:         ebiten.KeyF7,
+	render.KeyF8:         ebiten.KeyF8,
+	render.KeyF9:         ebiten.KeyF9,
+	render.KeyF10:        ebiten.KeyF10,
+	render.KeyF11:        ebiten.KeyF11,
+	render.KeyF12:        ebiten.KeyF12,
+	render.KeyLeftShift:  ebiten.KeyShiftLeft,
+	render.KeyLeftCtrl:   ebiten.KeyControlLeft,
+	render.KeyLeftAlt:    ebiten.KeyAltLeft,
+	render.KeyRightShift: ebiten.KeyShiftRight,
+	render.KeyRightCtrl:  ebiten.KeyControlRight,
+	render.KeyRightAlt:   ebiten.KeyAltRight,
+}
+
+var buttons = map[render.MouseButton]ebiten.MouseButton{
+	render.MouseButtonLeft:   ebiten.MouseButtonLeft,
+	render.MouseButtonRight:  ebiten.MouseButtonRight,
+	render.MouseButtonMiddle: ebiten.MouseButtonMiddle,
+}
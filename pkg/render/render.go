@@ -0,0 +1,111 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+// Package render is a backend neutral drawing API.
+// Components and systems speak in terms of texture handles, rectangles and command lists,
+// while backends (raylib, ebiten, software) turn them into pixels.
+package render
+
+import (
+	"gomp/vectors"
+	"image"
+	"image/color"
+)
+
+// Color is a non premultiplied 8 bit color
+type Color = color.RGBA
+
+var (
+	White       = Color{R: 255, G: 255, B: 255, A: 255}
+	Black       = Color{A: 255}
+	Transparent = Color{}
+)
+
+// Texture is a handle to an image uploaded to a backend.
+// ID 0 is never a valid texture.
+type Texture struct {
+	ID     uint32
+	Width  int32
+	Height int32
+}
+
+func (t Texture) Valid() bool {
+	return t.ID != 0
+}
+
+// Rectangle is a float rectangle, negative Width or Height of a source rectangle flips the image
+type Rectangle struct {
+	X, Y, Width, Height float32
+}
+
+func NewRectangle(x, y, width, height float32) Rectangle {
+	return Rectangle{X: x, Y: y, Width: width, Height: height}
+}
+
+func (r Rectangle) Position() vectors.Vec2 {
+	return vectors.Vec2{X: r.X, Y: r.Y}
+}
+
+func (r Rectangle) Size() vectors.Vec2 {
+	return vectors.Vec2{X: r.Width, Y: r.Height}
+}
+
+func (r Rectangle) Contains(point vectors.Vec2) bool {
+	return point.X >= r.X && point.X < r.X+r.Width && point.Y >= r.Y && point.Y < r.Y+r.Height
+}
+
+func (r Rectangle) Intersects(other Rectangle) bool {
+	return r.X < other.X+other.Width && other.X < r.X+r.Width &&
+		r.Y < other.Y+other.Height && other.Y < r.Y+r.Height
+}
+
+// WindowConfig describes a window to open
+type WindowConfig struct {
+	Title     string
+	Width     int
+	Height    int
+	TargetFPS int  // 0 leaves the backend default
+	VSync     bool // Presents in sync with the display refresh
+}
+
+// Window is the platform window a backend presents into
+type Window interface {
+	Open(config WindowConfig) error
+	Close()
+	ShouldClose() bool
+	Size() (width, height int)
+}
+
+// Input is keyboard and mouse state of a window.
+// Pressed means the key went down since the previous Present.
+type Input interface {
+	IsKeyDown(key Key) bool
+	IsKeyPressed(key Key) bool
+	IsMouseButtonDown(button MouseButton) bool
+	IsMouseButtonPressed(button MouseButton) bool
+	MousePosition() vectors.Vec2
+}
+
+// Backend turns command lists into frames
+type Backend interface {
+	Window
+	Input
+
+	LoadTexture(img image.Image) Texture
+	UnloadTexture(texture Texture)
+
+	// Present draws the list and shows the frame.
+	// The list may be reused by the caller after Present returns.
+	Present(list *CommandList)
+}
@@ -0,0 +1,159 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+// Package rlrender is the raylib render.Backend
+package rlrender
+
+import (
+	rl "github.com/gen2brain/raylib-go/raylib"
+	"gomp/pkg/render"
+	"gomp/vectors"
+	"image"
+)
+
+var _ render.Backend = (*Backend)(nil)
+
+// Default is the backend of the single raylib window
+var Default = New()
+
+func New() *Backend {
+	return &Backend{}
+}
+
+// Backend keeps no state of its own, raylib is global.
+// Texture handles are raylib texture ids, so handles and rl.Texture2D convert both ways.
+type Backend struct{}
+
+func (b *Backend) Open(config render.WindowConfig) error {
+	if config.VSync {
+		rl.SetConfigFlags(rl.FlagVsyncHint)
+	}
+	rl.InitWindow(int32(config.Width), int32(config.Height), config.Title)
+	if config.TargetFPS > 0 {
+		rl.SetTargetFPS(int32(config.TargetFPS))
+	}
+	return nil
+}
+
+func (b *Backend) Close() {
+	rl.CloseWindow()
+}
+
+func (b *Backend) ShouldClose() bool {
+	return rl.WindowShouldClose()
+}
+
+func (b *Backend) Size() (width, height int) {
+	return rl.GetScreenWidth(), rl.GetScreenHeight()
+}
+
+func (b *Backend) LoadTexture(img image.Image) render.Texture {
+	rlImg := rl.NewImageFromImage(img)
+	defer rl.UnloadImage(rlImg)
+	return FromTexture2D(rl.LoadTextureFromImage(rlImg))
+}
+
+func (b *Backend) UnloadTexture(texture render.Texture) {
+	rl.UnloadTexture(Texture2D(texture))
+}
+
+// Present draws the list between BeginDrawing and EndDrawing, which also poll input and wait for the frame time,
+// so it is called once per frame with every scene in the list
+func (b *Backend) Present(list *render.CommandList) {
+	rl.BeginDrawing()
+	Draw(list)
+	rl.EndDrawing()
+}
+
+func (b *Backend) IsKeyDown(key render.Key) bool {
+	return rl.IsKeyDown(int32(key))
+}
+
+func (b *Backend) IsKeyPressed(key render.Key) bool {
+	return rl.IsKeyPressed(int32(key))
+}
+
+func (b *Backend) IsMouseButtonDown(button render.MouseButton) bool {
+	return rl.IsMouseButtonDown(rl.MouseButton(button))
+}
+
+func (b *Backend) IsMouseButtonPressed(button render.MouseButton) bool {
+	return rl.IsMouseButtonPressed(rl.MouseButton(button))
+}
+
+func (b *Backend) MousePosition() vectors.Vec2 {
+	return Vec2(rl.GetMousePosition())
+}
+
+// Draw replays the list without Begin/EndDrawing, e.g. inside rl.BeginMode2D
+func Draw(list *render.CommandList) {
+	for i := range list.Commands() {
+		command := &list.Commands()[i]
+		switch command.Kind {
+		case render.CommandClear:
+			rl.ClearBackground(command.Color)
+		case render.CommandTexture:
+			rl.DrawTexturePro(Texture2D(command.Texture), Rectangle(command.Source), Rectangle(command.Dest),
+				Vector2(command.Origin), command.Rotation, command.Color)
+		case render.CommandRectangle:
+			rl.DrawRectanglePro(Rectangle(command.Dest), Vector2(command.Origin), command.Rotation, command.Color)
+		case render.CommandText:
+			rl.DrawText(command.Text, int32(command.Dest.X), int32(command.Dest.Y), int32(command.FontSize), command.Color)
+		case render.CommandBeginCamera:
+			if viewport := command.Camera.Viewport; viewport.Width > 0 && viewport.Height > 0 {
+				rl.BeginScissorMode(int32(viewport.X), int32(viewport.Y), int32(viewport.Width), int32(viewport.Height))
+			}
+			rl.BeginMode2D(Camera2D(command.Camera))
+		case render.CommandEndCamera:
+			rl.EndMode2D()
+			rl.EndScissorMode()
+		}
+	}
+}
+
+// Texture2D assumes the texture was loaded by this backend: one mipmap, 8 bit RGBA
+func Texture2D(texture render.Texture) rl.Texture2D {
+	return rl.Texture2D{
+		ID:      texture.ID,
+		Width:   texture.Width,
+		Height:  texture.Height,
+		Mipmaps: 1,
+		Format:  rl.UncompressedR8g8b8a8,
+	}
+}
+
+func Camera2D(camera render.Camera) rl.Camera2D {
+	return rl.Camera2D{
+		Offset:   Vector2(camera.Offset),
+		Target:   Vector2(camera.Target),
+		Rotation: camera.Rotation,
+		Zoom:     camera.Zoom,
+	}
+}
+
+func FromTexture2D(texture rl.Texture2D) render.Texture {
+	return render.Texture{ID: texture.ID, Width: texture.Width, Height: texture.Height}
+}
+
+func Rectangle(r render.Rectangle) rl.Rectangle {
+	return rl.Rectangle{X: r.X, Y: r.Y, Width: r.Width, Height: r.Height}
+}
+
+func Vector2(v vectors.Vec2) rl.Vector2 {
+	return rl.Vector2{X: v.X, Y: v.Y}
+}
+
+func Vec2(v rl.Vector2) vectors.Vec2 {
+	return vectors.Vec2{X: v.X, Y: v.Y}
+}
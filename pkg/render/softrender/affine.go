@@ -0,0 +1,77 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package softrender
+
+import (
+	"gomp/pkg/render"
+	"math"
+)
+
+// affine is a 2D transform: x' = a*x + b*y + tx, y' = c*x + d*y + ty
+type affine struct {
+	a, b, c, d, tx, ty float64
+}
+
+var identity = affine{a: 1, d: 1}
+
+func translation(x, y float64) affine {
+	return affine{a: 1, d: 1, tx: x, ty: y}
+}
+
+// rotation is clockwise in screen space, where y points down
+func rotation(degrees float64) affine {
+	sin, cos := math.Sincos(degrees * math.Pi / 180)
+	return affine{a: cos, b: -sin, c: sin, d: cos}
+}
+
+func scaling(x, y float64) affine {
+	return affine{a: x, d: y}
+}
+
+// mul applies other first, then m
+func (m affine) mul(other affine) affine {
+	return affine{
+		a:  m.a*other.a + m.b*other.c,
+		b:  m.a*other.b + m.b*other.d,
+		c:  m.c*other.a + m.d*other.c,
+		d:  m.c*other.b + m.d*other.d,
+		tx: m.a*other.tx + m.b*other.ty + m.tx,
+		ty: m.c*other.tx + m.d*other.ty + m.ty,
+	}
+}
+
+func (m affine) apply(x, y float64) (float64, float64) {
+	return m.a*x + m.b*y + m.tx, m.c*x + m.d*y + m.ty
+}
+
+func (m affine) inverse() (affine, bool) {
+	det := m.a*m.d - m.b*m.c
+	if det == 0 {
+		return affine{}, false
+	}
+	inv := affine{a: m.d / det, b: -m.b / det, c: -m.c / det, d: m.a / det}
+	inv.tx = -(inv.a*m.tx + inv.b*m.ty)
+	inv.ty = -(inv.c*m.tx + inv.d*m.ty)
+	return inv, true
+}
+
+// cameraTransform matches render.Camera.WorldToScreen
+func cameraTransform(camera render.Camera) affine {
+	zoom := float64(camera.Zoom)
+	return translation(float64(camera.Offset.X), float64(camera.Offset.Y)).
+		mul(scaling(zoom, zoom)).
+		mul(rotation(float64(camera.Rotation))).
+		mul(translation(-float64(camera.Target.X), -float64(camera.Target.Y)))
+}
@@ -0,0 +1,49 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package softrender
+
+import (
+	"image"
+	"image/color"
+)
+
+// Diff counts pixels of got that differ from want by more than tolerance in any channel.
+// Images of different size differ in every pixel of the larger one.
+func Diff(want, got image.Image, tolerance uint8) int {
+	wantBounds, gotBounds := want.Bounds(), got.Bounds()
+	if wantBounds.Size() != gotBounds.Size() {
+		return max(wantBounds.Dx()*wantBounds.Dy(), gotBounds.Dx()*gotBounds.Dy())
+	}
+
+	mismatched := 0
+	for y := 0; y < wantBounds.Dy(); y++ {
+		for x := 0; x < wantBounds.Dx(); x++ {
+			a := color.RGBAModel.Convert(want.At(wantBounds.Min.X+x, wantBounds.Min.Y+y)).(color.RGBA)
+			b := color.RGBAModel.Convert(got.At(gotBounds.Min.X+x, gotBounds.Min.Y+y)).(color.RGBA)
+			if channelDiff(a.R, b.R) > tolerance || channelDiff(a.G, b.G) > tolerance ||
+				channelDiff(a.B, b.B) > tolerance || channelDiff(a.A, b.A) > tolerance {
+				mismatched++
+			}
+		}
+	}
+	return mismatched
+}
+
+func channelDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
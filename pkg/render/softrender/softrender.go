@@ -0,0 +1,259 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+// Package softrender is a pure Go render.Backend that rasterizes into an image.RGBA.
+// It needs no GPU and no cgo, which makes it suitable for golden image tests.
+package softrender
+
+import (
+	"gomp/pkg/render"
+	"gomp/vectors"
+	"image"
+	"image/draw"
+	"math"
+)
+
+var _ render.Backend = (*Backend)(nil)
+
+// Backend draws with nearest sampling, tint multiplication and source over blending.
+// Text commands are skipped, there is no font rasterizer.
+// Input is driven by the Set* methods.
+type Backend struct {
+	frame    *image.RGBA
+	textures map[uint32]*image.NRGBA
+	nextId   uint32
+	closed   bool
+
+	keysDown       map[render.Key]bool
+	keysPressed    map[render.Key]bool
+	buttonsDown    map[render.MouseButton]bool
+	buttonsPressed map[render.MouseButton]bool
+	mouse          vectors.Vec2
+}
+
+func New(width, height int) *Backend {
+	return &Backend{
+		frame:          image.NewRGBA(image.Rect(0, 0, width, height)),
+		textures:       make(map[uint32]*image.NRGBA),
+		keysDown:       make(map[render.Key]bool),
+		keysPressed:    make(map[render.Key]bool),
+		buttonsDown:    make(map[render.MouseButton]bool),
+		buttonsPressed: make(map[render.MouseButton]bool),
+	}
+}
+
+// Image is the last presented frame, it is overwritten by the next Present
+func (b *Backend) Image() *image.RGBA {
+	return b.frame
+}
+
+func (b *Backend) Open(config render.WindowConfig) error {
+	b.frame = image.NewRGBA(image.Rect(0, 0, config.Width, config.Height))
+	b.closed = false
+	return nil
+}
+
+func (b *Backend) Close() {
+	b.closed = true
+}
+
+func (b *Backend) ShouldClose() bool {
+	return b.closed
+}
+
+func (b *Backend) Size() (width, height int) {
+	size := b.frame.Bounds().Size()
+	return size.X, size.Y
+}
+
+func (b *Backend) LoadTexture(img image.Image) render.Texture {
+	bounds := img.Bounds()
+	pixels := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(pixels, pixels.Bounds(), img, bounds.Min, draw.Src)
+
+	b.nextId++
+	b.textures[b.nextId] = pixels
+	return render.Texture{ID: b.nextId, Width: int32(bounds.Dx()), Height: int32(bounds.Dy())}
+}
+
+func (b *Backend) UnloadTexture(texture render.Texture) {
+	delete(b.textures, texture.ID)
+}
+
+func (b *Backend) Present(list *render.CommandList) {
+	view, clip := identity, b.frame.Bounds()
+	for i := range list.Commands() {
+		command := &list.Commands()[i]
+		switch command.Kind {
+		case render.CommandClear:
+			b.clear(clip, command.Color)
+		case render.CommandTexture:
+			texture, ok := b.textures[command.Texture.ID]
+			if !ok {
+				continue
+			}
+			b.rasterize(command, texture, view, clip)
+		case render.CommandRectangle:
+			b.rasterize(command, nil, view, clip)
+		case render.CommandBeginCamera:
+			view, clip = cameraTransform(command.Camera), b.frame.Bounds()
+			if viewport := command.Camera.Viewport; viewport.Width > 0 && viewport.Height > 0 {
+				clip = clip.Intersect(image.Rect(
+					int(math.Round(float64(viewport.X))), int(math.Round(float64(viewport.Y))),
+					int(math.Round(float64(viewport.X+viewport.Width))), int(math.Round(float64(viewport.Y+viewport.Height))),
+				))
+			}
+		case render.CommandEndCamera:
+			view, clip = identity, b.frame.Bounds()
+		}
+	}
+	clear(b.keysPressed)
+	clear(b.buttonsPressed)
+}
+
+func (b *Backend) SetKeyDown(key render.Key, down bool) {
+	if down && !b.keysDown[key] {
+		b.keysPressed[key] = true
+	}
+	b.keysDown[key] = down
+}
+
+func (b *Backend) SetMouseButtonDown(button render.MouseButton, down bool) {
+	if down && !b.buttonsDown[button] {
+		b.buttonsPressed[button] = true
+	}
+	b.buttonsDown[button] = down
+}
+
+func (b *Backend) SetMousePosition(position vectors.Vec2) {
+	b.mouse = position
+}
+
+func (b *Backend) IsKeyDown(key render.Key) bool {
+	return b.keysDown[key]
+}
+
+func (b *Backend) IsKeyPressed(key render.Key) bool {
+	return b.keysPressed[key]
+}
+
+func (b *Backend) IsMouseButtonDown(button render.MouseButton) bool {
+	return b.buttonsDown[button]
+}
+
+func (b *Backend) IsMouseButtonPressed(button render.MouseButton) bool {
+	return b.buttonsPressed[button]
+}
+
+func (b *Backend) MousePosition() vectors.Vec2 {
+	return b.mouse
+}
+
+func (b *Backend) clear(rect image.Rectangle, c render.Color) {
+	a := uint32(c.A)
+	pixel := [4]uint8{
+		uint8(uint32(c.R) * a / 255),
+		uint8(uint32(c.G) * a / 255),
+		uint8(uint32(c.B) * a / 255),
+		c.A,
+	}
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			i := b.frame.PixOffset(x, y)
+			copy(b.frame.Pix[i:i+4], pixel[:])
+		}
+	}
+}
+
+// rasterize fills the destination quad, sampling texture when it is not nil.
+// The quad is placed the way raylib DrawTexturePro places it and then transformed by view.
+// Pixel centers inside clip are mapped back into the quad local space.
+func (b *Backend) rasterize(command *render.Command, texture *image.NRGBA, view affine, clip image.Rectangle) {
+	dest := command.Dest
+	if dest.Width <= 0 || dest.Height <= 0 {
+		return
+	}
+
+	toScreen := view.mul(translation(float64(dest.X), float64(dest.Y))).
+		mul(rotation(float64(command.Rotation))).
+		mul(translation(-float64(command.Origin.X), -float64(command.Origin.Y)))
+	toLocal, ok := toScreen.inverse()
+	if !ok {
+		return
+	}
+
+	// Bounding box of the transformed quad
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, corner := range [4][2]float64{{0, 0}, {float64(dest.Width), 0}, {0, float64(dest.Height)}, {float64(dest.Width), float64(dest.Height)}} {
+		x, y := toScreen.apply(corner[0], corner[1])
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+	}
+	x0, y0 := max(int(math.Floor(minX)), clip.Min.X), max(int(math.Floor(minY)), clip.Min.Y)
+	x1, y1 := min(int(math.Ceil(maxX)), clip.Max.X), min(int(math.Ceil(maxY)), clip.Max.Y)
+
+	source := command.Source
+	flipX, flipY := source.Width < 0, source.Height < 0
+	sourceWidth, sourceHeight := math.Abs(float64(source.Width)), math.Abs(float64(source.Height))
+	tint := command.Color
+
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			lx, ly := toLocal.apply(float64(x)+0.5, float64(y)+0.5)
+			if lx < 0 || ly < 0 || lx >= float64(dest.Width) || ly >= float64(dest.Height) {
+				continue
+			}
+
+			c := tint
+			if texture != nil {
+				u := lx / float64(dest.Width) * sourceWidth
+				v := ly / float64(dest.Height) * sourceHeight
+				if flipX {
+					u = sourceWidth - u
+				}
+				if flipY {
+					v = sourceHeight - v
+				}
+				sx := int(math.Floor(float64(source.X) + u))
+				sy := int(math.Floor(float64(source.Y) + v))
+				if !(image.Point{X: sx, Y: sy}.In(texture.Rect)) {
+					continue
+				}
+				texel := texture.NRGBAAt(sx, sy)
+				c = render.Color{
+					R: uint8(uint32(texel.R) * uint32(tint.R) / 255),
+					G: uint8(uint32(texel.G) * uint32(tint.G) / 255),
+					B: uint8(uint32(texel.B) * uint32(tint.B) / 255),
+					A: uint8(uint32(texel.A) * uint32(tint.A) / 255),
+				}
+			}
+			b.blend(x, y, c)
+		}
+	}
+}
+
+func (b *Backend) blend(x, y int, c render.Color) {
+	if c.A == 0 {
+		return
+	}
+	i := b.frame.PixOffset(x, y)
+	pix := b.frame.Pix[i : i+4 : i+4]
+	a := uint32(c.A)
+	inv := 255 - a
+	pix[0] = uint8((uint32(c.R)*a + uint32(pix[0])*inv) / 255)
+	pix[1] = uint8((uint32(c.G)*a + uint32(pix[1])*inv) / 255)
+	pix[2] = uint8((uint32(c.B)*a + uint32(pix[2])*inv) / 255)
+	pix[3] = uint8((a*255 + uint32(pix[3])*inv) / 255)
+}
@@ -0,0 +1,110 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package softrender
+
+import (
+	"github.com/stretchr/testify/require"
+	"gomp/pkg/render"
+	"gomp/vectors"
+	"image"
+	"image/color"
+	"testing"
+)
+
+var (
+	red  = render.Color{R: 255, A: 255}
+	blue = render.Color{B: 255, A: 255}
+)
+
+// twoColorTexture is 2x1: red then blue
+func twoColorTexture(b *Backend) render.Texture {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.SetNRGBA(0, 0, color.NRGBA(red))
+	img.SetNRGBA(1, 0, color.NRGBA(blue))
+	return b.LoadTexture(img)
+}
+
+func TestBackendDrawTexture(t *testing.T) {
+	b := New(4, 2)
+	texture := twoColorTexture(b)
+	require.Equal(t, render.Texture{ID: 1, Width: 2, Height: 1}, texture)
+
+	list := render.NewCommandList(4)
+	list.Clear(render.Black)
+	list.DrawTexture(texture, render.NewRectangle(0, 0, 2, 1), render.NewRectangle(0, 0, 4, 2), vectors.Vec2{}, 0, render.White)
+	b.Present(&list)
+
+	img := b.Image()
+	require.Equal(t, red, img.RGBAAt(0, 0))
+	require.Equal(t, red, img.RGBAAt(1, 1))
+	require.Equal(t, blue, img.RGBAAt(2, 0))
+	require.Equal(t, blue, img.RGBAAt(3, 1))
+}
+
+func TestBackendFlipAndTint(t *testing.T) {
+	b := New(2, 1)
+	texture := twoColorTexture(b)
+
+	list := render.NewCommandList(4)
+	list.Clear(render.Black)
+	list.DrawTexture(texture, render.NewRectangle(0, 0, -2, 1), render.NewRectangle(0, 0, 2, 1), vectors.Vec2{}, 0, render.Color{R: 128, G: 255, B: 255, A: 255})
+	b.Present(&list)
+
+	require.Equal(t, blue, b.Image().RGBAAt(0, 0))
+	require.Equal(t, render.Color{R: 128, A: 255}, b.Image().RGBAAt(1, 0))
+}
+
+func TestBackendRotatedRectangle(t *testing.T) {
+	b := New(4, 4)
+
+	list := render.NewCommandList(4)
+	list.Clear(render.Black)
+	// 4x2 bar rotated by 90 degrees around its center becomes 2x4
+	list.DrawRectangle(render.NewRectangle(2, 2, 4, 2), vectors.Vec2{X: 2, Y: 1}, 90, red)
+	b.Present(&list)
+
+	img := b.Image()
+	require.Equal(t, render.Black, img.RGBAAt(0, 0))
+	require.Equal(t, red, img.RGBAAt(1, 0))
+	require.Equal(t, red, img.RGBAAt(2, 3))
+	require.Equal(t, render.Black, img.RGBAAt(3, 3))
+}
+
+func TestBackendBlend(t *testing.T) {
+	b := New(1, 1)
+
+	list := render.NewCommandList(4)
+	list.Clear(render.Black)
+	list.DrawRectangle(render.NewRectangle(0, 0, 1, 1), vectors.Vec2{}, 0, render.Color{R: 255, A: 128})
+	b.Present(&list)
+
+	require.Equal(t, render.Color{R: 128, A: 255}, b.Image().RGBAAt(0, 0))
+}
+
+func TestBackendInput(t *testing.T) {
+	b := New(1, 1)
+	list := render.NewCommandList(0)
+
+	b.SetKeyDown(render.KeySpace, true)
+	require.True(t, b.IsKeyPressed(render.KeySpace))
+	require.True(t, b.IsKeyDown(render.KeySpace))
+
+	b.Present(&list)
+	require.False(t, b.IsKeyPressed(render.KeySpace))
+	require.True(t, b.IsKeyDown(render.KeySpace))
+}
+
+func TestDiff(t *testing.T) {
+	a := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	b := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	b.SetRGBA(0, 0, render.Color{R: 3})
+	b.SetRGBA(1, 0, render.Color{G: 10})
+
+	require.Equal(t, 1, Diff(a, b, 4))
+	require.Equal(t, 0, Diff(a, b, 10))
+	require.Equal(t, 4, Diff(a, image.NewRGBA(image.Rect(0, 0, 2, 2)), 0))
+}
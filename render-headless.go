@@ -0,0 +1,57 @@
+//go:build headless
+
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package gomp
+
+import (
+	"gomp/pkg/render"
+	"time"
+)
+
+// Headless is true in builds with the headless tag, they have no window and no raylib dependency
+const Headless = true
+
+func NewRenderSystem() RenderSystem {
+	return RenderSystem{}
+}
+
+// Input is nil, headless builds have no keyboard
+var Input render.Input
+
+// RenderSystem has no window in headless builds.
+// Frames are presented only to a Backend set by hand, like softrender in tests.
+type RenderSystem struct {
+	Backend render.Backend
+}
+
+func (s *RenderSystem) Init() {}
+func (s *RenderSystem) Run(dt time.Duration) bool {
+	return true
+}
+func (s *RenderSystem) Present(list *render.CommandList) {
+	if s.Backend != nil {
+		s.Backend.Present(list)
+	}
+}
+func (s *RenderSystem) SetVSync(vsync bool) {}
+
+func (s *RenderSystem) Size() (width, height int) {
+	if s.Backend != nil {
+		return s.Backend.Size()
+	}
+	return 0, 0
+}
+func (s *RenderSystem) Destroy() {}
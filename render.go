@@ -1,3 +1,5 @@
+//go:build !headless
+
 /*
 This Source Code Form is subject to the terms of the Mozilla
 Public License, v. 2.0. If a copy of the MPL was not distributed
@@ -15,29 +17,62 @@ Thank you for your support!
 package gomp
 
 import (
-	rl "github.com/gen2brain/raylib-go/raylib"
+	"gomp/pkg/render"
+	"gomp/pkg/render/rlrender"
 	"time"
 )
 
+// Headless is true in builds with the headless tag, they have no window and no raylib dependency
+const Headless = false
+
 func NewRenderSystem() RenderSystem {
-	return RenderSystem{}
+	return RenderSystem{
+		Backend: rlrender.Default,
+		Window: render.WindowConfig{
+			Title:  "raylib [core] ebiten-ecs - basic window",
+			Width:  1280,
+			Height: 720,
+		},
+	}
 }
 
-type RenderSystem struct{}
+// Input is the keyboard and mouse of the window, std systems poll it for hotkeys.
+// RenderSystem.Init sets it to Backend, so input works with any render.Backend.
+var Input render.Input
+
+// RenderSystem owns the window of Backend
+type RenderSystem struct {
+	Backend render.Backend
+	Window  render.WindowConfig
+}
 
 func (s *RenderSystem) Init() {
-	//monitor := rl.GetCurrentMonitor()
-	//width, height := rl.GetMonitorWidth(monitor), rl.GetMonitorHeight(monitor)
-	rl.InitWindow(1280, 720, "raylib [core] ebiten-ecs - basic window")
-	//rl.SetWindowState(rl.FlagFullscreenMode)
+	if err := s.Backend.Open(s.Window); err != nil {
+		panic(err)
+	}
+	Input = s.Backend
 }
 func (s *RenderSystem) Run(dt time.Duration) bool {
-	if rl.WindowShouldClose() {
+	if s.Backend.ShouldClose() {
 		return false
 	}
 	return true
 }
 
+// Present shows the frame, Game calls it once per frame
+func (s *RenderSystem) Present(list *render.CommandList) {
+	s.Backend.Present(list)
+}
+
+func (s *RenderSystem) SetVSync(vsync bool) {
+	s.Window.VSync = vsync
+}
+
+func (s *RenderSystem) Size() (width, height int) {
+	return s.Backend.Size()
+}
+
 func (s *RenderSystem) Destroy() {
-	rl.CloseWindow()
+	Input = nil
+	s.Backend.Close()
 }
@@ -0,0 +1,221 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package gomp
+
+import (
+	"github.com/negrel/assert"
+	"slices"
+	"time"
+)
+
+const DefaultLoadBudget = 8 * time.Millisecond
+
+type sceneChangeKind uint8
+
+const (
+	scenePush sceneChangeKind = iota
+	scenePop
+	sceneReplace
+	sceneLoad
+)
+
+type sceneChange struct {
+	kind       sceneChangeKind
+	id         SceneId
+	loading    SceneId
+	transition Transition
+}
+
+type activeTransition struct {
+	active    bool
+	applied   bool
+	change    sceneChange
+	startedAt time.Time
+}
+
+type sceneLoading struct {
+	active     bool
+	target     SceneId
+	transition Transition
+	loaded     int
+}
+
+// Push covers the current scene with id. The covered scene keeps its state, but is not updated.
+// A scene can be on the stack more than once, it is initialized once and destroyed when its last copy is popped.
+func (g *Game) Push(id SceneId, transition Transition) {
+	g.changes = append(g.changes, sceneChange{kind: scenePush, id: id, transition: transition})
+}
+
+// Pop destroys the current scene and returns to the one below
+func (g *Game) Pop(transition Transition) {
+	g.changes = append(g.changes, sceneChange{kind: scenePop, transition: transition})
+}
+
+// Replace destroys the current scene and puts id in its place
+func (g *Game) Replace(id SceneId, transition Transition) {
+	g.changes = append(g.changes, sceneChange{kind: sceneReplace, id: id, transition: transition})
+}
+
+// Load replaces the current scene with loading, then initializes id and loads the assets it requested.
+// Assets are not loaded in the background: AssetLibrary loads them on the main thread within LoadBudget
+// every frame, as GPU uploads can not be done elsewhere.
+// Once everything is loaded, loading is replaced with id using the same transition.
+func (g *Game) Load(id SceneId, loading SceneId, transition Transition) {
+	g.changes = append(g.changes, sceneChange{kind: sceneLoad, id: id, loading: loading, transition: transition})
+}
+
+// LoadingProgress is the fraction of assets loaded by the running Load, for loading scenes to show
+func (g *Game) LoadingProgress() float32 {
+	total := g.loading.loaded
+	for _, library := range g.Assets {
+		total += library.Pending()
+	}
+	if total == 0 {
+		return 1
+	}
+	return float32(g.loading.loaded) / float32(total)
+}
+
+// Transitioning reports whether a transition is drawn
+func (g *Game) Transitioning() bool {
+	return g.transition.active
+}
+
+// updateSceneStack advances loading, transitions and queued scene changes
+func (g *Game) updateSceneStack() {
+	if g.loading.active && g.loadAssets() {
+		g.loading.active = false
+		g.Replace(g.loading.target, g.loading.transition)
+	}
+
+	for {
+		if g.transition.active {
+			progress := g.transitionProgress()
+			if !g.transition.applied && progress >= 0.5 {
+				g.applySceneChange(g.transition.change)
+				g.transition.applied = true
+			}
+			if progress < 1 {
+				return
+			}
+			g.transition = activeTransition{}
+		}
+
+		if len(g.changes) == 0 {
+			return
+		}
+		change := g.changes[0]
+		g.changes = g.changes[1:]
+
+		if change.transition == nil || change.transition.Duration() <= 0 {
+			g.applySceneChange(change)
+			continue
+		}
+		g.transition = activeTransition{active: true, change: change, startedAt: time.Now()}
+	}
+}
+
+// transitionProgress is measured in real time, so paused game time does not hold transitions
+func (g *Game) transitionProgress() float32 {
+	return min(float32(time.Since(g.transition.startedAt))/float32(g.transition.change.transition.Duration()), 1)
+}
+
+// loadAssets reports whether every requested asset is loaded
+func (g *Game) loadAssets() bool {
+	budget := g.LoadBudget
+	if budget == 0 {
+		budget = DefaultLoadBudget
+	}
+
+	start := time.Now()
+	for time.Since(start) < budget {
+		loaded := false
+		for _, library := range g.Assets {
+			if library.LoadNext() {
+				loaded = true
+				g.loading.loaded++
+				break
+			}
+		}
+		if !loaded {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Game) applySceneChange(change sceneChange) {
+	switch change.kind {
+	case scenePush:
+		g.scene(g.CurrentSceneId).OnExit()
+		g.pushScene(change.id)
+	case scenePop:
+		assert.True(len(g.stack) > 1, "Can not pop the last scene")
+		g.popScene()
+		g.scene(g.CurrentSceneId).OnEnter()
+	case sceneReplace:
+		g.popScene()
+		g.pushScene(change.id)
+	case sceneLoad:
+		if g.CurrentSceneId != change.loading {
+			g.popScene()
+			g.pushScene(change.loading)
+		}
+		// Assets are requested by Init and loaded while the loading scene is shown
+		g.initScene(change.id)
+		g.loading = sceneLoading{active: true, target: change.id, transition: change.transition}
+	}
+}
+
+func (g *Game) scene(id SceneId) AnyScene {
+	scene, ok := g.Scenes[id]
+	assert.True(ok, "Scene not found")
+	return scene
+}
+
+func (g *Game) initScene(id SceneId) {
+	if g.initialized[id] {
+		return
+	}
+	g.scene(id).Init()
+	g.initialized[id] = true
+}
+
+func (g *Game) destroyScene(id SceneId) {
+	if !g.initialized[id] {
+		return
+	}
+	g.scene(id).Destroy()
+	delete(g.initialized, id)
+}
+
+func (g *Game) pushScene(id SceneId) {
+	g.initScene(id)
+	g.stack = append(g.stack, id)
+	g.CurrentSceneId = id
+	g.scene(id).OnEnter()
+}
+
+func (g *Game) popScene() {
+	id := g.stack[len(g.stack)-1]
+	g.scene(id).OnExit()
+	g.stack = g.stack[:len(g.stack)-1]
+	if !slices.Contains(g.stack, id) {
+		g.destroyScene(id)
+	}
+	if len(g.stack) > 0 {
+		g.CurrentSceneId = g.stack[len(g.stack)-1]
+	}
+}
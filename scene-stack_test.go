@@ -0,0 +1,114 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package gomp
+
+import (
+	"fmt"
+	"gomp/pkg/render"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testScene struct {
+	Game   *Game
+	id     SceneId
+	events *[]string
+	next   SceneId
+	assets *AssetLibrary[int]
+}
+
+func (s *testScene) log(event string) {
+	*s.events = append(*s.events, fmt.Sprintf("%s %d", event, s.id))
+}
+
+func (s *testScene) Init() {
+	s.log("init")
+	s.next = s.id
+	if s.assets != nil {
+		s.assets.Get("a")
+		s.assets.Get("b")
+	}
+}
+func (s *testScene) Update(dt time.Duration) SceneId { return s.next }
+func (s *testScene) FixedUpdate(dt time.Duration)    {}
+func (s *testScene) Render(dt time.Duration)         {}
+func (s *testScene) Destroy()                        { s.log("destroy") }
+func (s *testScene) OnEnter()                        { s.log("enter") }
+func (s *testScene) OnExit()                         { s.log("exit") }
+func (s *testScene) Id() SceneId                     { return s.id }
+
+type testTransition struct{}
+
+func (testTransition) Duration() time.Duration                             { return time.Millisecond }
+func (testTransition) Draw(*render.CommandList, render.Rectangle, float32) {}
+
+func TestGameSceneStack(t *testing.T) {
+	var events []string
+	assets := CreateAssetLibrary(func(path string) int { return len(path) }, func(string, *int) {})
+	scenes := []*testScene{{id: 0}, {id: 1}, {id: 2, assets: &assets}}
+	game := NewGame(scenes[0], scenes[1], scenes[2])
+	game.Assets = []AnyAssetLibrary{&assets}
+	for _, scene := range scenes {
+		scene.events = &events
+	}
+	expect := func(expected ...string) {
+		t.Helper()
+		require.Equal(t, expected, events)
+		events = events[:0]
+	}
+
+	game.Init()
+	expect("init 0", "enter 0")
+
+	game.Push(1, nil)
+	game.Update(0)
+	expect("exit 0", "init 1", "enter 1")
+	require.Equal(t, SceneId(1), game.CurrentSceneId)
+
+	// The scene changes halfway through a transition
+	game.Pop(testTransition{})
+	game.Update(0)
+	require.True(t, game.Transitioning())
+	require.Empty(t, events)
+	time.Sleep(2 * time.Millisecond)
+	game.Update(0)
+	require.False(t, game.Transitioning())
+	expect("exit 1", "destroy 1", "enter 0")
+
+	// Returning another id from Update replaces the scene
+	scenes[0].next = 1
+	game.Update(0)
+	expect("exit 0", "destroy 0", "init 1", "enter 1")
+
+	// The target is initialized behind the loading scene, and entered once its assets are loaded
+	game.Load(2, 0, nil)
+	game.Update(0)
+	expect("exit 1", "destroy 1", "init 0", "enter 0", "init 2")
+	require.Equal(t, 2, assets.Pending())
+	require.Zero(t, game.LoadingProgress())
+	game.Update(0)
+	expect("exit 0", "destroy 0", "enter 2")
+	require.Equal(t, float32(1), game.LoadingProgress())
+	require.Equal(t, 1, *assets.Get("a"))
+
+	// Scene pushed over its own copy is destroyed with the last copy only
+	game.Push(1, nil)
+	game.Push(2, nil)
+	game.Update(0)
+	expect("exit 2", "init 1", "enter 1", "exit 1", "enter 2")
+	game.Pop(nil)
+	game.Update(0)
+	expect("exit 2", "enter 1")
+	game.Pop(nil)
+	game.Update(0)
+	expect("exit 1", "destroy 1", "enter 2")
+
+	game.Destroy()
+	expect("exit 2", "destroy 2")
+}
@@ -20,8 +20,11 @@ import (
 
 type SceneId uint16
 
+// AnyScene is a part of Game on the scene stack. Init and Destroy are called when it is put on and
+// taken off the stack, OnEnter and OnExit whenever it becomes or stops being the top scene.
 type AnyScene interface {
 	Init()
+	// Update returns the id of the scene to replace this one with, or its own id to stay
 	Update(dt time.Duration) SceneId
 	FixedUpdate(dt time.Duration)
 	Render(dt time.Duration)
@@ -30,3 +33,8 @@ type AnyScene interface {
 	OnExit()
 	Id() SceneId
 }
+
+// TransparentScene lets the scenes below it on the stack render first, like a pause menu over gameplay
+type TransparentScene interface {
+	Transparent() bool
+}
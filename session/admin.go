@@ -0,0 +1,101 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package session
+
+import (
+	"crypto/subtle"
+	"errors"
+	"gomp/network"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// RoomInfo is a room as shown by the admin API
+type RoomInfo struct {
+	Code      string           `json:"code"`
+	CreatedAt time.Time        `json:"created_at"`
+	Players   []network.PeerId `json:"players"`
+	Health    TickHealth       `json:"health"`
+}
+
+func (r *Room) Info() RoomInfo {
+	return RoomInfo{
+		Code:      r.Code,
+		CreatedAt: r.CreatedAt,
+		Players:   r.Players(),
+		Health:    r.Health(),
+	}
+}
+
+// NewAdminServer returns HTTP admin API of the manager:
+//
+//	GET    /rooms        list rooms with players and tick health
+//	POST   /rooms        create a room
+//	GET    /rooms/:code  show a room
+//	DELETE /rooms/:code  tear a room down
+//
+// Requests must carry "Authorization: Bearer <token>" unless the token is empty.
+func NewAdminServer(manager *Manager, token string) *echo.Echo {
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	e.Use(middleware.Recover())
+
+	if token != "" {
+		e.Use(middleware.KeyAuth(func(key string, c echo.Context) (bool, error) {
+			return subtle.ConstantTimeCompare([]byte(key), []byte(token)) == 1, nil
+		}))
+	}
+
+	e.GET("/rooms", func(c echo.Context) error {
+		rooms := manager.Rooms()
+		infos := make([]RoomInfo, len(rooms))
+		for i, room := range rooms {
+			infos[i] = room.Info()
+		}
+		return c.JSON(http.StatusOK, infos)
+	})
+
+	e.POST("/rooms", func(c echo.Context) error {
+		room, err := manager.CreateRoom()
+		if errors.Is(err, ErrRoomLimitReached) {
+			return echo.NewHTTPError(http.StatusServiceUnavailable, err.Error())
+		}
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusCreated, room.Info())
+	})
+
+	e.GET("/rooms/:code", func(c echo.Context) error {
+		room, ok := manager.Room(c.Param("code"))
+		if !ok {
+			return echo.NewHTTPError(http.StatusNotFound, ErrRoomNotFound.Error())
+		}
+		return c.JSON(http.StatusOK, room.Info())
+	})
+
+	e.DELETE("/rooms/:code", func(c echo.Context) error {
+		if err := manager.CloseRoom(c.Param("code")); err != nil {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	return e
+}
@@ -0,0 +1,76 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package session
+
+import (
+	"fmt"
+	"gomp/network"
+)
+
+const maxCodeLength = 32
+
+// RoomStatus is sent by the server in reply to join and leave requests, and when a room is closed
+type RoomStatus uint8
+
+const (
+	RoomJoined RoomStatus = iota
+	RoomLeft
+	RoomNotFound
+	RoomFull
+	RoomLimitReached
+	RoomClosed
+)
+
+func (s RoomStatus) String() string {
+	switch s {
+	case RoomJoined:
+		return "joined"
+	case RoomLeft:
+		return "left"
+	case RoomNotFound:
+		return "room not found"
+	case RoomFull:
+		return "room is full"
+	case RoomLimitReached:
+		return "too many rooms"
+	case RoomClosed:
+		return "room closed"
+	default:
+		return fmt.Sprintf("room status %d", uint8(s))
+	}
+}
+
+// JoinMessage asks the server to put the client into the room, an empty code creates a new room.
+// Send it over network.ChannelReliableOrdered.
+func JoinMessage(code string) []byte {
+	return append([]byte{byte(network.MessageRoomJoin)}, code...)
+}
+
+// LeaveMessage asks the server to take the client out of its room
+func LeaveMessage() []byte {
+	return []byte{byte(network.MessageRoomLeave)}
+}
+
+// ParseStatus decodes network.MessageRoomStatus received by the client
+func ParseStatus(data []byte) (RoomStatus, string, error) {
+	if len(data) < 2 || network.MessageType(data[0]) != network.MessageRoomStatus || len(data) > 2+maxCodeLength {
+		return 0, "", network.ErrMalformedMessage
+	}
+	return RoomStatus(data[1]), string(data[2:]), nil
+}
+
+func statusMessage(status RoomStatus, code string) []byte {
+	return append([]byte{byte(network.MessageRoomStatus), byte(status)}, code...)
+}
@@ -0,0 +1,261 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package session
+
+import (
+	"errors"
+	"gomp/network"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+var ErrNotInRoom = errors.New("peer is not in the room")
+
+// Simulation is the game of a single room, usually it owns an ecs.World.
+// All methods are called on the room goroutine.
+type Simulation interface {
+	Init(room *Room)
+	Update(dt time.Duration)
+	Destroy()
+}
+
+type PlayerEventType uint8
+
+const (
+	PlayerJoined PlayerEventType = iota
+	PlayerLeft
+)
+
+type PlayerEvent struct {
+	Peer network.PeerId
+	Type PlayerEventType
+}
+
+// TickHealth shows whether the room keeps up with its tick rate
+type TickHealth struct {
+	TickRate     int           `json:"tick_rate"`
+	Ticks        uint64        `json:"ticks"`
+	Overruns     uint64        `json:"overruns"` // Ticks which took longer than the tick interval
+	LastTick     time.Time     `json:"last_tick"`
+	LastDuration time.Duration `json:"last_duration"`
+	MaxDuration  time.Duration `json:"max_duration"`
+}
+
+// Room runs its Simulation at the manager tick rate on its own goroutine.
+// It is a network.RPCTransport limited to players of the room, so every room may have its own network.RPCRegistry.
+type Room struct {
+	Code      string
+	CreatedAt time.Time
+
+	manager    *Manager
+	simulation Simulation
+
+	mx           sync.RWMutex
+	players      map[network.PeerId]struct{}
+	emptySince   time.Time
+	closed       bool
+	health       TickHealth
+	stop         chan struct{}
+	stopOnce     sync.Once
+	done         chan struct{}
+	inbox        queue[network.Message]
+	events       queue[PlayerEvent]
+	emptyTimeout time.Duration
+}
+
+func newRoom(manager *Manager, code string) *Room {
+	now := time.Now()
+	room := &Room{
+		Code:         code,
+		CreatedAt:    now,
+		manager:      manager,
+		players:      make(map[network.PeerId]struct{}),
+		emptySince:   now,
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+		emptyTimeout: manager.config.EmptyTimeout,
+	}
+	room.health.TickRate = manager.config.TickRate
+	room.simulation = manager.config.NewSimulation(room)
+	return room
+}
+
+// Players returns peers currently in the room
+func (r *Room) Players() []network.PeerId {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+
+	players := slices.Collect(maps.Keys(r.players))
+	slices.Sort(players)
+	return players
+}
+
+func (r *Room) Health() TickHealth {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+	return r.health
+}
+
+// Receive returns the next game message sent by a player of the room
+func (r *Room) Receive() (network.Message, bool) {
+	return r.inbox.pop()
+}
+
+// PollEvent returns the next player join or leave event
+func (r *Room) PollEvent() (PlayerEvent, bool) {
+	return r.events.pop()
+}
+
+func (r *Room) Mode() network.Mode {
+	return network.ModeServer
+}
+
+// Send broadcasts data to every player of the room
+func (r *Room) Send(ch network.Channel, data []byte) error {
+	var errs []error
+	for _, peer := range r.Players() {
+		errs = append(errs, r.manager.transport.Send(peer, ch, data))
+	}
+	return errors.Join(errs...)
+}
+
+// SendTo sends data to a player of the room
+func (r *Room) SendTo(peer network.PeerId, ch network.Channel, data []byte) error {
+	r.mx.RLock()
+	_, ok := r.players[peer]
+	r.mx.RUnlock()
+	if !ok {
+		return ErrNotInRoom
+	}
+	return r.manager.transport.Send(peer, ch, data)
+}
+
+func (r *Room) run() {
+	defer close(r.done)
+
+	interval := time.Second / time.Duration(r.health.TickRate)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	r.simulation.Init(r)
+	defer r.simulation.Destroy()
+
+	last := time.Now()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case now := <-ticker.C:
+			dt := now.Sub(last)
+			last = now
+
+			r.simulation.Update(dt)
+			r.recordTick(now, time.Since(now), interval)
+
+			if r.isAbandoned(now) && r.manager.closeRoom(r, true) {
+				return
+			}
+		}
+	}
+}
+
+func (r *Room) recordTick(at time.Time, duration, interval time.Duration) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	r.health.Ticks++
+	r.health.LastTick = at
+	r.health.LastDuration = duration
+	r.health.MaxDuration = max(r.health.MaxDuration, duration)
+	if duration > interval {
+		r.health.Overruns++
+	}
+}
+
+func (r *Room) isAbandoned(now time.Time) bool {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+	return r.abandoned(now)
+}
+
+func (r *Room) abandoned(now time.Time) bool {
+	return r.emptyTimeout > 0 && len(r.players) == 0 && now.Sub(r.emptySince) >= r.emptyTimeout
+}
+
+// addPlayer is called with manager lock held
+func (r *Room) addPlayer(peer network.PeerId, maxPlayers int) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	if r.closed {
+		return ErrRoomNotFound
+	}
+	if _, ok := r.players[peer]; ok {
+		return nil
+	}
+	if maxPlayers > 0 && len(r.players) >= maxPlayers {
+		return ErrRoomFull
+	}
+
+	r.players[peer] = struct{}{}
+	r.events.push(PlayerEvent{Peer: peer, Type: PlayerJoined})
+	return nil
+}
+
+// removePlayer is called with manager lock held
+func (r *Room) removePlayer(peer network.PeerId) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	if _, ok := r.players[peer]; !ok {
+		return
+	}
+	delete(r.players, peer)
+	if len(r.players) == 0 {
+		r.emptySince = time.Now()
+	}
+	r.events.push(PlayerEvent{Peer: peer, Type: PlayerLeft})
+}
+
+func (r *Room) signalStop() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+	})
+}
+
+type queue[T any] struct {
+	mx     sync.Mutex
+	values []T
+}
+
+func (q *queue[T]) push(value T) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	q.values = append(q.values, value)
+}
+
+func (q *queue[T]) pop() (value T, ok bool) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+
+	if len(q.values) == 0 {
+		return value, false
+	}
+	value = q.values[0]
+	q.values = q.values[1:]
+	return value, true
+}
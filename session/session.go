@@ -0,0 +1,299 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+// Package session hosts several game rooms in a single dedicated server process.
+// Players join rooms by code, every room runs its own Simulation and receives messages of its players only.
+package session
+
+import (
+	"errors"
+	"gomp/network"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultTickRate     = 30
+	DefaultEmptyTimeout = time.Minute
+
+	codeLength   = 6
+	codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789" // No 0/O and 1/I look-alikes
+)
+
+var (
+	ErrRoomNotFound     = errors.New("room not found")
+	ErrRoomFull         = errors.New("room is full")
+	ErrRoomLimitReached = errors.New("too many rooms")
+)
+
+// Transport delivers messages to peers, network.QuicServer implements it
+type Transport interface {
+	Send(peer network.PeerId, ch network.Channel, data []byte) error
+}
+
+type Config struct {
+	TickRate     int           // Ticks per second of every room
+	MaxRooms     int           // 0 means unlimited
+	MaxPlayers   int           // Players per room, 0 means unlimited
+	EmptyTimeout time.Duration // Rooms without players are closed after it, never if 0
+
+	// NewSimulation creates the game of a new room, it is required
+	NewSimulation func(room *Room) Simulation
+}
+
+func DefaultConfig() Config {
+	return Config{
+		TickRate:     DefaultTickRate,
+		EmptyTimeout: DefaultEmptyTimeout,
+	}
+}
+
+// Manager creates rooms on demand, routes players and their messages to rooms and tears rooms down.
+type Manager struct {
+	transport Transport
+	config    Config
+
+	mx      sync.RWMutex
+	rooms   map[string]*Room
+	players map[network.PeerId]*Room
+}
+
+func NewManager(transport Transport, config Config) *Manager {
+	if config.TickRate <= 0 {
+		config.TickRate = DefaultTickRate
+	}
+
+	return &Manager{
+		transport: transport,
+		config:    config,
+		rooms:     make(map[string]*Room),
+		players:   make(map[network.PeerId]*Room),
+	}
+}
+
+// NewQuicManager serves rooms to peers of the server. It must be called before the server is started.
+func NewQuicManager(server *network.QuicServer, config Config) *Manager {
+	m := NewManager(server, config)
+	server.OnMessage = m.HandleMessage
+	server.OnPeerEvent = m.HandlePeerEvent
+	return m
+}
+
+// HandleMessage handles room requests and routes other messages to the room of the sender.
+// Messages of peers outside of rooms are dropped.
+func (m *Manager) HandleMessage(msg network.Message) {
+	if len(msg.Data) == 0 {
+		return
+	}
+
+	switch network.MessageType(msg.Data[0]) {
+	case network.MessageRoomJoin:
+		code := string(msg.Data[1:])
+		room, err := m.Join(msg.Peer, code)
+		status := RoomJoined
+		switch {
+		case errors.Is(err, ErrRoomNotFound):
+			status = RoomNotFound
+		case errors.Is(err, ErrRoomFull):
+			status = RoomFull
+		case errors.Is(err, ErrRoomLimitReached):
+			status = RoomLimitReached
+		default:
+			code = room.Code
+		}
+		m.transport.Send(msg.Peer, network.ChannelReliableOrdered, statusMessage(status, code))
+
+	case network.MessageRoomLeave:
+		if room := m.Leave(msg.Peer); room != nil {
+			m.transport.Send(msg.Peer, network.ChannelReliableOrdered, statusMessage(RoomLeft, room.Code))
+		}
+
+	default:
+		m.mx.RLock()
+		room := m.players[msg.Peer]
+		m.mx.RUnlock()
+		if room != nil {
+			room.inbox.push(msg)
+		}
+	}
+}
+
+// HandlePeerEvent takes disconnected peers out of their rooms
+func (m *Manager) HandlePeerEvent(event network.PeerEvent) {
+	if event.Type == network.PeerDisconnected || event.Type == network.PeerTimedOut {
+		m.Leave(event.Peer)
+	}
+}
+
+// CreateRoom starts a new room with a random code
+func (m *Manager) CreateRoom() (*Room, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return m.createRoom()
+}
+
+func (m *Manager) createRoom() (*Room, error) {
+	if m.config.MaxRooms > 0 && len(m.rooms) >= m.config.MaxRooms {
+		return nil, ErrRoomLimitReached
+	}
+
+	code := newCode()
+	for m.rooms[code] != nil {
+		code = newCode()
+	}
+
+	room := newRoom(m, code)
+	m.rooms[code] = room
+	go room.run()
+	return room, nil
+}
+
+// Room finds a room by code, codes are case-insensitive
+func (m *Manager) Room(code string) (*Room, bool) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	room, ok := m.rooms[strings.ToUpper(code)]
+	return room, ok
+}
+
+// Rooms returns all rooms ordered by creation time
+func (m *Manager) Rooms() []*Room {
+	m.mx.RLock()
+	rooms := make([]*Room, 0, len(m.rooms))
+	for _, room := range m.rooms {
+		rooms = append(rooms, room)
+	}
+	m.mx.RUnlock()
+
+	slices.SortFunc(rooms, func(a, b *Room) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return rooms
+}
+
+// PlayerRoom returns the room of the peer
+func (m *Manager) PlayerRoom(peer network.PeerId) (*Room, bool) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	room, ok := m.players[peer]
+	return room, ok
+}
+
+// Join puts the peer into the room, an empty code creates a new room. The peer leaves its previous room
+// only after it has joined the new one.
+func (m *Manager) Join(peer network.PeerId, code string) (*Room, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	var room *Room
+	if code == "" {
+		var err error
+		if room, err = m.createRoom(); err != nil {
+			return nil, err
+		}
+	} else if room = m.rooms[strings.ToUpper(code)]; room == nil {
+		return nil, ErrRoomNotFound
+	}
+
+	// The peer stays in its previous room when the new one does not take it
+	if err := room.addPlayer(peer, m.config.MaxPlayers); err != nil {
+		if code == "" {
+			delete(m.rooms, room.Code)
+			room.signalStop()
+		}
+		return nil, err
+	}
+
+	if previous := m.players[peer]; previous != nil && previous != room {
+		previous.removePlayer(peer)
+	}
+	m.players[peer] = room
+	return room, nil
+}
+
+// Leave takes the peer out of its room and returns the room
+func (m *Manager) Leave(peer network.PeerId) *Room {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	room := m.players[peer]
+	if room == nil {
+		return nil
+	}
+	room.removePlayer(peer)
+	delete(m.players, peer)
+	return room
+}
+
+// CloseRoom tears the room down, its players are notified with RoomClosed
+func (m *Manager) CloseRoom(code string) error {
+	room, ok := m.Room(code)
+	if !ok || !m.closeRoom(room, false) {
+		return ErrRoomNotFound
+	}
+	<-room.done
+	return nil
+}
+
+// Stop closes all rooms
+func (m *Manager) Stop() {
+	for _, room := range m.Rooms() {
+		m.closeRoom(room, false)
+		<-room.done
+	}
+}
+
+// closeRoom detaches the room and signals it to stop, it is safe to call from the room goroutine.
+// When abandoned is true, the room is closed only if it is still abandoned under the lock.
+func (m *Manager) closeRoom(room *Room, abandoned bool) bool {
+	m.mx.Lock()
+	if m.rooms[room.Code] != room {
+		m.mx.Unlock()
+		return false
+	}
+
+	room.mx.Lock()
+	if abandoned && !room.abandoned(time.Now()) {
+		room.mx.Unlock()
+		m.mx.Unlock()
+		return false
+	}
+	room.closed = true
+	players := make([]network.PeerId, 0, len(room.players))
+	for peer := range room.players {
+		players = append(players, peer)
+		delete(m.players, peer)
+	}
+	room.mx.Unlock()
+
+	delete(m.rooms, room.Code)
+	m.mx.Unlock()
+
+	for _, peer := range players {
+		m.transport.Send(peer, network.ChannelReliableOrdered, statusMessage(RoomClosed, room.Code))
+	}
+	room.signalStop()
+	return true
+}
+
+func newCode() string {
+	var code [codeLength]byte
+	for i := range code {
+		code[i] = codeAlphabet[rand.IntN(len(codeAlphabet))]
+	}
+	return string(code[:])
+}
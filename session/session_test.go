@@ -0,0 +1,231 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package session
+
+import (
+	"encoding/json"
+	"gomp/network"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testTimeout = 5 * time.Second
+
+type sentMessage struct {
+	peer network.PeerId
+	data []byte
+}
+
+type fakeTransport struct {
+	mx   sync.Mutex
+	sent []sentMessage
+}
+
+func (t *fakeTransport) Send(peer network.PeerId, ch network.Channel, data []byte) error {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	t.sent = append(t.sent, sentMessage{peer: peer, data: data})
+	return nil
+}
+
+func (t *fakeTransport) lastStatus(tb testing.TB, peer network.PeerId) (RoomStatus, string) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	for i := len(t.sent) - 1; i >= 0; i-- {
+		if t.sent[i].peer != peer {
+			continue
+		}
+		if status, code, err := ParseStatus(t.sent[i].data); err == nil {
+			return status, code
+		}
+	}
+	tb.Fatalf("no status sent to peer %d", peer)
+	return 0, ""
+}
+
+// echoSimulation sends received messages back to the room and counts ticks
+type echoSimulation struct {
+	room      *Room
+	ticks     atomic.Int64
+	destroyed atomic.Bool
+	players   atomic.Int64
+}
+
+func (s *echoSimulation) Init(room *Room) { s.room = room }
+func (s *echoSimulation) Destroy()        { s.destroyed.Store(true) }
+func (s *echoSimulation) Update(dt time.Duration) {
+	s.ticks.Add(1)
+	for event, ok := s.room.PollEvent(); ok; event, ok = s.room.PollEvent() {
+		if event.Type == PlayerJoined {
+			s.players.Add(1)
+		} else {
+			s.players.Add(-1)
+		}
+	}
+	for msg, ok := s.room.Receive(); ok; msg, ok = s.room.Receive() {
+		s.room.Send(network.ChannelReliableOrdered, msg.Data)
+	}
+}
+
+func newTestManager(t *testing.T, configure func(*Config)) (*Manager, *fakeTransport, map[string]*echoSimulation) {
+	var mx sync.Mutex
+	simulations := make(map[string]*echoSimulation)
+
+	config := DefaultConfig()
+	config.TickRate = 100
+	config.NewSimulation = func(room *Room) Simulation {
+		mx.Lock()
+		defer mx.Unlock()
+		simulation := &echoSimulation{}
+		simulations[room.Code] = simulation
+		return simulation
+	}
+	if configure != nil {
+		configure(&config)
+	}
+
+	transport := &fakeTransport{}
+	manager := NewManager(transport, config)
+	t.Cleanup(manager.Stop)
+	return manager, transport, simulations
+}
+
+func TestManagerRooms(t *testing.T) {
+	manager, transport, simulations := newTestManager(t, func(c *Config) {
+		c.MaxPlayers = 2
+	})
+
+	manager.HandleMessage(network.Message{Peer: 1, Data: JoinMessage("")})
+	status, code := transport.lastStatus(t, 1)
+	require.Equal(t, RoomJoined, status)
+	require.Len(t, code, codeLength)
+
+	manager.HandleMessage(network.Message{Peer: 2, Data: JoinMessage(code)})
+	manager.HandleMessage(network.Message{Peer: 3, Data: JoinMessage(code)})
+	status, _ = transport.lastStatus(t, 3)
+	require.Equal(t, RoomFull, status)
+
+	manager.HandleMessage(network.Message{Peer: 3, Data: JoinMessage("NOPE42")})
+	status, _ = transport.lastStatus(t, 3)
+	require.Equal(t, RoomNotFound, status)
+
+	room, ok := manager.Room(code)
+	require.True(t, ok)
+	require.Equal(t, []network.PeerId{1, 2}, room.Players())
+
+	// Joining a full room while already in a room keeps the previous room
+	manager.HandleMessage(network.Message{Peer: 3, Data: JoinMessage("")})
+	_, otherCode := transport.lastStatus(t, 3)
+	manager.HandleMessage(network.Message{Peer: 3, Data: JoinMessage(code)})
+	status, _ = transport.lastStatus(t, 3)
+	require.Equal(t, RoomFull, status)
+	other, ok := manager.PlayerRoom(3)
+	require.True(t, ok)
+	require.Equal(t, otherCode, other.Code)
+	require.Equal(t, []network.PeerId{3}, other.Players())
+	manager.Leave(3)
+	require.NoError(t, manager.CloseRoom(otherCode))
+
+	// Messages of players are routed to their room only
+	manager.HandleMessage(network.Message{Peer: 1, Data: []byte{0xAA}})
+	manager.HandleMessage(network.Message{Peer: 3, Data: []byte{0xBB}})
+	require.Eventually(t, func() bool {
+		transport.mx.Lock()
+		defer transport.mx.Unlock()
+		echoed := 0
+		for _, msg := range transport.sent {
+			if msg.data[0] == 0xAA {
+				echoed++
+			}
+		}
+		return echoed == 2
+	}, testTimeout, time.Millisecond)
+	for _, msg := range transport.sent {
+		require.NotEqual(t, byte(0xBB), msg.data[0])
+	}
+
+	simulation := simulations[code]
+	require.Eventually(t, func() bool { return simulation.players.Load() == 2 }, testTimeout, time.Millisecond)
+
+	manager.HandlePeerEvent(network.PeerEvent{Peer: 2, Type: network.PeerTimedOut})
+	require.Equal(t, []network.PeerId{1}, room.Players())
+
+	require.NoError(t, manager.CloseRoom(code))
+	require.True(t, simulation.destroyed.Load())
+	require.Empty(t, manager.Rooms())
+	status, _ = transport.lastStatus(t, 1)
+	require.Equal(t, RoomClosed, status)
+	_, ok = manager.PlayerRoom(1)
+	require.False(t, ok)
+}
+
+func TestManagerClosesEmptyRooms(t *testing.T) {
+	manager, _, simulations := newTestManager(t, func(c *Config) {
+		c.EmptyTimeout = 50 * time.Millisecond
+		c.MaxRooms = 1
+	})
+
+	room, err := manager.Join(1, "")
+	require.NoError(t, err)
+	_, err = manager.CreateRoom()
+	require.ErrorIs(t, err, ErrRoomLimitReached)
+
+	// Occupied room lives past the timeout
+	time.Sleep(100 * time.Millisecond)
+	_, ok := manager.Room(room.Code)
+	require.True(t, ok)
+	require.Positive(t, room.Health().Ticks)
+
+	manager.Leave(1)
+	require.Eventually(t, func() bool {
+		_, ok := manager.Room(room.Code)
+		return !ok
+	}, testTimeout, time.Millisecond)
+	require.Eventually(t, simulations[room.Code].destroyed.Load, testTimeout, time.Millisecond)
+}
+
+func TestAdminServer(t *testing.T) {
+	manager, _, _ := newTestManager(t, nil)
+	admin := NewAdminServer(manager, "secret")
+
+	request := func(method, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		admin.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := httptest.NewRecorder()
+	admin.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/rooms", nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = request(http.MethodPost, "/rooms")
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var created RoomInfo
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+
+	_, err := manager.Join(7, created.Code)
+	require.NoError(t, err)
+
+	rec = request(http.MethodGet, "/rooms")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var rooms []RoomInfo
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &rooms))
+	require.Len(t, rooms, 1)
+	require.Equal(t, []network.PeerId{7}, rooms[0].Players)
+	require.Equal(t, 100, rooms[0].Health.TickRate)
+
+	require.Equal(t, http.StatusNoContent, request(http.MethodDelete, "/rooms/"+created.Code).Code)
+	require.Equal(t, http.StatusNotFound, request(http.MethodGet, "/rooms/"+created.Code).Code)
+}
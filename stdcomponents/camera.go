@@ -0,0 +1,88 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package stdcomponents
+
+import (
+	"gomp/pkg/ecs"
+	"gomp/pkg/render"
+	"gomp/vectors"
+	"time"
+)
+
+// Camera2D is a view of the world drawn into Viewport, the rest is updated by CameraSystem.
+// Several active cameras make split screen, minimaps or picture in picture.
+type Camera2D struct {
+	Active     bool
+	Order      int              // Cameras are drawn in ascending Order
+	Viewport   render.Rectangle // Screen area the camera draws into
+	Background render.Color     // Viewport is cleared with it, transparent keeps what is below
+	Layers     RenderLayerMask  // Layers the camera draws, 0 draws all
+
+	Target    ecs.Entity       // Entity with Position to follow, 0 follows nothing
+	Position  vectors.Vec2     // World point at the viewport center
+	Zoom      float32          // 0 is treated as 1
+	Rotation  float32          // Degrees, clockwise
+	Bounds    render.Rectangle // World area the view is kept inside, empty disables
+	Smoothing float32          // Follow speed as exponential decay per second, 0 snaps to Target
+
+	ShakeIntensity float32 // Max shake offset in world units, fades out over ShakeDuration
+	ShakeDuration  time.Duration
+	ShakeLeft      time.Duration
+	ShakeOffset    vectors.Vec2
+}
+
+// Shake starts a new shake, replacing the current one
+func (c *Camera2D) Shake(intensity float32, duration time.Duration) {
+	c.ShakeIntensity = intensity
+	c.ShakeDuration = duration
+	c.ShakeLeft = duration
+}
+
+// Render is the camera in terms of render backends
+func (c *Camera2D) Render() render.Camera {
+	zoom := c.Zoom
+	if zoom == 0 {
+		zoom = 1
+	}
+	return render.Camera{
+		Offset: vectors.Vec2{
+			X: c.Viewport.X + c.Viewport.Width/2,
+			Y: c.Viewport.Y + c.Viewport.Height/2,
+		},
+		Target:   c.Position.Add(c.ShakeOffset),
+		Rotation: c.Rotation,
+		Zoom:     zoom,
+		Viewport: c.Viewport,
+	}
+}
+
+func (c *Camera2D) WorldToScreen(point vectors.Vec2) vectors.Vec2 {
+	return c.Render().WorldToScreen(point)
+}
+
+func (c *Camera2D) ScreenToWorld(point vectors.Vec2) vectors.Vec2 {
+	return c.Render().ScreenToWorld(point)
+}
+
+// VisibleRect is the world space bounding box of what the camera shows
+func (c *Camera2D) VisibleRect() render.Rectangle {
+	return c.Render().VisibleRect()
+}
+
+type Camera2DComponentManager = ecs.ComponentManager[Camera2D]
+
+func NewCamera2DComponentManager() Camera2DComponentManager {
+	return ecs.NewComponentManager[Camera2D](Camera2DComponentId)
+}
@@ -0,0 +1,147 @@
+// Code generated by gompcodec. DO NOT EDIT.
+
+package stdcomponents
+
+import (
+	"gomp/pkg/codec"
+	"gomp/pkg/ecs"
+)
+
+func init() {
+	ecs.RegisterCodec(AppendPosition, ReadPosition)
+	ecs.RegisterCodec(AppendRotation, ReadRotation)
+	ecs.RegisterCodec(AppendScale, ReadScale)
+	ecs.RegisterCodec(AppendFlip, ReadFlip)
+	ecs.RegisterCodec(AppendVelocity, ReadVelocity)
+	ecs.RegisterCodec(AppendNetwork, ReadNetwork)
+	ecs.RegisterCodec(AppendRigidBody, ReadRigidBody)
+}
+
+func AppendPosition(dst []byte, c *Position) []byte {
+	dst = codec.AppendFloat32(dst, c.XY.X)
+	dst = codec.AppendFloat32(dst, c.XY.Y)
+	return dst
+}
+
+func ReadPosition(data []byte, c *Position) ([]byte, error) {
+	var err error
+	if data, err = codec.ReadFloat32(data, &c.XY.X); err != nil {
+		return data, err
+	}
+	if data, err = codec.ReadFloat32(data, &c.XY.Y); err != nil {
+		return data, err
+	}
+	return data, nil
+}
+
+func AppendRotation(dst []byte, c *Rotation) []byte {
+	dst = codec.AppendFloat64(dst, c.Angle)
+	return dst
+}
+
+func ReadRotation(data []byte, c *Rotation) ([]byte, error) {
+	var err error
+	if data, err = codec.ReadFloat64(data, &c.Angle); err != nil {
+		return data, err
+	}
+	return data, nil
+}
+
+func AppendScale(dst []byte, c *Scale) []byte {
+	dst = codec.AppendFloat32(dst, c.XY.X)
+	dst = codec.AppendFloat32(dst, c.XY.Y)
+	return dst
+}
+
+func ReadScale(data []byte, c *Scale) ([]byte, error) {
+	var err error
+	if data, err = codec.ReadFloat32(data, &c.XY.X); err != nil {
+		return data, err
+	}
+	if data, err = codec.ReadFloat32(data, &c.XY.Y); err != nil {
+		return data, err
+	}
+	return data, nil
+}
+
+func AppendFlip(dst []byte, c *Flip) []byte {
+	var bits codec.BitWriter
+	dst = codec.AppendBitsBool(dst, &bits, c.X)
+	dst = codec.AppendBitsBool(dst, &bits, c.Y)
+	dst = bits.Flush(dst)
+	return dst
+}
+
+func ReadFlip(data []byte, c *Flip) ([]byte, error) {
+	var err error
+	var bits codec.BitReader
+	bits.Reset(data)
+	if err = codec.ReadBitsBool(&bits, &c.X); err != nil {
+		return data, err
+	}
+	if err = codec.ReadBitsBool(&bits, &c.Y); err != nil {
+		return data, err
+	}
+	data = bits.Rest()
+	return data, nil
+}
+
+func AppendVelocity(dst []byte, c *Velocity) []byte {
+	dst = codec.AppendFloat32(dst, c.X)
+	dst = codec.AppendFloat32(dst, c.Y)
+	return dst
+}
+
+func ReadVelocity(data []byte, c *Velocity) ([]byte, error) {
+	var err error
+	if data, err = codec.ReadFloat32(data, &c.X); err != nil {
+		return data, err
+	}
+	if data, err = codec.ReadFloat32(data, &c.Y); err != nil {
+		return data, err
+	}
+	return data, nil
+}
+
+func AppendNetwork(dst []byte, c *Network) []byte {
+	dst = codec.AppendVarint(dst, c.Id)
+	dst = codec.AppendVarint(dst, c.Owner)
+	dst = codec.AppendUint8(dst, c.Authority)
+	return dst
+}
+
+func ReadNetwork(data []byte, c *Network) ([]byte, error) {
+	var err error
+	if data, err = codec.ReadVarint(data, &c.Id); err != nil {
+		return data, err
+	}
+	if data, err = codec.ReadVarint(data, &c.Owner); err != nil {
+		return data, err
+	}
+	if data, err = codec.ReadUint8(data, &c.Authority); err != nil {
+		return data, err
+	}
+	return data, nil
+}
+
+func AppendRigidBody(dst []byte, c *RigidBody) []byte {
+	var bits codec.BitWriter
+	dst = codec.AppendBitsBool(dst, &bits, c.IsStatic)
+	dst = bits.Flush(dst)
+	dst = codec.AppendFloat32(dst, c.Mass)
+	return dst
+}
+
+func ReadRigidBody(data []byte, c *RigidBody) ([]byte, error) {
+	var err error
+	var bits codec.BitReader
+	bits.Reset(data)
+	if err = codec.ReadBitsBool(&bits, &c.IsStatic); err != nil {
+		return data, err
+	}
+	data = bits.Rest()
+	if data, err = codec.ReadFloat32(data, &c.Mass); err != nil {
+		return data, err
+	}
+	return data, nil
+}
@@ -10,6 +10,8 @@ import (
 	"gomp/pkg/ecs"
 )
 
+//go:generate go run gomp/cmd/gompcodec -type=Position,Rotation,Scale,Flip,Velocity,Network,RigidBody
+
 // StdComponentIds MUST always be the last
 const (
 	InvalidComponentId ecs.ComponentId = iota
@@ -40,5 +42,14 @@ const (
 	AABBComponentId
 	RigidBodyComponentId
 	BvhTreeComponentId
+	InterpolationComponentId
+	NetworkObserverComponentId
+	NetworkPriorityComponentId
+	NetworkPeerComponentId
+	NetworkStatsOverlayComponentId
+	Camera2DComponentId
+	RenderLayerComponentId
+	PreviousTransformComponentId
+	TimeGroupComponentId
 	StdComponentIds
 )
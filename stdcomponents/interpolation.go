@@ -0,0 +1,159 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package stdcomponents
+
+import (
+	"gomp/pkg/ecs"
+	"gomp/vectors"
+	"time"
+)
+
+const (
+	InterpolationBufferSize = 32
+
+	// DefaultInterpolationDelay is two patches behind with 20Hz patch rate
+	DefaultInterpolationDelay = 100 * time.Millisecond
+	// DefaultMaxExtrapolation is how long entity keeps moving after the last received patch
+	DefaultMaxExtrapolation = 250 * time.Millisecond
+)
+
+// TransformSnapshot is a state of Position, Rotation and Scale received at some point in time
+type TransformSnapshot struct {
+	At       time.Time
+	Position vectors.Vec2
+	Rotation vectors.Radians
+	Scale    vectors.Vec2
+}
+
+func (s TransformSnapshot) Lerp(to TransformSnapshot, t float64) TransformSnapshot {
+	return TransformSnapshot{
+		At:       s.At.Add(time.Duration(float64(to.At.Sub(s.At)) * t)),
+		Position: s.Position.Lerp(to.Position, float32(t)),
+		Rotation: vectors.LerpAngle(s.Rotation, to.Rotation, t),
+		Scale:    s.Scale.Lerp(to.Scale, float32(t)),
+	}
+}
+
+// NewInterpolation returns Interpolation with DefaultInterpolationDelay and DefaultMaxExtrapolation
+func NewInterpolation() Interpolation {
+	return Interpolation{
+		Delay:            DefaultInterpolationDelay,
+		MaxExtrapolation: DefaultMaxExtrapolation,
+	}
+}
+
+// Interpolation buffers snapshots of a remote entity, so it is rendered at now - Delay
+// between two known states instead of snapping to every received patch.
+// The zero value renders the newest snapshot without extrapolation, NewInterpolation sets the defaults.
+type Interpolation struct {
+	Delay            time.Duration
+	MaxExtrapolation time.Duration
+
+	Snapshots [InterpolationBufferSize]TransformSnapshot
+	Head      int // index of the newest snapshot
+	Len       int
+
+	// Rendered is the last state written by InterpolationSystem
+	Rendered TransformSnapshot
+}
+
+// Push adds a new snapshot. Snapshots older than the newest one are dropped.
+func (i *Interpolation) Push(snapshot TransformSnapshot) {
+	if i.Len > 0 && snapshot.At.Before(i.Snapshots[i.Head].At) {
+		return
+	}
+
+	i.Head = (i.Head + 1) % InterpolationBufferSize
+	i.Snapshots[i.Head] = snapshot
+	if i.Len < InterpolationBufferSize {
+		i.Len++
+	}
+}
+
+// Latest returns the newest snapshot, nil if there are none
+func (i *Interpolation) Latest() *TransformSnapshot {
+	if i.Len == 0 {
+		return nil
+	}
+	return &i.Snapshots[i.Head]
+}
+
+// Get returns snapshot by index, where 0 is the oldest one
+func (i *Interpolation) Get(index int) *TransformSnapshot {
+	oldest := i.Head - i.Len + 1
+	return &i.Snapshots[(oldest+index+InterpolationBufferSize)%InterpolationBufferSize]
+}
+
+// Sample returns the state at the given time. Between snapshots the state is interpolated,
+// after the newest one it is extrapolated for MaxExtrapolation, then clamped to the newest one.
+func (i *Interpolation) Sample(at time.Time) (TransformSnapshot, bool) {
+	if i.Len == 0 {
+		return TransformSnapshot{}, false
+	}
+
+	oldest := i.Get(0)
+	if !at.After(oldest.At) {
+		return *oldest, true
+	}
+
+	newest := i.Get(i.Len - 1)
+	if at.After(newest.At) {
+		if i.Len < 2 || i.MaxExtrapolation <= 0 {
+			return *newest, true
+		}
+
+		prev := i.Get(i.Len - 2)
+		interval := newest.At.Sub(prev.At)
+		if interval <= 0 {
+			return *newest, true
+		}
+
+		over := at.Sub(newest.At)
+		if over > i.MaxExtrapolation {
+			// No patch for too long, entity has most likely stopped
+			return *newest, true
+		}
+		return prev.Lerp(*newest, 1+float64(over)/float64(interval)), true
+	}
+
+	for index := i.Len - 2; index >= 0; index-- {
+		from := i.Get(index)
+		if at.Before(from.At) {
+			continue
+		}
+
+		to := i.Get(index + 1)
+		interval := to.At.Sub(from.At)
+		if interval <= 0 {
+			return *to, true
+		}
+
+		return from.Lerp(*to, float64(at.Sub(from.At))/float64(interval)), true
+	}
+
+	return *oldest, true
+}
+
+// Reset drops all buffered snapshots, e.g. after teleport
+func (i *Interpolation) Reset() {
+	i.Head = 0
+	i.Len = 0
+}
+
+type InterpolationComponentManager = ecs.ComponentManager[Interpolation]
+
+func NewInterpolationComponentManager() InterpolationComponentManager {
+	return ecs.NewComponentManager[Interpolation](InterpolationComponentId)
+}
@@ -0,0 +1,45 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package stdcomponents
+
+import (
+	"gomp/network"
+	"gomp/pkg/ecs"
+)
+
+// NetworkObserver marks an entity, which position defines area of interest of a peer.
+// Usually it is a player character or a camera. Peer can have several observers.
+type NetworkObserver struct {
+	Peer   network.PeerId
+	Radius float32 // 0 means NetworkInterestSystem.Radius
+}
+
+type NetworkObserverComponentManager = ecs.ComponentManager[NetworkObserver]
+
+func NewNetworkObserverComponentManager() NetworkObserverComponentManager {
+	return ecs.NewComponentManager[NetworkObserver](NetworkObserverComponentId)
+}
+
+// NetworkPriority scales how often entity is replicated when peer bandwidth budget is exceeded.
+// Entities without the component have priority 1.
+type NetworkPriority struct {
+	Value float32
+}
+
+type NetworkPriorityComponentManager = ecs.ComponentManager[NetworkPriority]
+
+func NewNetworkPriorityComponentManager() NetworkPriorityComponentManager {
+	return ecs.NewComponentManager[NetworkPriority](NetworkPriorityComponentId)
+}
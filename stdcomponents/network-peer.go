@@ -0,0 +1,44 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package stdcomponents
+
+import (
+	"gomp/network"
+	"gomp/pkg/ecs"
+	"time"
+)
+
+type NetworkPeerState uint8
+
+const (
+	NetworkPeerStateNone NetworkPeerState = iota
+	NetworkPeerStateConnected
+	NetworkPeerStateDisconnected
+	NetworkPeerStateTimedOut
+)
+
+// NetworkPeer Marks a proxy entity as representing a connected peer.
+// Disconnected and timed out peers stay for a single tick, so systems can react, and then are deleted.
+type NetworkPeer struct {
+	Id    network.PeerId
+	State NetworkPeerState
+	RTT   time.Duration
+}
+
+type NetworkPeerComponentManager = ecs.ComponentManager[NetworkPeer]
+
+func NewNetworkPeerComponentManager() NetworkPeerComponentManager {
+	return ecs.NewComponentManager[NetworkPeer](NetworkPeerComponentId)
+}
@@ -0,0 +1,44 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package stdcomponents
+
+import (
+	"gomp/network"
+	"gomp/pkg/ecs"
+	"time"
+)
+
+// NetworkStatsOverlay is network traffic prepared for drawing next to the FPS counter.
+// NetworkStatsSystem keeps it up to date, render systems draw Lines when Visible.
+type NetworkStatsOverlay struct {
+	Visible bool
+
+	Peers                    int
+	RTT                      time.Duration // Average of all peers
+	Loss                     float64       // Share of lost unreliable messages, from 0 to 1
+	BytesSentPerSecond       float64
+	BytesReceivedPerSecond   float64
+	PacketsSentPerSecond     float64
+	PacketsReceivedPerSecond float64
+	Components               []network.ComponentStats // Bytes per second of every replicated component
+
+	Lines []string
+}
+
+type NetworkStatsOverlayComponentManager = ecs.ComponentManager[NetworkStatsOverlay]
+
+func NewNetworkStatsOverlayComponentManager() NetworkStatsOverlayComponentManager {
+	return ecs.NewComponentManager[NetworkStatsOverlay](NetworkStatsOverlayComponentId)
+}
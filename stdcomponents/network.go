@@ -14,13 +14,51 @@ Thank you for your support!
 
 package stdcomponents
 
-import "gomp/pkg/ecs"
+import (
+	"gomp/network"
+	"gomp/pkg/ecs"
+)
 
 type NetworkId int32
+
+// NetworkAuthority defines who is allowed to change state of a networked entity
+type NetworkAuthority uint8
+
+const (
+	// NetworkAuthorityServer entities are changed by the server only
+	NetworkAuthorityServer NetworkAuthority = iota
+	// NetworkAuthorityOwner entities are changed by the owner peer, e.g. client predicted player character
+	NetworkAuthorityOwner
+	// NetworkAuthorityShared entities are changed by any peer and the server keeps the resulting state
+	NetworkAuthorityShared
+)
+
 type Network struct {
-	Id       NetworkId
-	PatchIn  []byte
-	PatchOut []byte
+	Id        NetworkId
+	Owner     network.PeerId // network.ServerPeerId, the zero value, for entities owned by the server
+	Authority NetworkAuthority
+	PatchIn   []byte `gomp:"-"`
+	PatchOut  []byte `gomp:"-"`
+}
+
+// IsAuthority reports whether the peer holds the true state of the entity, such peers get no replicated state of it
+func (n *Network) IsAuthority(peer network.PeerId) bool {
+	if n.Authority == NetworkAuthorityOwner {
+		return peer == n.Owner
+	}
+	return peer == network.ServerPeerId
+}
+
+// CanWrite reports whether the server accepts changes of the entity from the peer
+func (n *Network) CanWrite(peer network.PeerId) bool {
+	switch n.Authority {
+	case NetworkAuthorityOwner:
+		return peer == n.Owner
+	case NetworkAuthorityShared:
+		return true
+	default:
+		return peer == network.ServerPeerId
+	}
 }
 
 type NetworkComponentManager = ecs.ComponentManager[Network]
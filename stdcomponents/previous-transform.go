@@ -0,0 +1,35 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package stdcomponents
+
+import (
+	"gomp/pkg/ecs"
+	"gomp/vectors"
+)
+
+// PreviousTransform is Position, Rotation and Scale before the last fixed update,
+// sprites with it are rendered between it and the current state by the interpolation alpha.
+type PreviousTransform struct {
+	Position vectors.Vec2
+	Rotation vectors.Radians
+	Scale    vectors.Vec2
+	Valid    bool // False until the first snapshot, the current state is rendered as is
+}
+
+type PreviousTransformComponentManager = ecs.ComponentManager[PreviousTransform]
+
+func NewPreviousTransformComponentManager() PreviousTransformComponentManager {
+	return ecs.NewComponentManager[PreviousTransform](PreviousTransformComponentId)
+}
@@ -0,0 +1,89 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package stdcomponents
+
+import "gomp/pkg/ecs"
+
+type RenderLayerId uint8
+
+// Layers are drawn in ascending order
+const (
+	BackgroundRenderLayer RenderLayerId = iota
+	WorldRenderLayer
+	EffectsRenderLayer
+	UIRenderLayer
+	RenderLayerCount
+)
+
+func (l RenderLayerId) Mask() RenderLayerMask {
+	return 1 << l
+}
+
+// RenderLayerMask is a set of layers, 0 is all of them
+type RenderLayerMask uint8
+
+func (m RenderLayerMask) Has(layer RenderLayerId) bool {
+	return m == 0 || m&layer.Mask() != 0
+}
+
+type RenderSortMode uint8
+
+const (
+	RenderSortY         RenderSortMode = iota // By RenderOrder.CalculatedZ, written by YSortSystem
+	RenderSortZ                               // By RenderLayer.Z
+	RenderSortInsertion                       // By component order, which is creation order until entities are removed
+)
+
+// RenderLayer puts an entity on a layer, entities without one are on WorldRenderLayer
+type RenderLayer struct {
+	Layer RenderLayerId
+	Z     float32 // Order inside RenderSortZ layers
+}
+
+type RenderLayerComponentManager = ecs.ComponentManager[RenderLayer]
+
+func NewRenderLayerComponentManager() RenderLayerComponentManager {
+	return ecs.NewComponentManager[RenderLayer](RenderLayerComponentId)
+}
+
+type RenderLayerSettings struct {
+	Name   string
+	Sort   RenderSortMode
+	Hidden bool
+	Debug  bool // Draws sprite bounds of the layer
+}
+
+// RenderLayers configures every layer. Put it in the component list to share it between
+// YSortSystem and RenderSystem, systems without one use NewRenderLayers defaults.
+type RenderLayers [RenderLayerCount]RenderLayerSettings
+
+func NewRenderLayers() RenderLayers {
+	return RenderLayers{
+		BackgroundRenderLayer: {Name: "background", Sort: RenderSortZ},
+		WorldRenderLayer:      {Name: "world", Sort: RenderSortY},
+		EffectsRenderLayer:    {Name: "effects", Sort: RenderSortInsertion},
+		UIRenderLayer:         {Name: "ui", Sort: RenderSortZ},
+	}
+}
+
+var defaultRenderLayers = NewRenderLayers()
+
+// Get is safe on a nil table
+func (l *RenderLayers) Get(layer RenderLayerId) RenderLayerSettings {
+	if l == nil {
+		return defaultRenderLayers[layer]
+	}
+	return l[layer]
+}
@@ -15,20 +15,38 @@ Thank you for your support!
 package stdcomponents
 
 import (
-	rl "github.com/gen2brain/raylib-go/raylib"
 	"gomp/pkg/ecs"
+	"gomp/pkg/render"
+	"gomp/vectors"
 	"image/color"
+	"math"
 )
 
+// RLTexturePro is the draw data of one textured quad, placed as by raylib DrawTexturePro.
+// It only holds render types and can be drawn by any render.Backend.
 type RLTexturePro struct {
-	Texture  *rl.Texture2D
-	Frame    rl.Rectangle
-	Origin   rl.Vector2
+	Texture  *render.Texture
+	Frame    render.Rectangle
+	Origin   vectors.Vec2
 	Tint     color.RGBA
-	Dest     rl.Rectangle
+	Dest     render.Rectangle
 	Rotation float32
 }
 
+// Bounds is the world space bounding box of the rotated quad
+func (t *RLTexturePro) Bounds() render.Rectangle {
+	sin, cos := math.Sincos(float64(t.Rotation) * math.Pi / 180)
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, corner := range [4][2]float32{{0, 0}, {t.Dest.Width, 0}, {0, t.Dest.Height}, {t.Dest.Width, t.Dest.Height}} {
+		x, y := float64(corner[0]-t.Origin.X), float64(corner[1]-t.Origin.Y)
+		worldX, worldY := float64(t.Dest.X)+x*cos-y*sin, float64(t.Dest.Y)+x*sin+y*cos
+		minX, maxX = math.Min(minX, worldX), math.Max(maxX, worldX)
+		minY, maxY = math.Min(minY, worldY), math.Max(maxY, worldY)
+	}
+	return render.Rectangle{X: float32(minX), Y: float32(minY), Width: float32(maxX - minX), Height: float32(maxY - minY)}
+}
+
 type RLTextureProComponentManager = ecs.ComponentManager[RLTexturePro]
 
 func NewRlTextureProComponentManager() RLTextureProComponentManager {
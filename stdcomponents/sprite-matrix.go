@@ -15,21 +15,22 @@ Thank you for your support!
 package stdcomponents
 
 import (
-	rl "github.com/gen2brain/raylib-go/raylib"
 	"gomp/pkg/ecs"
+	"gomp/pkg/render"
+	"gomp/vectors"
 )
 
 type SpriteMatrixAnimation struct {
 	Name        string
-	Frame       rl.Rectangle
+	Frame       render.Rectangle
 	NumOfFrames uint8
 	Vertical    bool
 	Loop        bool
 }
 
 type SpriteMatrix struct {
-	Texture    *rl.Texture2D
-	Origin     rl.Vector2
+	Texture    *render.Texture
+	Origin     vectors.Vec2
 	FPS        int32
 	Animations []SpriteMatrixAnimation
 }
@@ -15,14 +15,15 @@ Thank you for your support!
 package stdcomponents
 
 import (
-	rl "github.com/gen2brain/raylib-go/raylib"
 	"gomp/pkg/ecs"
+	"gomp/pkg/render"
+	"gomp/vectors"
 )
 
 type SpriteSheet struct {
-	Texture     *rl.Texture2D
-	Frame       rl.Rectangle
-	Origin      rl.Vector2
+	Texture     *render.Texture
+	Frame       render.Rectangle
+	Origin      vectors.Vec2
 	NumOfFrames int32
 	FPS         int32
 	Vertical    bool
@@ -0,0 +1,31 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package stdcomponents
+
+import "gomp/pkg/ecs"
+
+const TimeGroupCount = 8
+
+// TimeGroup selects the time scale an entity is simulated and animated with, see gomp.TimeControl.
+// Entities without one are in group 0.
+type TimeGroup struct {
+	Group uint8
+}
+
+type TimeGroupComponentManager = ecs.ComponentManager[TimeGroup]
+
+func NewTimeGroupComponentManager() TimeGroupComponentManager {
+	return ecs.NewComponentManager[TimeGroup](TimeGroupComponentId)
+}
@@ -7,6 +7,7 @@ with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
 package stdsystems
 
 import (
+	"gomp"
 	"gomp/pkg/ecs"
 	"gomp/stdcomponents"
 	"time"
@@ -18,18 +19,21 @@ func NewAnimationPlayerSystem() AnimationPlayerSystem {
 	return AnimationPlayerSystem{}
 }
 
+// AnimationPlayerSystem advances animations by game time dt, scaled by the TimeGroup of the entity
 type AnimationPlayerSystem struct {
 	AnimationPlayers *ecs.ComponentManager[stdcomponents.AnimationPlayer]
-	lastRunAt        time.Time
+	TimeGroups       *stdcomponents.TimeGroupComponentManager
+	Time             *gomp.TimeControl // Group scales, nil ignores TimeGroup
 }
 
-func (s *AnimationPlayerSystem) Init() {
-	s.lastRunAt = time.Now()
-}
-func (s *AnimationPlayerSystem) Run() {
-	dt := time.Since(s.lastRunAt)
-	s.AnimationPlayers.EachComponentParallel(func(animation *stdcomponents.AnimationPlayer) bool {
-		animation.ElapsedTime += time.Duration(float32(dt.Microseconds())*animation.Speed) * time.Microsecond
+func (s *AnimationPlayerSystem) Init() {}
+func (s *AnimationPlayerSystem) Run(dt time.Duration) {
+	s.AnimationPlayers.EachParallel(func(entity ecs.Entity, animation *stdcomponents.AnimationPlayer) bool {
+		entityDt := dt
+		if group := s.TimeGroups.Get(entity); group != nil {
+			entityDt = s.Time.GroupDt(group.Group, dt)
+		}
+		animation.ElapsedTime += time.Duration(float32(entityDt.Microseconds())*animation.Speed) * time.Microsecond
 
 		assert.True(animation.FrameDuration > 0, "frame duration must be greater than 0")
 
@@ -64,6 +68,5 @@ func (s *AnimationPlayerSystem) Run() {
 
 		return true
 	})
-	s.lastRunAt = time.Now()
 }
 func (s *AnimationPlayerSystem) Destroy() {}
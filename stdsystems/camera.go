@@ -0,0 +1,106 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package stdsystems
+
+import (
+	"gomp/stdcomponents"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+func NewCameraSystem() CameraSystem {
+	return CameraSystem{
+		Alpha: 1,
+	}
+}
+
+// CameraSystem moves Camera2D towards its target, keeps it inside bounds and shakes it.
+// A target with a PreviousTransform is followed between it and the current position by Alpha,
+// the same way TextureProSystem draws it.
+type CameraSystem struct {
+	Cameras   *stdcomponents.Camera2DComponentManager
+	Positions *stdcomponents.PositionComponentManager
+
+	PreviousTransforms *stdcomponents.PreviousTransformComponentManager
+	Alpha              float32 // Interpolation alpha of the frame, see gomp.Game.InterpolationAlpha
+}
+
+func (s *CameraSystem) Init() {}
+func (s *CameraSystem) Run(dt time.Duration) {
+	dts := dt.Seconds()
+	s.Cameras.EachComponent(func(camera *stdcomponents.Camera2D) bool {
+		s.follow(camera, dts)
+		s.clamp(camera)
+		s.shake(camera, dt)
+		return true
+	})
+}
+func (s *CameraSystem) Destroy() {}
+
+func (s *CameraSystem) follow(camera *stdcomponents.Camera2D, dts float64) {
+	if camera.Target == 0 {
+		return
+	}
+	position := s.Positions.Get(camera.Target)
+	if position == nil {
+		return
+	}
+	xy := position.XY
+	if previous := s.PreviousTransforms.Get(camera.Target); previous != nil && previous.Valid {
+		xy = previous.Position.Lerp(xy, s.Alpha)
+	}
+	if camera.Smoothing <= 0 {
+		camera.Position = xy
+		return
+	}
+	decay := float32(math.Exp(-float64(camera.Smoothing) * dts))
+	camera.Position.X = xy.X + (camera.Position.X-xy.X)*decay
+	camera.Position.Y = xy.Y + (camera.Position.Y-xy.Y)*decay
+}
+
+// clamp keeps the unrotated view inside Bounds, a view larger than Bounds is centered on them
+func (s *CameraSystem) clamp(camera *stdcomponents.Camera2D) {
+	bounds := camera.Bounds
+	if bounds.Width <= 0 || bounds.Height <= 0 {
+		return
+	}
+	zoom := camera.Zoom
+	if zoom == 0 {
+		zoom = 1
+	}
+	halfWidth, halfHeight := camera.Viewport.Width/zoom/2, camera.Viewport.Height/zoom/2
+	camera.Position.X = clampAxis(camera.Position.X, bounds.X+halfWidth, bounds.X+bounds.Width-halfWidth)
+	camera.Position.Y = clampAxis(camera.Position.Y, bounds.Y+halfHeight, bounds.Y+bounds.Height-halfHeight)
+}
+
+func clampAxis(value, low, high float32) float32 {
+	if low > high {
+		return (low + high) / 2
+	}
+	return min(max(value, low), high)
+}
+
+func (s *CameraSystem) shake(camera *stdcomponents.Camera2D, dt time.Duration) {
+	camera.ShakeLeft -= dt
+	if camera.ShakeLeft <= 0 || camera.ShakeDuration <= 0 {
+		camera.ShakeLeft = 0
+		camera.ShakeOffset.X, camera.ShakeOffset.Y = 0, 0
+		return
+	}
+	strength := camera.ShakeIntensity * float32(camera.ShakeLeft) / float32(camera.ShakeDuration)
+	camera.ShakeOffset.X = (rand.Float32()*2 - 1) * strength
+	camera.ShakeOffset.Y = (rand.Float32()*2 - 1) * strength
+}
@@ -0,0 +1,114 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package stdsystems
+
+import (
+	"github.com/stretchr/testify/require"
+	"gomp/pkg/ecs"
+	"gomp/pkg/render"
+	"gomp/stdcomponents"
+	"gomp/vectors"
+	"testing"
+	"time"
+)
+
+type cameraTestComponents struct {
+	Positions stdcomponents.PositionComponentManager
+	Previous  stdcomponents.PreviousTransformComponentManager
+	Cameras   stdcomponents.Camera2DComponentManager
+}
+
+type cameraTestSystems struct {
+	Camera CameraSystem
+}
+
+func TestCameraSystem(t *testing.T) {
+	world := ecs.NewWorld(cameraTestComponents{
+		Positions: stdcomponents.NewPositionComponentManager(),
+		Previous:  stdcomponents.NewPreviousTransformComponentManager(),
+		Cameras:   stdcomponents.NewCamera2DComponentManager(),
+	}, cameraTestSystems{Camera: NewCameraSystem()})
+	world.Init()
+	defer world.Destroy()
+
+	target := world.Entities.Create()
+	position := world.Components.Positions.Create(target, stdcomponents.Position{XY: vectors.Vec2{X: 100, Y: 50}})
+	camera := world.Components.Cameras.Create(world.Entities.Create(), stdcomponents.Camera2D{
+		Target:   target,
+		Viewport: render.NewRectangle(0, 0, 40, 20),
+	})
+
+	// Snaps without smoothing
+	world.Systems.Camera.Run(time.Second / 60)
+	require.Equal(t, vectors.Vec2{X: 100, Y: 50}, camera.Position)
+
+	// Smoothing closes part of the distance every tick
+	camera.Smoothing = 10
+	position.XY = vectors.Vec2{X: 200, Y: 50}
+	world.Systems.Camera.Run(time.Second / 10)
+	require.InDelta(t, 200-100*0.3679, camera.Position.X, 0.01)
+
+	// Bounds keep the view inside, a view taller than bounds is centered
+	camera.Smoothing = 0
+	camera.Bounds = render.NewRectangle(0, 0, 210, 10)
+	world.Systems.Camera.Run(time.Second / 60)
+	require.Equal(t, vectors.Vec2{X: 190, Y: 5}, camera.Position)
+
+	// Shake offsets the render target and fades out
+	camera.Bounds = render.Rectangle{}
+	camera.Shake(4, time.Second)
+	world.Systems.Camera.Run(time.Second / 2)
+	require.LessOrEqual(t, max(abs32(camera.ShakeOffset.X), abs32(camera.ShakeOffset.Y)), float32(2))
+	require.Equal(t, camera.Position.Add(camera.ShakeOffset), camera.Render().Target)
+	world.Systems.Camera.Run(time.Second)
+	require.Zero(t, camera.ShakeLeft)
+	require.Equal(t, vectors.Vec2{}, camera.ShakeOffset)
+
+	// Screen and world conversions are inverse
+	camera.Zoom = 2
+	camera.Rotation = 30
+	screen := camera.WorldToScreen(vectors.Vec2{X: 205, Y: 45})
+	world2 := camera.ScreenToWorld(screen)
+	require.InDelta(t, 205, world2.X, 0.001)
+	require.InDelta(t, 45, world2.Y, 0.001)
+	require.Equal(t, vectors.Vec2{X: 20, Y: 10}, camera.WorldToScreen(camera.Position))
+}
+
+func TestCameraSystemInterpolatedTarget(t *testing.T) {
+	world := ecs.NewWorld(cameraTestComponents{
+		Positions: stdcomponents.NewPositionComponentManager(),
+		Previous:  stdcomponents.NewPreviousTransformComponentManager(),
+		Cameras:   stdcomponents.NewCamera2DComponentManager(),
+	}, cameraTestSystems{Camera: NewCameraSystem()})
+	world.Init()
+	defer world.Destroy()
+
+	target := world.Entities.Create()
+	world.Components.Positions.Create(target, stdcomponents.Position{XY: vectors.Vec2{X: 100, Y: 50}})
+	previous := world.Components.Previous.Create(target, stdcomponents.PreviousTransform{Position: vectors.Vec2{X: 80, Y: 40}})
+	camera := world.Components.Cameras.Create(world.Entities.Create(), stdcomponents.Camera2D{Target: target})
+
+	// Without a snapshot the current position is followed
+	world.Systems.Camera.Alpha = 0.5
+	world.Systems.Camera.Run(time.Second / 60)
+	require.Equal(t, vectors.Vec2{X: 100, Y: 50}, camera.Position)
+
+	// Camera stays on the sprite drawn between the ticks
+	previous.Valid = true
+	world.Systems.Camera.Run(time.Second / 60)
+	require.Equal(t, vectors.Vec2{X: 90, Y: 45}, camera.Position)
+	world.Systems.Camera.Alpha = 0
+	world.Systems.Camera.Run(time.Second / 60)
+	require.Equal(t, vectors.Vec2{X: 80, Y: 40}, camera.Position)
+}
+
+func abs32(v float32) float32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
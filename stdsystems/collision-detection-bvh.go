@@ -52,6 +52,10 @@ type CollisionDetectionBVHSystem struct {
 	ColliderSleepStateComponentManager *stdcomponents.ColliderSleepStateComponentManager
 	BvhTreeComponentManager            *stdcomponents.BvhTreeComponentManager
 
+	// History of colliders for lag compensation, set it with NewHitboxHistory to enable
+	History HitboxHistory
+	tick    uint64
+
 	trees       []bvh.Tree
 	treesLookup map[stdcomponents.CollisionLayer]int
 
@@ -80,6 +84,9 @@ func (s *CollisionDetectionBVHSystem) Run(dt time.Duration) {
 	s.currentCollisions = make(map[CollisionPair]struct{})
 	defer s.processExitStates()
 
+	s.tick++
+	s.recordHistory()
+
 	if s.GenericCollider.Len() == 0 {
 		return
 	}
@@ -220,6 +227,62 @@ func (s *CollisionDetectionBVHSystem) Run(dt time.Duration) {
 
 func (s *CollisionDetectionBVHSystem) Destroy() {}
 
+// Tick returns number of the last simulated tick. Clients attach it to their actions,
+// so server can Rewind to the state they have seen.
+func (s *CollisionDetectionBVHSystem) Tick() uint64 {
+	return s.tick
+}
+
+// Rewind returns colliders state as it was at the tick
+func (s *CollisionDetectionBVHSystem) Rewind(tick uint64) (*HitboxFrame, bool) {
+	return s.History.Rewind(tick)
+}
+
+func (s *CollisionDetectionBVHSystem) recordHistory() {
+	if !s.History.Enabled() {
+		return
+	}
+	frame := s.History.Record(s.tick)
+
+	s.GenericCollider.EachEntity(func(entity ecs.Entity) bool {
+		collider := s.GenericCollider.Get(entity)
+		position := s.Positions.Get(entity)
+		rotation := s.Rotations.Get(entity)
+		scale := s.Scales.Get(entity)
+
+		hitbox := Hitbox{
+			Entity: entity,
+			Shape:  collider.Shape,
+			Layer:  collider.Layer,
+			AABB:   *s.AABB.Get(entity),
+			Transform: stdcomponents.Transform2d{
+				Position: position.XY,
+				Rotation: rotation.Angle,
+				Scale:    scale.XY,
+			},
+		}
+
+		switch collider.Shape {
+		case stdcomponents.BoxColliderShape:
+			hitbox.Box = *s.BoxColliders.Get(entity)
+		case stdcomponents.CircleColliderShape:
+			hitbox.Circle = *s.CircleColliders.Get(entity)
+		case stdcomponents.PolygonColliderShape:
+			polygon := s.PolygonColliders.Get(entity)
+			var vertices []vectors.Vec2
+			if n := len(frame.Hitboxes); n < cap(frame.Hitboxes) {
+				// Vertices of the hitbox recorded in this slot ticks ago are not referenced anymore
+				vertices = frame.Hitboxes[:n+1][n].Polygon.Vertices[:0]
+			}
+			hitbox.Polygon = *polygon
+			hitbox.Polygon.Vertices = append(vertices, polygon.Vertices...)
+		}
+
+		frame.Hitboxes = append(frame.Hitboxes, hitbox)
+		return true
+	})
+}
+
 func (s *CollisionDetectionBVHSystem) findEntityCollisions(entities []ecs.Entity) {
 	var wg sync.WaitGroup
 	entitiesLength := len(entities)
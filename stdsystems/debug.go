@@ -9,7 +9,8 @@ package stdsystems
 import (
 	"fmt"
 	"github.com/felixge/fgprof"
-	rl "github.com/gen2brain/raylib-go/raylib"
+	"gomp"
+	"gomp/pkg/render"
 	"log"
 	"net/http"
 	_ "net/http/pprof"
@@ -23,7 +24,11 @@ func NewDebugSystem() DebugSystem {
 	return DebugSystem{}
 }
 
+// DebugSystem toggles profiling with F9, pauses with F7 and steps a paused game by a fixed update with F8.
+// With gpprof it serves http.DefaultServeMux on :6060, handlers like gomp.EngineMetrics can be mounted there.
 type DebugSystem struct {
+	Time *gomp.TimeControl // Pause and step are disabled if nil
+
 	pprofEnabled bool
 }
 
@@ -37,7 +42,16 @@ func (s *DebugSystem) Init() {
 
 }
 func (s *DebugSystem) Run() {
-	if rl.IsKeyPressed(rl.KeyF9) {
+	if s.Time != nil {
+		if isKeyPressed(render.KeyF7) {
+			s.Time.TogglePause()
+		}
+		if isKeyPressed(render.KeyF8) {
+			s.Time.Step()
+		}
+	}
+
+	if isKeyPressed(render.KeyF9) {
 		if s.pprofEnabled {
 			pprof.StopCPUProfile()
 			fmt.Println("CPU Profile Stopped")
@@ -0,0 +1,25 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package stdsystems
+
+import (
+	"gomp"
+	"gomp/pkg/render"
+)
+
+// isKeyPressed polls gomp.Input, keys are never pressed without a window
+func isKeyPressed(key render.Key) bool {
+	return gomp.Input != nil && gomp.Input.IsKeyPressed(key)
+}
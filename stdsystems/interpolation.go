@@ -0,0 +1,92 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package stdsystems
+
+import (
+	"gomp/pkg/ecs"
+	"gomp/stdcomponents"
+	"time"
+)
+
+func NewInterpolationSystem() InterpolationSystem {
+	return InterpolationSystem{}
+}
+
+// InterpolationSystem smooths remote entities between network patches.
+// Every value written to Position, Rotation or Scale by a patch is captured as a snapshot,
+// then components are overwritten with the state sampled at now - Interpolation.Delay.
+// Snapshots keep the authoritative state, components hold the rendered one.
+// Should run after patches are applied and before render systems.
+type InterpolationSystem struct {
+	Interpolations *stdcomponents.InterpolationComponentManager
+	Positions      *stdcomponents.PositionComponentManager
+	Rotations      *stdcomponents.RotationComponentManager
+	Scales         *stdcomponents.ScaleComponentManager
+}
+
+func (s *InterpolationSystem) Init() {}
+func (s *InterpolationSystem) Run(dt time.Duration) {
+	now := time.Now()
+
+	s.Interpolations.EachParallel(func(entity ecs.Entity, interpolation *stdcomponents.Interpolation) bool {
+		position := s.Positions.Get(entity)
+		rotation := s.Rotations.Get(entity)
+		scale := s.Scales.Get(entity)
+
+		// Components differ from what we rendered last time only where a patch arrived,
+		// channels it did not touch keep their authoritative value from the latest snapshot
+		latest := interpolation.Latest()
+		received := latest == nil
+		var current stdcomponents.TransformSnapshot
+		if latest != nil {
+			current = *latest
+		}
+		current.At = now
+		if position != nil && (latest == nil || position.XY != interpolation.Rendered.Position) {
+			current.Position = position.XY
+			received = true
+		}
+		if rotation != nil && (latest == nil || rotation.Angle != interpolation.Rendered.Rotation) {
+			current.Rotation = rotation.Angle
+			received = true
+		}
+		if scale != nil && (latest == nil || scale.XY != interpolation.Rendered.Scale) {
+			current.Scale = scale.XY
+			received = true
+		}
+		if received {
+			interpolation.Push(current)
+		}
+
+		sample, ok := interpolation.Sample(now.Add(-interpolation.Delay))
+		if !ok {
+			return true
+		}
+
+		if position != nil {
+			position.XY = sample.Position
+		}
+		if rotation != nil {
+			rotation.Angle = sample.Rotation
+		}
+		if scale != nil {
+			scale.XY = sample.Scale
+		}
+		interpolation.Rendered = sample
+
+		return true
+	})
+}
+func (s *InterpolationSystem) Destroy() {}
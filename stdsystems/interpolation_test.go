@@ -0,0 +1,115 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package stdsystems
+
+import (
+	"github.com/stretchr/testify/require"
+	"gomp/pkg/ecs"
+	"gomp/stdcomponents"
+	"gomp/vectors"
+	"testing"
+	"time"
+)
+
+func TestInterpolationSample(t *testing.T) {
+	start := time.Unix(0, 0)
+	at := func(ms int) time.Time {
+		return start.Add(time.Duration(ms) * time.Millisecond)
+	}
+	snapshot := func(ms int, x float32) stdcomponents.TransformSnapshot {
+		return stdcomponents.TransformSnapshot{At: at(ms), Position: vectors.Vec2{X: x}}
+	}
+
+	defaults := stdcomponents.NewInterpolation()
+	require.Equal(t, stdcomponents.DefaultInterpolationDelay, defaults.Delay)
+	require.Equal(t, stdcomponents.DefaultMaxExtrapolation, defaults.MaxExtrapolation)
+
+	interpolation := stdcomponents.Interpolation{MaxExtrapolation: 50 * time.Millisecond}
+	_, ok := interpolation.Sample(at(0))
+	require.False(t, ok)
+
+	interpolation.Push(snapshot(0, 0))
+	interpolation.Push(snapshot(100, 10))
+	interpolation.Push(snapshot(50, 99)) // Older than the newest one
+	require.Equal(t, 2, interpolation.Len)
+	require.Equal(t, float32(10), interpolation.Latest().Position.X)
+
+	sample, _ := interpolation.Sample(at(-10))
+	require.Equal(t, float32(0), sample.Position.X)
+	sample, _ = interpolation.Sample(at(50))
+	require.InDelta(t, 5, sample.Position.X, 0.001)
+
+	// Extrapolated while within MaxExtrapolation, then clamped to the newest snapshot
+	sample, _ = interpolation.Sample(at(150))
+	require.InDelta(t, 15, sample.Position.X, 0.001)
+	sample, _ = interpolation.Sample(at(200))
+	require.Equal(t, float32(10), sample.Position.X)
+
+	// Oldest snapshots are overwritten once the buffer is full
+	for i := range stdcomponents.InterpolationBufferSize {
+		interpolation.Push(snapshot(200+i*10, float32(i)))
+	}
+	require.Equal(t, stdcomponents.InterpolationBufferSize, interpolation.Len)
+	require.Equal(t, at(200), interpolation.Get(0).At)
+	require.Equal(t, float32(stdcomponents.InterpolationBufferSize-1), interpolation.Latest().Position.X)
+}
+
+type interpolationTestComponents struct {
+	Interpolations stdcomponents.InterpolationComponentManager
+	Positions      stdcomponents.PositionComponentManager
+	Rotations      stdcomponents.RotationComponentManager
+	Scales         stdcomponents.ScaleComponentManager
+}
+
+type interpolationTestSystems struct {
+	Interpolation InterpolationSystem
+}
+
+func TestInterpolationSystemChannels(t *testing.T) {
+	world := ecs.NewWorld(interpolationTestComponents{
+		Interpolations: stdcomponents.NewInterpolationComponentManager(),
+		Positions:      stdcomponents.NewPositionComponentManager(),
+		Rotations:      stdcomponents.NewRotationComponentManager(),
+		Scales:         stdcomponents.NewScaleComponentManager(),
+	}, interpolationTestSystems{Interpolation: NewInterpolationSystem()})
+	world.Init()
+	defer world.Destroy()
+
+	entity := world.Entities.Create()
+	interpolation := world.Components.Interpolations.Create(entity, stdcomponents.Interpolation{Delay: time.Hour})
+	position := world.Components.Positions.Create(entity, stdcomponents.Position{})
+	rotation := world.Components.Rotations.Create(entity, stdcomponents.Rotation{})
+	world.Components.Scales.Create(entity, stdcomponents.Scale{XY: vectors.Vec2{X: 1, Y: 1}})
+
+	world.Systems.Interpolation.Run(0)
+
+	// Rendered state stays an hour behind, so components keep the first snapshot
+	rotation.Angle = 1
+	world.Systems.Interpolation.Run(0)
+	require.Equal(t, vectors.Radians(0), rotation.Angle)
+	require.Equal(t, vectors.Radians(1), interpolation.Latest().Rotation)
+
+	// Patch of the position alone keeps the authoritative rotation, not the rendered one
+	position.XY.X = 10
+	world.Systems.Interpolation.Run(0)
+	require.Equal(t, 3, interpolation.Len)
+	require.Equal(t, float32(10), interpolation.Latest().Position.X)
+	require.Equal(t, vectors.Radians(1), interpolation.Latest().Rotation)
+
+	// Nothing arrived, nothing is pushed
+	world.Systems.Interpolation.Run(0)
+	require.Equal(t, 3, interpolation.Len)
+
+	// With defaults a received patch is rendered DefaultInterpolationDelay later, not right away
+	smoothed := world.Entities.Create()
+	world.Components.Interpolations.Create(smoothed, stdcomponents.NewInterpolation())
+	smoothedPosition := world.Components.Positions.Create(smoothed, stdcomponents.Position{})
+	world.Systems.Interpolation.Run(0)
+	smoothedPosition.XY.X = 10
+	world.Systems.Interpolation.Run(0)
+	require.Equal(t, float32(0), smoothedPosition.XY.X)
+}
@@ -0,0 +1,170 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package stdsystems
+
+import (
+	gjk "gomp/pkg/collision"
+	"gomp/pkg/ecs"
+	"gomp/stdcomponents"
+	"gomp/vectors"
+)
+
+// DefaultHitboxHistorySize keeps a bit more than a second of history at 50 ticks per second
+const DefaultHitboxHistorySize = 64
+
+// Hitbox is a collider state captured at some tick
+type Hitbox struct {
+	Entity    ecs.Entity
+	Shape     stdcomponents.ColliderShape
+	Layer     stdcomponents.CollisionLayer
+	Transform stdcomponents.Transform2d
+	AABB      stdcomponents.AABB
+	Box       stdcomponents.BoxCollider
+	Circle    stdcomponents.CircleCollider
+	Polygon   stdcomponents.PolygonCollider
+}
+
+func (h *Hitbox) Collider() gjk.AnyCollider {
+	switch h.Shape {
+	case stdcomponents.BoxColliderShape:
+		return &h.Box
+	case stdcomponents.CircleColliderShape:
+		return &h.Circle
+	case stdcomponents.PolygonColliderShape:
+		return &h.Polygon
+	default:
+		panic("unsupported collider shape")
+	}
+}
+
+// HitboxHit is a collision found in a rewound frame
+type HitboxHit struct {
+	Entity ecs.Entity
+	Normal vectors.Vec2
+	Depth  float32
+}
+
+// HitboxFrame is the state of all colliders at a single tick
+type HitboxFrame struct {
+	Tick     uint64
+	Hitboxes []Hitbox
+}
+
+// Query re-runs narrow phase of the collider against historical hitboxes of the frame.
+// Only hitboxes on layers included in the mask are checked.
+func (f *HitboxFrame) Query(
+	collider gjk.AnyCollider,
+	transform *stdcomponents.Transform2d,
+	aabb *stdcomponents.AABB,
+	mask stdcomponents.CollisionMask,
+	result []HitboxHit,
+) []HitboxHit {
+	for i := range f.Hitboxes {
+		hitbox := &f.Hitboxes[i]
+
+		if !mask.HasLayer(hitbox.Layer) {
+			continue
+		}
+
+		if hitbox.AABB.Max.X < aabb.Min.X || hitbox.AABB.Min.X > aabb.Max.X ||
+			hitbox.AABB.Max.Y < aabb.Min.Y || hitbox.AABB.Min.Y > aabb.Max.Y {
+			continue
+		}
+
+		other := hitbox.Collider()
+		simplex, collision := gjk.CheckCollision(collider, other, transform, &hitbox.Transform)
+		if !collision {
+			continue
+		}
+
+		normal, depth := gjk.EPA(collider, other, transform, &hitbox.Transform, &simplex)
+		result = append(result, HitboxHit{
+			Entity: hitbox.Entity,
+			Normal: normal,
+			Depth:  depth,
+		})
+	}
+	return result
+}
+
+// Find returns hitbox of the entity in the frame
+func (f *HitboxFrame) Find(entity ecs.Entity) (*Hitbox, bool) {
+	for i := range f.Hitboxes {
+		if f.Hitboxes[i].Entity == entity {
+			return &f.Hitboxes[i], true
+		}
+	}
+	return nil, false
+}
+
+func NewHitboxHistory(size int) HitboxHistory {
+	return HitboxHistory{
+		frames: make([]HitboxFrame, size),
+	}
+}
+
+// HitboxHistory is a ring buffer of the latest HitboxFrame's.
+// Server uses it to validate hits against where targets were when the client fired.
+type HitboxHistory struct {
+	frames []HitboxFrame
+	head   int
+	len    int
+}
+
+// Enabled reports whether the history was created with a non-zero size
+func (h *HitboxHistory) Enabled() bool {
+	return len(h.frames) > 0
+}
+
+// Record starts a new frame, reusing memory of the oldest one
+func (h *HitboxHistory) Record(tick uint64) *HitboxFrame {
+	if len(h.frames) == 0 {
+		return nil
+	}
+
+	h.head = (h.head + 1) % len(h.frames)
+	if h.len < len(h.frames) {
+		h.len++
+	}
+
+	frame := &h.frames[h.head]
+	frame.Tick = tick
+	frame.Hitboxes = frame.Hitboxes[:0]
+	return frame
+}
+
+// Rewind returns the frame recorded at the tick
+func (h *HitboxHistory) Rewind(tick uint64) (*HitboxFrame, bool) {
+	if h.len == 0 {
+		return nil, false
+	}
+
+	newest := &h.frames[h.head]
+	if tick > newest.Tick || newest.Tick-tick >= uint64(h.len) {
+		return nil, false
+	}
+
+	index := (h.head - int(newest.Tick-tick) + len(h.frames)) % len(h.frames)
+	frame := &h.frames[index]
+	if frame.Tick != tick {
+		return nil, false
+	}
+	return frame, true
+}
+
+// Len returns number of recorded frames
+func (h *HitboxHistory) Len() int {
+	return h.len
+}
@@ -0,0 +1,138 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package stdsystems
+
+import (
+	"github.com/stretchr/testify/require"
+	"gomp/pkg/ecs"
+	"gomp/stdcomponents"
+	"gomp/vectors"
+	"testing"
+)
+
+func TestHitboxHistory(t *testing.T) {
+	disabled := HitboxHistory{}
+	require.False(t, disabled.Enabled())
+
+	history := NewHitboxHistory(4)
+	require.True(t, history.Enabled())
+	_, ok := history.Rewind(1)
+	require.False(t, ok)
+
+	for tick := uint64(1); tick <= 6; tick++ {
+		frame := history.Record(tick)
+		frame.Hitboxes = append(frame.Hitboxes, Hitbox{Entity: ecs.Entity(tick)})
+	}
+	require.Equal(t, 4, history.Len())
+
+	// Ticks 1 and 2 are overwritten, ticks from the future are unknown
+	for _, tick := range []uint64{1, 2, 7} {
+		_, ok = history.Rewind(tick)
+		require.False(t, ok, "tick %d", tick)
+	}
+	for tick := uint64(3); tick <= 6; tick++ {
+		frame, ok := history.Rewind(tick)
+		require.True(t, ok)
+		require.Equal(t, tick, frame.Tick)
+		require.Equal(t, []Hitbox{{Entity: ecs.Entity(tick)}}, frame.Hitboxes)
+	}
+}
+
+func TestHitboxFrameQuery(t *testing.T) {
+	box := func(entity ecs.Entity, layer stdcomponents.CollisionLayer, x float32) Hitbox {
+		return Hitbox{
+			Entity:    entity,
+			Shape:     stdcomponents.BoxColliderShape,
+			Layer:     layer,
+			Transform: stdcomponents.Transform2d{Position: vectors.Vec2{X: x}, Scale: vectors.Vec2{X: 1, Y: 1}},
+			AABB:      stdcomponents.AABB{Min: vectors.Vec2{X: x}, Max: vectors.Vec2{X: x + 10, Y: 10}},
+			Box:       stdcomponents.BoxCollider{WH: vectors.Vec2{X: 10, Y: 10}},
+		}
+	}
+	frame := HitboxFrame{Hitboxes: []Hitbox{box(1, 0, 0), box(2, 1, 5), box(3, 0, 100)}}
+
+	bullet := box(4, 2, 8)
+	hits := frame.Query(bullet.Collider(), &bullet.Transform, &bullet.AABB, 1<<0, nil)
+	require.Len(t, hits, 1)
+	require.Equal(t, ecs.Entity(1), hits[0].Entity)
+	require.Greater(t, hits[0].Depth, float32(0))
+
+	hits = frame.Query(bullet.Collider(), &bullet.Transform, &bullet.AABB, 1<<0|1<<1, hits[:0])
+	require.Len(t, hits, 2)
+}
+
+type historyTestComponents struct {
+	Positions        stdcomponents.PositionComponentManager
+	Rotations        stdcomponents.RotationComponentManager
+	Scales           stdcomponents.ScaleComponentManager
+	GenericColliders stdcomponents.GenericColliderComponentManager
+	BoxColliders     stdcomponents.BoxColliderComponentManager
+	CircleColliders  stdcomponents.CircleColliderComponentManager
+	PolygonColliders stdcomponents.PolygonColliderComponentManager
+	Collisions       stdcomponents.CollisionComponentManager
+	SpatialIndex     stdcomponents.SpatialIndexComponentManager
+	AABB             stdcomponents.AABBComponentManager
+	SleepStates      stdcomponents.ColliderSleepStateComponentManager
+	BvhTrees         stdcomponents.BvhTreeComponentManager
+}
+
+type historyTestSystems struct {
+	Collisions CollisionDetectionBVHSystem
+}
+
+func TestCollisionHistoryPolygon(t *testing.T) {
+	world := ecs.NewWorld(historyTestComponents{
+		Positions:        stdcomponents.NewPositionComponentManager(),
+		Rotations:        stdcomponents.NewRotationComponentManager(),
+		Scales:           stdcomponents.NewScaleComponentManager(),
+		GenericColliders: stdcomponents.NewGenericColliderComponentManager(),
+		BoxColliders:     stdcomponents.NewBoxColliderComponentManager(),
+		CircleColliders:  stdcomponents.NewCircleColliderComponentManager(),
+		PolygonColliders: stdcomponents.NewPolygonColliderComponentManager(),
+		Collisions:       stdcomponents.NewCollisionComponentManager(),
+		SpatialIndex:     stdcomponents.NewSpatialIndexComponentManager(),
+		AABB:             stdcomponents.NewAABBComponentManager(),
+		SleepStates:      stdcomponents.NewColliderSleepStateComponentManager(),
+		BvhTrees:         stdcomponents.NewBvhTreeComponentManager(),
+	}, historyTestSystems{Collisions: NewCollisionDetectionBVHSystem()})
+	world.Init()
+	defer world.Destroy()
+	world.Systems.Collisions.Init()
+	require.False(t, world.Systems.Collisions.History.Enabled())
+	world.Systems.Collisions.History = NewHitboxHistory(2)
+
+	entity := world.Entities.Create()
+	world.Components.Positions.Create(entity, stdcomponents.Position{})
+	world.Components.Rotations.Create(entity, stdcomponents.Rotation{})
+	world.Components.Scales.Create(entity, stdcomponents.Scale{XY: vectors.Vec2{X: 1, Y: 1}})
+	world.Components.AABB.Create(entity, stdcomponents.AABB{Max: vectors.Vec2{X: 10, Y: 10}})
+	world.Components.GenericColliders.Create(entity, stdcomponents.GenericCollider{Shape: stdcomponents.PolygonColliderShape})
+	polygon := world.Components.PolygonColliders.Create(entity, stdcomponents.PolygonCollider{
+		Vertices: []vectors.Vec2{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 0, Y: 10}},
+	})
+
+	world.Systems.Collisions.Run(0)
+	tick := world.Systems.Collisions.Tick()
+
+	// Changing the live collider does not change its history
+	polygon.Vertices[1].X = 50
+	world.Systems.Collisions.Run(0)
+
+	frame, ok := world.Systems.Collisions.Rewind(tick)
+	require.True(t, ok)
+	hitbox, ok := frame.Find(entity)
+	require.True(t, ok)
+	require.Equal(t, float32(10), hitbox.Polygon.Vertices[1].X)
+
+	// Slots are reused once the ring wraps around
+	world.Systems.Collisions.Run(0)
+	_, ok = world.Systems.Collisions.Rewind(tick)
+	require.False(t, ok)
+	frame, _ = world.Systems.Collisions.Rewind(world.Systems.Collisions.Tick())
+	hitbox, _ = frame.Find(entity)
+	require.Equal(t, float32(50), hitbox.Polygon.Vertices[1].X)
+}
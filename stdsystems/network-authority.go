@@ -0,0 +1,192 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package stdsystems
+
+import (
+	"errors"
+	"fmt"
+	"gomp/network"
+	"gomp/pkg/ecs"
+	"gomp/stdcomponents"
+	"time"
+)
+
+var (
+	ErrUnknownComponent = errors.New("patch changes unknown component")
+	ErrNotNetworked     = errors.New("patch changes entity which is not networked")
+	ErrNotAuthorized    = errors.New("peer is not allowed to change the entity")
+)
+
+// NetworkAuthorityRequest is sent by a client to take or release ownership of an entity
+type NetworkAuthorityRequest struct {
+	Id      stdcomponents.NetworkId
+	Release bool
+}
+
+// NetworkAuthorityGrant is the server answer. Granted transfers are broadcast, so every client learns the new owner.
+type NetworkAuthorityGrant struct {
+	Id      stdcomponents.NetworkId
+	Owner   network.PeerId
+	Granted bool
+}
+
+func NewNetworkAuthoritySystem() NetworkAuthoritySystem {
+	return NetworkAuthoritySystem{
+		RPC: network.DefaultRPC,
+	}
+}
+
+// NetworkAuthoritySystem transfers ownership of networked entities between peers and validates
+// patches received from clients against stdcomponents.Network authority.
+// Entities of disconnected peers are given back to the server.
+type NetworkAuthoritySystem struct {
+	EntityManager *ecs.EntityManager
+	Networks      *stdcomponents.NetworkComponentManager
+	NetworkPeers  *stdcomponents.NetworkPeerComponentManager
+
+	RPC *network.RPCRegistry
+	// CanTransfer decides whether the peer may take the entity. By default entities with owner authority
+	// or shared authority are given away when they are owned by the server.
+	CanTransfer func(peer network.PeerId, entity ecs.Entity, n *stdcomponents.Network) bool
+	// OnGrant is called on clients for every answer of the server
+	OnGrant func(grant NetworkAuthorityGrant)
+
+	request network.RPC[NetworkAuthorityRequest]
+	grant   network.RPC[NetworkAuthorityGrant]
+}
+
+func (s *NetworkAuthoritySystem) Init() {
+	if s.CanTransfer == nil {
+		s.CanTransfer = func(peer network.PeerId, entity ecs.Entity, n *stdcomponents.Network) bool {
+			return n.Authority != stdcomponents.NetworkAuthorityServer && n.Owner == network.ServerPeerId
+		}
+	}
+
+	s.request = network.RegisterRPCIn(s.RPC, "gomp.authority.request", s.handleRequest,
+		network.WithRPCRateLimit(10, 10),
+	)
+	s.grant = network.RegisterRPCIn(s.RPC, "gomp.authority.grant", s.handleGrant,
+		network.WithRPCDirection(network.RPCToClient),
+	)
+}
+
+func (s *NetworkAuthoritySystem) Run(dt time.Duration) {
+	s.NetworkPeers.EachComponent(func(peer *stdcomponents.NetworkPeer) bool {
+		if peer.State != stdcomponents.NetworkPeerStateDisconnected && peer.State != stdcomponents.NetworkPeerStateTimedOut {
+			return true
+		}
+
+		s.Networks.EachComponent(func(n *stdcomponents.Network) bool {
+			if n.Authority != stdcomponents.NetworkAuthorityServer && n.Owner == peer.Id {
+				n.Owner = network.ServerPeerId
+				s.grant.Broadcast(NetworkAuthorityGrant{Id: n.Id, Owner: n.Owner, Granted: true})
+			}
+			return true
+		})
+		return true
+	})
+}
+
+func (s *NetworkAuthoritySystem) Destroy() {}
+
+// Request asks the server for ownership of the entity, or gives it back when release is true.
+// The answer arrives as NetworkAuthorityGrant.
+func (s *NetworkAuthoritySystem) Request(id stdcomponents.NetworkId, release bool) error {
+	return s.request.Call(NetworkAuthorityRequest{Id: id, Release: release})
+}
+
+// ValidatePatch checks that the peer is allowed to change every entity in the patch.
+// Network components are never accepted from peers, ownership changes only with Request.
+func (s *NetworkAuthoritySystem) ValidatePatch(peer network.PeerId, patch ecs.Patch) error {
+	for i := range patch {
+		componentPatch := &patch[i]
+		if !s.EntityManager.HasComponent(componentPatch.ID) {
+			return fmt.Errorf("%w %d", ErrUnknownComponent, componentPatch.ID)
+		}
+		if componentPatch.ID == stdcomponents.NetworkComponentId {
+			return fmt.Errorf("%w: network component", ErrNotAuthorized)
+		}
+
+		for _, changes := range []*ecs.ComponentChanges{&componentPatch.Created, &componentPatch.Patched, &componentPatch.Deleted} {
+			for _, entity := range changes.Entities {
+				n := s.Networks.Get(entity)
+				if n == nil {
+					return fmt.Errorf("%w: entity %d", ErrNotNetworked, entity)
+				}
+				if !n.CanWrite(peer) {
+					return fmt.Errorf("%w: entity %d", ErrNotAuthorized, entity)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ApplyPatch applies the patch received from the peer when it passes ValidatePatch
+func (s *NetworkAuthoritySystem) ApplyPatch(peer network.PeerId, patch ecs.Patch) error {
+	if err := s.ValidatePatch(peer, patch); err != nil {
+		return err
+	}
+	for i := range patch {
+		network.Quic.RecordComponentReceived(uint16(patch[i].ID), patch[i].Size())
+	}
+	return s.EntityManager.PatchApply(patch)
+}
+
+func (s *NetworkAuthoritySystem) handleRequest(peer network.PeerId, req *NetworkAuthorityRequest) {
+	entity, n := s.find(req.Id)
+	if n == nil {
+		s.grant.CallPeer(peer, NetworkAuthorityGrant{Id: req.Id})
+		return
+	}
+
+	switch {
+	case req.Release && n.Owner == peer:
+		n.Owner = network.ServerPeerId
+	case !req.Release && n.Owner != peer && s.CanTransfer(peer, entity, n):
+		n.Owner = peer
+	case !req.Release && n.Owner == peer:
+		// Already owned, just confirm
+	default:
+		s.grant.CallPeer(peer, NetworkAuthorityGrant{Id: req.Id, Owner: n.Owner})
+		return
+	}
+
+	s.grant.Broadcast(NetworkAuthorityGrant{Id: n.Id, Owner: n.Owner, Granted: true})
+}
+
+func (s *NetworkAuthoritySystem) handleGrant(peer network.PeerId, grant *NetworkAuthorityGrant) {
+	if grant.Granted {
+		if _, n := s.find(grant.Id); n != nil {
+			n.Owner = grant.Owner
+		}
+	}
+	if s.OnGrant != nil {
+		s.OnGrant(*grant)
+	}
+}
+
+func (s *NetworkAuthoritySystem) find(id stdcomponents.NetworkId) (ecs.Entity, *stdcomponents.Network) {
+	var found ecs.Entity
+	var result *stdcomponents.Network
+	s.Networks.Each(func(entity ecs.Entity, n *stdcomponents.Network) bool {
+		if n.Id != id {
+			return true
+		}
+		found, result = entity, n
+		return false
+	})
+	return found, result
+}
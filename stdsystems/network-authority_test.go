@@ -0,0 +1,152 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package stdsystems
+
+import (
+	"github.com/stretchr/testify/require"
+	"gomp/network"
+	"gomp/pkg/ecs"
+	"gomp/stdcomponents"
+	"testing"
+)
+
+type authorityTestComponents struct {
+	Networks     stdcomponents.NetworkComponentManager
+	NetworkPeers stdcomponents.NetworkPeerComponentManager
+	Positions    stdcomponents.PositionComponentManager
+}
+
+type authorityTestSystems struct {
+	Authority NetworkAuthoritySystem
+}
+
+// authorityTestTransport is a server transport delivering grants into the client registry
+type authorityTestTransport struct {
+	client *network.RPCRegistry
+}
+
+func (t *authorityTestTransport) Mode() network.Mode { return network.ModeServer }
+
+func (t *authorityTestTransport) Send(ch network.Channel, data []byte) error {
+	return t.client.Receive(network.Message{Peer: network.ServerPeerId, Channel: ch, Data: data})
+}
+
+func (t *authorityTestTransport) SendTo(peer network.PeerId, ch network.Channel, data []byte) error {
+	return t.Send(ch, data)
+}
+
+type authorityTestClient struct{}
+
+func (authorityTestClient) Mode() network.Mode                                   { return network.ModeClient }
+func (authorityTestClient) Send(network.Channel, []byte) error                   { return nil }
+func (authorityTestClient) SendTo(network.PeerId, network.Channel, []byte) error { return nil }
+
+func newAuthorityTestWorld(t *testing.T) (*ecs.World[authorityTestComponents, authorityTestSystems], func() []NetworkAuthorityGrant) {
+	client := network.NewRPCRegistry(authorityTestClient{})
+	var grants []NetworkAuthorityGrant
+	network.RegisterRPCIn(client, "gomp.authority.grant", func(peer network.PeerId, grant *NetworkAuthorityGrant) {
+		grants = append(grants, *grant)
+	}, network.WithRPCDirection(network.RPCToClient))
+
+	authority := NewNetworkAuthoritySystem()
+	authority.RPC = network.NewRPCRegistry(&authorityTestTransport{client: client})
+	world := ecs.NewWorld(authorityTestComponents{
+		Networks:     stdcomponents.NewNetworkComponentManager(),
+		NetworkPeers: stdcomponents.NewNetworkPeerComponentManager(),
+		Positions:    stdcomponents.NewPositionComponentManager(),
+	}, authorityTestSystems{Authority: authority})
+	world.Init()
+	t.Cleanup(world.Destroy)
+	world.Systems.Authority.Init()
+
+	dispatch := func() []NetworkAuthorityGrant {
+		grants = grants[:0]
+		world.Systems.Authority.RPC.Dispatch()
+		client.Dispatch()
+		return grants
+	}
+	return &world, dispatch
+}
+
+func TestNetworkAuthorityValidatePatch(t *testing.T) {
+	world, _ := newAuthorityTestWorld(t)
+	authority := &world.Systems.Authority
+
+	create := func(n stdcomponents.Network) ecs.Entity {
+		entity := world.Entities.Create()
+		world.Components.Networks.Create(entity, n)
+		return entity
+	}
+	server := create(stdcomponents.Network{Id: 1})
+	owned := create(stdcomponents.Network{Id: 2, Owner: 1, Authority: stdcomponents.NetworkAuthorityOwner})
+	shared := create(stdcomponents.Network{Id: 3, Authority: stdcomponents.NetworkAuthorityShared})
+	local := world.Entities.Create()
+
+	patch := func(id ecs.ComponentId, entity ecs.Entity) ecs.Patch {
+		return ecs.Patch{{ID: id, Patched: ecs.ComponentChanges{Len: 1, Entities: []ecs.Entity{entity}}}}
+	}
+	positions := stdcomponents.PositionComponentId
+
+	require.NoError(t, authority.ValidatePatch(1, patch(positions, owned)))
+	require.NoError(t, authority.ValidatePatch(2, patch(positions, shared)))
+	require.NoError(t, authority.ValidatePatch(network.ServerPeerId, patch(positions, server)))
+	require.ErrorIs(t, authority.ValidatePatch(2, patch(positions, owned)), ErrNotAuthorized)
+	require.ErrorIs(t, authority.ValidatePatch(1, patch(positions, server)), ErrNotAuthorized)
+	require.ErrorIs(t, authority.ValidatePatch(1, patch(stdcomponents.NetworkComponentId, owned)), ErrNotAuthorized)
+	require.ErrorIs(t, authority.ValidatePatch(1, patch(positions, local)), ErrNotNetworked)
+	require.ErrorIs(t, authority.ValidatePatch(1, patch(stdcomponents.RotationComponentId, owned)), ErrUnknownComponent)
+}
+
+func TestNetworkAuthorityTransfer(t *testing.T) {
+	world, dispatch := newAuthorityTestWorld(t)
+	authority := &world.Systems.Authority
+
+	entity := world.Entities.Create()
+	n := world.Components.Networks.Create(entity, stdcomponents.Network{Id: 7, Authority: stdcomponents.NetworkAuthorityOwner})
+	locked := world.Entities.Create()
+	world.Components.Networks.Create(locked, stdcomponents.Network{Id: 8})
+
+	request := func(peer network.PeerId, id stdcomponents.NetworkId, release bool) []NetworkAuthorityGrant {
+		authority.handleRequest(peer, &NetworkAuthorityRequest{Id: id, Release: release})
+		return dispatch()
+	}
+
+	// Zero value owner is the server, so the entity is given away
+	require.Equal(t, []NetworkAuthorityGrant{{Id: 7, Owner: 1, Granted: true}}, request(1, 7, false))
+	require.Equal(t, network.PeerId(1), n.Owner)
+	require.Equal(t, []NetworkAuthorityGrant{{Id: 7, Owner: 1, Granted: true}}, request(1, 7, false))
+
+	// Owned entities are not taken by other peers, nor released by them
+	require.Equal(t, []NetworkAuthorityGrant{{Id: 7, Owner: 1}}, request(2, 7, false))
+	require.Equal(t, []NetworkAuthorityGrant{{Id: 7, Owner: 1}}, request(2, 7, true))
+
+	require.Equal(t, []NetworkAuthorityGrant{{Id: 7, Owner: network.ServerPeerId, Granted: true}}, request(1, 7, true))
+	require.Equal(t, network.ServerPeerId, n.Owner)
+
+	// Server authority entities and unknown ids are refused
+	require.Equal(t, []NetworkAuthorityGrant{{Id: 8, Owner: network.ServerPeerId}}, request(1, 8, false))
+	require.Equal(t, []NetworkAuthorityGrant{{Id: 9}}, request(1, 9, false))
+}
+
+func TestNetworkAuthorityDisconnect(t *testing.T) {
+	world, dispatch := newAuthorityTestWorld(t)
+	authority := &world.Systems.Authority
+
+	owned := world.Components.Networks.Create(world.Entities.Create(), stdcomponents.Network{Id: 1, Owner: 1, Authority: stdcomponents.NetworkAuthorityOwner})
+	other := world.Components.Networks.Create(world.Entities.Create(), stdcomponents.Network{Id: 2, Owner: 2, Authority: stdcomponents.NetworkAuthorityShared})
+	peer := world.Components.NetworkPeers.Create(world.Entities.Create(), stdcomponents.NetworkPeer{Id: 1, State: stdcomponents.NetworkPeerStateConnected})
+
+	authority.Run(0)
+	require.Empty(t, dispatch())
+	require.Equal(t, network.PeerId(1), owned.Owner)
+
+	peer.State = stdcomponents.NetworkPeerStateDisconnected
+	authority.Run(0)
+	require.Equal(t, []NetworkAuthorityGrant{{Id: 1, Owner: network.ServerPeerId, Granted: true}}, dispatch())
+	require.Equal(t, network.ServerPeerId, owned.Owner)
+	require.Equal(t, network.PeerId(2), other.Owner)
+}
@@ -0,0 +1,267 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package stdsystems
+
+import (
+	"cmp"
+	"gomp/network"
+	"gomp/pkg/ecs"
+	"gomp/stdcomponents"
+	"slices"
+	"time"
+)
+
+const networkInterestCellSize = 256
+
+func NewNetworkInterestSystem() NetworkInterestSystem {
+	return NetworkInterestSystem{
+		Radius:     1024,
+		EntityCost: 32,
+		grid:       ecs.NewSpatialGrid(networkInterestCellSize),
+		gridFrames: make(map[ecs.Entity]uint32),
+		peers:      make(map[network.PeerId]*peerInterest),
+	}
+}
+
+// NetworkInterestSystem decides which networked entities are relevant for each peer.
+// Entities entering area of interest of a peer are announced with network.MessageSpawn,
+// entities leaving it - with network.MessageDespawn.
+// Relevant entities are ordered by accumulated priority and cut by BudgetPerPeer,
+// so far and unimportant entities are still replicated, but less often.
+// Entities the peer is authority of are announced, but never replicated back to it.
+type NetworkInterestSystem struct {
+	Networks   *stdcomponents.NetworkComponentManager
+	Positions  *stdcomponents.PositionComponentManager
+	Observers  *stdcomponents.NetworkObserverComponentManager
+	Priorities *stdcomponents.NetworkPriorityComponentManager
+
+	Radius        float32 // Area of interest radius for observers without own one
+	BudgetPerPeer int     // Bytes of entity states replicated to a peer per run, 0 means unlimited
+	EntityCost    int     // Estimated size of a single entity state in bytes
+
+	// Send delivers spawn and despawn messages, network.Quic.SendTo is used if nil
+	Send func(peer network.PeerId, data []byte)
+
+	frame      uint32
+	grid       ecs.SpatialGrid
+	gridFrames map[ecs.Entity]uint32
+	peers      map[network.PeerId]*peerInterest
+	peerIds    []network.PeerId
+	candidates []ecs.Entity
+	message    []byte
+}
+
+type peerInterest struct {
+	frame       uint32
+	visible     map[ecs.Entity]stdcomponents.NetworkId
+	next        map[ecs.Entity]stdcomponents.NetworkId
+	accumulated map[ecs.Entity]float32
+	updates     []ecs.Entity
+	spawned     []stdcomponents.NetworkId
+	despawned   []stdcomponents.NetworkId
+}
+
+func (s *NetworkInterestSystem) Init() {
+	if s.Send == nil {
+		s.Send = func(peer network.PeerId, data []byte) {
+			if network.Quic.Mode() != network.ModeServer {
+				return
+			}
+			network.Quic.SendTo(peer, network.ChannelReliableOrdered, data)
+		}
+	}
+}
+
+func (s *NetworkInterestSystem) Run(dt time.Duration) {
+	s.frame++
+
+	// Keep grid in sync with networked entities
+	s.Networks.EachEntity(func(entity ecs.Entity) bool {
+		position := s.Positions.Get(entity)
+		if position == nil {
+			return true
+		}
+
+		x, y := float64(position.XY.X), float64(position.XY.Y)
+		if s.grid.Has(entity) {
+			s.grid.MoveEntity(entity, x, y)
+		} else {
+			s.grid.AddEntity(entity, x, y)
+		}
+		s.gridFrames[entity] = s.frame
+		return true
+	})
+	for entity, frame := range s.gridFrames {
+		if frame != s.frame {
+			s.grid.RemoveEntity(entity)
+			delete(s.gridFrames, entity)
+		}
+	}
+
+	// Collect entities around every observer
+	s.Observers.Each(func(entity ecs.Entity, observer *stdcomponents.NetworkObserver) bool {
+		position := s.Positions.Get(entity)
+		if position == nil {
+			return true
+		}
+
+		peer := s.peer(observer.Peer)
+		if peer.frame != s.frame {
+			peer.frame = s.frame
+			clear(peer.next)
+		}
+
+		radius := observer.Radius
+		if radius <= 0 {
+			radius = s.Radius
+		}
+
+		x, y, r := float64(position.XY.X), float64(position.XY.Y), float64(radius)
+		s.candidates = s.grid.QueryRect(x-r, y-r, x+r, y+r, s.candidates[:0])
+		for _, candidate := range s.candidates {
+			distance := s.Positions.Get(candidate).XY.Distance(position.XY)
+			if distance > radius {
+				continue
+			}
+
+			peer.next[candidate] = s.Networks.Get(candidate).Id
+
+			priority := float32(1)
+			if p := s.Priorities.Get(candidate); p != nil {
+				priority = p.Value
+			}
+			// Closer entities are more important
+			peer.accumulated[candidate] += priority * (1 - 0.5*distance/radius)
+		}
+		return true
+	})
+
+	for id, peer := range s.peers {
+		if peer.frame != s.frame {
+			// All observers of the peer are gone
+			clear(peer.next)
+		}
+
+		s.diff(id, peer)
+
+		if len(peer.visible) == 0 && peer.frame != s.frame {
+			delete(s.peers, id)
+			continue
+		}
+
+		s.budget(id, peer)
+	}
+}
+
+func (s *NetworkInterestSystem) Destroy() {}
+
+// Peers returns peers with an area of interest, sorted by id
+func (s *NetworkInterestSystem) Peers() []network.PeerId {
+	s.peerIds = s.peerIds[:0]
+	for id := range s.peers {
+		s.peerIds = append(s.peerIds, id)
+	}
+	slices.Sort(s.peerIds)
+	return s.peerIds
+}
+
+// Relevant returns entities which state should be replicated to the peer during this run
+func (s *NetworkInterestSystem) Relevant(peer network.PeerId) []ecs.Entity {
+	p, ok := s.peers[peer]
+	if !ok {
+		return nil
+	}
+	return p.updates
+}
+
+// IsVisible reports whether the entity is inside area of interest of the peer
+func (s *NetworkInterestSystem) IsVisible(peer network.PeerId, entity ecs.Entity) bool {
+	p, ok := s.peers[peer]
+	if !ok {
+		return false
+	}
+	_, ok = p.visible[entity]
+	return ok
+}
+
+func (s *NetworkInterestSystem) peer(id network.PeerId) *peerInterest {
+	peer, ok := s.peers[id]
+	if !ok {
+		peer = &peerInterest{
+			visible:     make(map[ecs.Entity]stdcomponents.NetworkId),
+			next:        make(map[ecs.Entity]stdcomponents.NetworkId),
+			accumulated: make(map[ecs.Entity]float32),
+		}
+		s.peers[id] = peer
+	}
+	return peer
+}
+
+func (s *NetworkInterestSystem) diff(id network.PeerId, peer *peerInterest) {
+	peer.spawned = peer.spawned[:0]
+	peer.despawned = peer.despawned[:0]
+
+	for entity, networkId := range peer.visible {
+		if _, ok := peer.next[entity]; !ok {
+			peer.despawned = append(peer.despawned, networkId)
+			delete(peer.accumulated, entity)
+		}
+	}
+	for entity, networkId := range peer.next {
+		if _, ok := peer.visible[entity]; !ok {
+			peer.spawned = append(peer.spawned, networkId)
+		}
+	}
+	peer.visible, peer.next = peer.next, peer.visible
+
+	if len(peer.despawned) > 0 {
+		slices.Sort(peer.despawned)
+		s.message = network.AppendIdsMessage(s.message[:0], network.MessageDespawn, peer.despawned)
+		s.Send(id, s.message)
+	}
+	if len(peer.spawned) > 0 {
+		slices.Sort(peer.spawned)
+		s.message = network.AppendIdsMessage(s.message[:0], network.MessageSpawn, peer.spawned)
+		s.Send(id, s.message)
+	}
+}
+
+func (s *NetworkInterestSystem) budget(id network.PeerId, peer *peerInterest) {
+	peer.updates = peer.updates[:0]
+	for entity := range peer.visible {
+		// Authority of the entity already has its true state
+		if n := s.Networks.Get(entity); n != nil && n.IsAuthority(id) {
+			continue
+		}
+		peer.updates = append(peer.updates, entity)
+	}
+
+	limit := len(peer.updates)
+	if s.BudgetPerPeer > 0 {
+		limit = min(limit, s.BudgetPerPeer/max(s.EntityCost, 1))
+	}
+
+	if limit < len(peer.updates) {
+		slices.SortFunc(peer.updates, func(a, b ecs.Entity) int {
+			return cmp.Compare(peer.accumulated[b], peer.accumulated[a])
+		})
+		peer.updates = peer.updates[:limit]
+	}
+
+	// Replicated entities start to accumulate priority from scratch
+	for _, entity := range peer.updates {
+		peer.accumulated[entity] = 0
+	}
+}
@@ -0,0 +1,144 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package stdsystems
+
+import (
+	"github.com/stretchr/testify/require"
+	"gomp/network"
+	"gomp/pkg/ecs"
+	"gomp/stdcomponents"
+	"gomp/vectors"
+	"testing"
+)
+
+type interestTestComponents struct {
+	Networks   stdcomponents.NetworkComponentManager
+	Positions  stdcomponents.PositionComponentManager
+	Rotations  stdcomponents.RotationComponentManager
+	Flips      stdcomponents.FlipComponentManager
+	Observers  stdcomponents.NetworkObserverComponentManager
+	Priorities stdcomponents.NetworkPriorityComponentManager
+}
+
+type interestTestSystems struct {
+	Interest NetworkInterestSystem
+	Send     NetworkSendSystem
+}
+
+type interestTestMessage struct {
+	peer        network.PeerId
+	messageType network.MessageType
+	ids         []stdcomponents.NetworkId
+}
+
+func TestNetworkInterest(t *testing.T) {
+	world := ecs.NewWorld(interestTestComponents{
+		Networks:   stdcomponents.NewNetworkComponentManager(),
+		Positions:  stdcomponents.NewPositionComponentManager(),
+		Rotations:  stdcomponents.NewRotationComponentManager(),
+		Flips:      stdcomponents.NewFlipComponentManager(),
+		Observers:  stdcomponents.NewNetworkObserverComponentManager(),
+		Priorities: stdcomponents.NewNetworkPriorityComponentManager(),
+	}, interestTestSystems{
+		Interest: NewNetworkInterestSystem(),
+		Send:     NewNetworkSendSystem(),
+	})
+
+	var messages []interestTestMessage
+	world.Systems.Interest.Send = func(peer network.PeerId, data []byte) {
+		messageType, ids, err := network.ReadIdsMessage[stdcomponents.NetworkId](data, nil)
+		require.NoError(t, err)
+		messages = append(messages, interestTestMessage{peer, messageType, ids})
+	}
+	patches := make(map[network.PeerId]ecs.Patch)
+	world.Systems.Send.Interest = &world.Systems.Interest
+	world.Systems.Send.Send = func(peer network.PeerId, data []byte) {
+		require.Equal(t, network.MessagePatch, network.MessageType(data[0]))
+		var patch ecs.Patch
+		require.NoError(t, patch.UnmarshalBinary(data[1:]))
+		patches[peer] = patch
+	}
+	world.Init()
+	defer world.Destroy()
+	world.Systems.Interest.Init()
+	world.Systems.Send.Init()
+
+	const peer network.PeerId = 1
+	observer := world.Entities.Create()
+	world.Components.Positions.Create(observer, stdcomponents.Position{})
+	world.Components.Observers.Create(observer, stdcomponents.NetworkObserver{Peer: peer, Radius: 100})
+
+	create := func(id stdcomponents.NetworkId, x, y float32) ecs.Entity {
+		entity := world.Entities.Create()
+		world.Components.Networks.Create(entity, stdcomponents.Network{Id: id, Owner: network.ServerPeerId})
+		world.Components.Positions.Create(entity, stdcomponents.Position{XY: vectors.Vec2{X: x, Y: y}})
+		world.Components.Rotations.Create(entity, stdcomponents.Rotation{})
+		return entity
+	}
+	near := create(10, 50, 0)
+	far := create(11, 500, 0)
+	create(12, 80, 80) // Inside the queried square, but out of the radius
+
+	run := func() {
+		messages = messages[:0]
+		clear(patches)
+		world.Systems.Interest.Run(0)
+		world.Systems.Send.Run(0)
+	}
+
+	run()
+	require.Equal(t, []interestTestMessage{{peer, network.MessageSpawn, []stdcomponents.NetworkId{10}}}, messages)
+	require.Equal(t, []ecs.Entity{near}, world.Systems.Interest.Relevant(peer))
+	require.True(t, world.Systems.Interest.IsVisible(peer, near))
+	require.False(t, world.Systems.Interest.IsVisible(peer, far))
+
+	// Peer gets a patch of its relevant entities only, identified by network ids
+	require.Len(t, patches, 1)
+	for _, componentPatch := range patches[peer] {
+		require.Equal(t, []ecs.Entity{10}, componentPatch.Patched.Entities)
+	}
+
+	// Sent components are accounted in network stats
+	var positionBytes uint64
+	for _, component := range network.Quic.Stats().Components {
+		if component.Component == uint16(stdcomponents.PositionComponentId) {
+			positionBytes = component.BytesSent
+		}
+	}
+	require.NotZero(t, positionBytes)
+
+	// One entity leaves the area, the other one enters it
+	world.Components.Positions.Get(near).XY.X = 1000
+	world.Components.Positions.Get(far).XY.X = 60
+	run()
+	require.Equal(t, []interestTestMessage{
+		{peer, network.MessageDespawn, []stdcomponents.NetworkId{10}},
+		{peer, network.MessageSpawn, []stdcomponents.NetworkId{11}},
+	}, messages)
+	require.Equal(t, []ecs.Entity{far}, world.Systems.Interest.Relevant(peer))
+
+	// Budget of a single entity goes to the most important one, others accumulate priority
+	world.Components.Positions.Get(near).XY.X = 60
+	world.Components.Priorities.Create(far, stdcomponents.NetworkPriority{Value: 1.5})
+	world.Systems.Interest.BudgetPerPeer = world.Systems.Interest.EntityCost
+	run()
+	require.Equal(t, []ecs.Entity{far}, world.Systems.Interest.Relevant(peer))
+	run()
+	require.Equal(t, []ecs.Entity{near}, world.Systems.Interest.Relevant(peer))
+	for _, componentPatch := range patches[peer] {
+		require.Equal(t, []ecs.Entity{10}, componentPatch.Patched.Entities)
+	}
+
+	// Observer is gone, everything is despawned and the peer is forgotten
+	world.Entities.Delete(observer)
+	run()
+	require.Len(t, messages, 1)
+	require.Equal(t, network.MessageDespawn, messages[0].messageType)
+	require.ElementsMatch(t, []stdcomponents.NetworkId{10, 11}, messages[0].ids)
+	require.Empty(t, world.Systems.Interest.Peers())
+	require.Empty(t, patches)
+}
@@ -14,14 +14,75 @@ Thank you for your support!
 
 package stdsystems
 
-import "time"
+import (
+	"gomp/network"
+	"gomp/pkg/ecs"
+	"gomp/stdcomponents"
+	"time"
+)
 
 func NewNetworkReceiveSystem() NetworkReceiveSystem {
-	return NetworkReceiveSystem{}
+	return NetworkReceiveSystem{
+		peers: make(map[network.PeerId]ecs.Entity),
+	}
 }
 
-type NetworkReceiveSystem struct{}
+// NetworkReceiveSystem turns peer connection events into stdcomponents.NetworkPeer proxy entities
+// and invokes handlers of received messages and RPC calls
+type NetworkReceiveSystem struct {
+	EntityManager *ecs.EntityManager
+	NetworkPeers  *stdcomponents.NetworkPeerComponentManager
 
-func (s *NetworkReceiveSystem) Init()                {}
-func (s *NetworkReceiveSystem) Run(dt time.Duration) {}
-func (s *NetworkReceiveSystem) Destroy()             {}
+	peers map[network.PeerId]ecs.Entity // Maps peers to proxy entities
+}
+
+func (s *NetworkReceiveSystem) Init() {}
+func (s *NetworkReceiveSystem) Run(dt time.Duration) {
+	// Proxies of gone peers live for exactly one tick
+	for id, proxy := range s.peers {
+		peer := s.NetworkPeers.Get(proxy)
+		if peer.State == stdcomponents.NetworkPeerStateDisconnected || peer.State == stdcomponents.NetworkPeerStateTimedOut {
+			delete(s.peers, id)
+			s.EntityManager.Delete(proxy)
+		}
+	}
+
+	for {
+		event, ok := network.Quic.PollPeerEvent()
+		if !ok {
+			break
+		}
+
+		switch event.Type {
+		case network.PeerConnected:
+			proxy := s.EntityManager.Create()
+			s.NetworkPeers.Create(proxy, stdcomponents.NetworkPeer{
+				Id:    event.Peer,
+				State: stdcomponents.NetworkPeerStateConnected,
+			})
+			s.peers[event.Peer] = proxy
+		case network.PeerDisconnected, network.PeerTimedOut:
+			proxy, ok := s.peers[event.Peer]
+			if !ok {
+				continue
+			}
+			peer := s.NetworkPeers.Get(proxy)
+			peer.State = stdcomponents.NetworkPeerStateDisconnected
+			if event.Type == network.PeerTimedOut {
+				peer.State = stdcomponents.NetworkPeerStateTimedOut
+			}
+			network.DefaultRPC.ForgetPeer(event.Peer)
+		}
+	}
+
+	network.Quic.Dispatch()
+	network.DefaultRPC.Dispatch()
+
+	for id, proxy := range s.peers {
+		peer := s.NetworkPeers.Get(proxy)
+		if peer.State == stdcomponents.NetworkPeerStateConnected {
+			peer.RTT = network.Quic.RTT(id)
+		}
+	}
+}
+func (s *NetworkReceiveSystem) Destroy() {}
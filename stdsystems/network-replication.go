@@ -0,0 +1,140 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package stdsystems
+
+import (
+	"fmt"
+	"gomp/network"
+	"gomp/pkg/ecs"
+	"gomp/stdcomponents"
+	"log"
+	"time"
+)
+
+func NewNetworkReplicationSystem() NetworkReplicationSystem {
+	return NetworkReplicationSystem{
+		entities: make(map[stdcomponents.NetworkId]ecs.Entity),
+	}
+}
+
+// NetworkReplicationSystem is the client side of NetworkInterestSystem and NetworkSendSystem.
+// Spawned network ids get local entities, patches are translated to them and applied, despawned ones are deleted.
+// Messages are handled by network.Quic.Dispatch and applied on Run.
+type NetworkReplicationSystem struct {
+	World     *ecs.EntityManager
+	Networks  *stdcomponents.NetworkComponentManager
+	Positions *stdcomponents.PositionComponentManager
+	Rotations *stdcomponents.RotationComponentManager
+	Mirroreds *stdcomponents.FlipComponentManager
+
+	// OnSpawn is called for every spawned entity, e.g. to add sprites or stdcomponents.NewInterpolation
+	OnSpawn func(entity ecs.Entity, id stdcomponents.NetworkId)
+
+	entities map[stdcomponents.NetworkId]ecs.Entity
+	received []network.Message
+	ids      []stdcomponents.NetworkId
+}
+
+func (s *NetworkReplicationSystem) Init() {
+	// Same components NetworkSendSystem replicates
+	s.Positions.TrackChanges = true
+	s.Rotations.TrackChanges = true
+	s.Mirroreds.TrackChanges = true
+
+	network.Quic.Handle(network.MessageSpawn, s.HandleMessage)
+	network.Quic.Handle(network.MessageDespawn, s.HandleMessage)
+	network.Quic.Handle(network.MessagePatch, s.HandleMessage)
+}
+
+func (s *NetworkReplicationSystem) Run(dt time.Duration) {
+	for i, msg := range s.received {
+		if err := s.apply(msg); err != nil {
+			log.Printf("Replication message from peer %d: %v", msg.Peer, err)
+		}
+		s.received[i] = network.Message{}
+	}
+	s.received = s.received[:0]
+}
+
+func (s *NetworkReplicationSystem) Destroy() {}
+
+// HandleMessage queues a spawn, despawn or patch message of the server until Run
+func (s *NetworkReplicationSystem) HandleMessage(msg network.Message) error {
+	if len(msg.Data) == 0 || msg.Peer != network.ServerPeerId {
+		return fmt.Errorf("%w: replication from peer %d", network.ErrMalformedMessage, msg.Peer)
+	}
+	s.received = append(s.received, msg)
+	return nil
+}
+
+// Entity returns the local entity of the network id
+func (s *NetworkReplicationSystem) Entity(id stdcomponents.NetworkId) (ecs.Entity, bool) {
+	entity, ok := s.entities[id]
+	return entity, ok
+}
+
+func (s *NetworkReplicationSystem) apply(msg network.Message) error {
+	if network.MessageType(msg.Data[0]) == network.MessagePatch {
+		return s.applyPatch(msg.Data[1:])
+	}
+
+	messageType, ids, err := network.ReadIdsMessage(msg.Data, s.ids[:0])
+	s.ids = ids
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		entity, ok := s.entities[id]
+		switch {
+		case messageType == network.MessageSpawn && !ok:
+			entity = s.World.Create()
+			s.Networks.Create(entity, stdcomponents.Network{Id: id})
+			s.entities[id] = entity
+			if s.OnSpawn != nil {
+				s.OnSpawn(entity, id)
+			}
+		case messageType == network.MessageDespawn && ok:
+			s.World.Delete(entity)
+			delete(s.entities, id)
+		}
+	}
+	return nil
+}
+
+func (s *NetworkReplicationSystem) applyPatch(data []byte) error {
+	var patch ecs.Patch
+	if err := patch.UnmarshalBinary(data); err != nil {
+		return err
+	}
+
+	for i := range patch {
+		for _, changes := range []*ecs.ComponentChanges{&patch[i].Created, &patch[i].Patched, &patch[i].Deleted} {
+			for j, id := range changes.Entities {
+				entity, ok := s.entities[stdcomponents.NetworkId(id)]
+				if !ok {
+					// Spawn is sent reliably and may come after the patch, the next patch has full state again
+					return nil
+				}
+				changes.Entities[j] = entity
+			}
+		}
+	}
+
+	for i := range patch {
+		network.Quic.RecordComponentReceived(uint16(patch[i].ID), patch[i].Size())
+	}
+	return s.World.PatchApply(patch)
+}
@@ -0,0 +1,128 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package stdsystems
+
+import (
+	"github.com/stretchr/testify/require"
+	"gomp/network"
+	"gomp/pkg/ecs"
+	"gomp/stdcomponents"
+	"gomp/vectors"
+	"testing"
+)
+
+type replicationTestSystems struct {
+	Interest    NetworkInterestSystem
+	Send        NetworkSendSystem
+	Replication NetworkReplicationSystem
+}
+
+func newReplicationTestWorld() *ecs.World[interestTestComponents, replicationTestSystems] {
+	world := ecs.NewWorld(interestTestComponents{
+		Networks:   stdcomponents.NewNetworkComponentManager(),
+		Positions:  stdcomponents.NewPositionComponentManager(),
+		Rotations:  stdcomponents.NewRotationComponentManager(),
+		Flips:      stdcomponents.NewFlipComponentManager(),
+		Observers:  stdcomponents.NewNetworkObserverComponentManager(),
+		Priorities: stdcomponents.NewNetworkPriorityComponentManager(),
+	}, replicationTestSystems{
+		Interest:    NewNetworkInterestSystem(),
+		Send:        NewNetworkSendSystem(),
+		Replication: NewNetworkReplicationSystem(),
+	})
+	world.Systems.Send.Interest = &world.Systems.Interest
+	world.Init()
+	return &world
+}
+
+func TestNetworkReplication(t *testing.T) {
+	server, client := newReplicationTestWorld(), newReplicationTestWorld()
+	defer server.Destroy()
+	defer client.Destroy()
+
+	// Server messages go straight to the client, spawns are reliable and patches are not
+	var spawns, patches []network.Message
+	deliver := func(peer network.PeerId, data []byte) {
+		msg := network.Message{Peer: network.ServerPeerId, Data: append([]byte(nil), data...)}
+		if network.MessageType(data[0]) == network.MessagePatch {
+			patches = append(patches, msg)
+		} else {
+			spawns = append(spawns, msg)
+		}
+	}
+	receive := func(messages []network.Message) {
+		for _, msg := range messages {
+			require.NoError(t, client.Systems.Replication.HandleMessage(msg))
+		}
+		client.Systems.Replication.Run(0)
+	}
+	server.Systems.Interest.Send = deliver
+	server.Systems.Send.Send = deliver
+	server.Systems.Interest.Init()
+	server.Systems.Send.Init()
+	var spawned []stdcomponents.NetworkId
+	client.Systems.Replication.OnSpawn = func(entity ecs.Entity, id stdcomponents.NetworkId) {
+		spawned = append(spawned, id)
+	}
+	client.Systems.Replication.Init()
+
+	const peer network.PeerId = 1
+	observer := server.Entities.Create()
+	server.Components.Positions.Create(observer, stdcomponents.Position{})
+	server.Components.Observers.Create(observer, stdcomponents.NetworkObserver{Peer: peer, Radius: 100})
+
+	// Local entities of the client must not collide with server ones
+	client.Entities.Create()
+	client.Entities.Create()
+
+	ship := server.Entities.Create()
+	server.Components.Networks.Create(ship, stdcomponents.Network{Id: 42})
+	server.Components.Positions.Create(ship, stdcomponents.Position{XY: vectors.Vec2{X: 10, Y: 20}})
+	server.Components.Rotations.Create(ship, stdcomponents.Rotation{Angle: 1})
+
+	send := func() {
+		spawns, patches = spawns[:0], patches[:0]
+		server.Systems.Interest.Run(0)
+		server.Systems.Send.Run(0)
+	}
+
+	send()
+	receive(spawns)
+	receive(patches)
+	require.Equal(t, []stdcomponents.NetworkId{42}, spawned)
+	entity, ok := client.Systems.Replication.Entity(42)
+	require.True(t, ok)
+	require.Equal(t, vectors.Vec2{X: 10, Y: 20}, client.Components.Positions.Get(entity).XY)
+	require.Equal(t, vectors.Radians(1), client.Components.Rotations.Get(entity).Angle)
+
+	// Patch arriving before its spawn is dropped, the next one brings full state
+	late := server.Entities.Create()
+	server.Components.Networks.Create(late, stdcomponents.Network{Id: 43})
+	server.Components.Positions.Create(late, stdcomponents.Position{XY: vectors.Vec2{X: 5}})
+	server.Components.Positions.Get(ship).XY.X = 30
+	send()
+	receive(patches)
+	require.Equal(t, float32(10), client.Components.Positions.Get(entity).XY.X)
+	receive(spawns)
+
+	send()
+	receive(patches)
+	require.Equal(t, float32(30), client.Components.Positions.Get(entity).XY.X)
+	lateEntity, ok := client.Systems.Replication.Entity(43)
+	require.True(t, ok)
+	require.Equal(t, float32(5), client.Components.Positions.Get(lateEntity).XY.X)
+
+	// Despawned entities are deleted on the client
+	server.Components.Positions.Get(ship).XY.X = 1000
+	send()
+	receive(spawns)
+	_, ok = client.Systems.Replication.Entity(42)
+	require.False(t, ok)
+	require.False(t, client.Components.Positions.Has(entity))
+
+	require.Error(t, client.Systems.Replication.HandleMessage(network.Message{Peer: peer, Data: []byte{byte(network.MessagePatch)}}))
+}
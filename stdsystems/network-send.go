@@ -15,7 +15,6 @@ Thank you for your support!
 package stdsystems
 
 import (
-	"fmt"
 	"gomp/network"
 	"gomp/pkg/ecs"
 	"gomp/stdcomponents"
@@ -26,52 +25,87 @@ func NewNetworkSendSystem() NetworkSendSystem {
 	return NetworkSendSystem{}
 }
 
+// NetworkSendSystem replicates state of networked entities from the server to clients.
+// Patches identify entities by stdcomponents.NetworkId, NetworkReplicationSystem maps them to client entities.
+// With Interest set every peer gets a patch of its relevant entities only,
+// otherwise every peer gets every networked entity. Should run after NetworkInterestSystem.
 type NetworkSendSystem struct {
 	World     *ecs.EntityManager
+	Networks  *stdcomponents.NetworkComponentManager
 	Positions *stdcomponents.PositionComponentManager
 	Rotations *stdcomponents.RotationComponentManager
 	Mirroreds *stdcomponents.FlipComponentManager
+
+	Interest *NetworkInterestSystem
+
+	// Send delivers patches, network.Quic.SendTo is used if nil
+	Send func(peer network.PeerId, data []byte)
+
+	entities []ecs.Entity
+	message  []byte
 }
 
 func (s *NetworkSendSystem) Init() {
 	s.Positions.TrackChanges = true
 	s.Rotations.TrackChanges = true
 	s.Mirroreds.TrackChanges = true
+	// Encoders are generated by gompcodec, see stdcomponents/codec_gen.go
 
-	s.Positions.SetEncoder(func(components []stdcomponents.Position) []byte {
-		data := make([]byte, 0)
-		for _, component := range components {
-			binary := fmt.Sprintf("%b", component.XY.X)
-			data = append(data, []byte(binary)...)
+	if s.Send == nil {
+		s.Send = func(peer network.PeerId, data []byte) {
+			if network.Quic.Mode() != network.ModeServer {
+				return
+			}
+			network.Quic.SendTo(peer, network.ChannelUnreliableSequenced, data)
 		}
+	}
+}
+func (s *NetworkSendSystem) Run(dt time.Duration) {
+	// Peers get full state of entities they are sent, so changes are not accumulated
+	defer s.World.PatchReset()
 
-		return data
-	})
-	s.Rotations.SetEncoder(func(components []stdcomponents.Rotation) []byte {
-		data := make([]byte, 0)
-		for _, component := range components {
-			binary := fmt.Sprintf("%b", component.Angle)
-			data = append(data, []byte(binary)...)
+	if s.Interest != nil {
+		for _, peer := range s.Interest.Peers() {
+			s.send(peer, s.Interest.Relevant(peer))
 		}
+		return
+	}
 
-		return data
-	})
-	s.Mirroreds.SetEncoder(func(components []stdcomponents.Flip) []byte {
-		data := make([]byte, 0)
-		for _, component := range components {
-			binary := fmt.Sprintf("%b", component.X)
-			data = append(data, []byte(binary)...)
+	if network.Quic.Mode() != network.ModeServer {
+		return
+	}
+	for _, peer := range network.Quic.Peers() {
+		s.entities = s.entities[:0]
+		s.Networks.Each(func(entity ecs.Entity, n *stdcomponents.Network) bool {
+			if !n.IsAuthority(peer) {
+				s.entities = append(s.entities, entity)
+			}
+			return true
+		})
+		s.send(peer, s.entities)
+	}
+}
+func (s *NetworkSendSystem) Destroy() {}
+
+func (s *NetworkSendSystem) send(peer network.PeerId, entities []ecs.Entity) {
+	if len(entities) == 0 {
+		return
+	}
+
+	patch := s.World.PatchGetEntities(entities)
+	if len(patch) == 0 {
+		return
+	}
+	for i := range patch {
+		for j, entity := range patch[i].Patched.Entities {
+			patch[i].Patched.Entities[j] = ecs.Entity(s.Networks.Get(entity).Id)
 		}
+	}
 
-		return data
-	})
-}
-func (s *NetworkSendSystem) Run(dt time.Duration) {
-	//patch := world.PatchGet()
-	//world.PatchReset()
-	//log.Printf("%v", patch)
-	if network.Quic.Mode() != network.ModeNone {
-		network.Quic.Send([]byte("patch"), 0)
+	s.message = append(s.message[:0], byte(network.MessagePatch))
+	s.message, _ = patch.AppendBinary(s.message)
+	s.Send(peer, s.message)
+	for i := range patch {
+		network.Quic.RecordComponentSent(uint16(patch[i].ID), patch[i].Size())
 	}
 }
-func (s *NetworkSendSystem) Destroy() {}
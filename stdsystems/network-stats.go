@@ -0,0 +1,124 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package stdsystems
+
+import (
+	"fmt"
+	"gomp/network"
+	"gomp/pkg/ecs"
+	"gomp/pkg/render"
+	"gomp/stdcomponents"
+	"time"
+)
+
+func NewNetworkStatsSystem() NetworkStatsSystem {
+	return NetworkStatsSystem{
+		Interval:  time.Second,
+		ToggleKey: render.KeyF3,
+	}
+}
+
+// NetworkStatsSystem turns network counters into per second rates of stdcomponents.NetworkStatsOverlay.
+// An overlay entity is created on Init when the world has none.
+type NetworkStatsSystem struct {
+	EntityManager *ecs.EntityManager
+	Overlays      *stdcomponents.NetworkStatsOverlayComponentManager
+
+	Interval  time.Duration // How often rates are recalculated
+	ToggleKey render.Key    // Shows and hides overlays, render.KeyNone disables toggling
+
+	// Stats is a source of counters, network.Quic.Stats is used if nil
+	Stats func() network.Stats
+
+	elapsed    time.Duration
+	previous   network.PeerStats
+	components map[uint16]network.ComponentStats
+}
+
+func (s *NetworkStatsSystem) Init() {
+	if s.Stats == nil {
+		s.Stats = network.Quic.Stats
+	}
+	s.components = make(map[uint16]network.ComponentStats)
+	if s.Overlays.Len() == 0 {
+		s.Overlays.Create(s.EntityManager.Create(), stdcomponents.NetworkStatsOverlay{})
+	}
+}
+
+func (s *NetworkStatsSystem) Run(dt time.Duration) {
+	if s.ToggleKey != render.KeyNone && isKeyPressed(s.ToggleKey) {
+		s.Overlays.EachComponent(func(overlay *stdcomponents.NetworkStatsOverlay) bool {
+			overlay.Visible = !overlay.Visible
+			return true
+		})
+	}
+
+	s.elapsed += dt
+	if s.elapsed < s.Interval {
+		return
+	}
+	seconds := s.elapsed.Seconds()
+	s.elapsed = 0
+
+	stats := s.Stats()
+	total := stats.Total()
+	previous := s.previous
+	s.previous = total
+
+	// Counters start over on reconnect
+	if total.PacketsSent < previous.PacketsSent || total.PacketsReceived < previous.PacketsReceived {
+		previous = network.PeerStats{}
+	}
+	rate := func(current, previous uint64) float64 {
+		if current < previous {
+			return 0
+		}
+		return float64(current-previous) / seconds
+	}
+
+	components := make([]network.ComponentStats, len(stats.Components))
+	for i, component := range stats.Components {
+		last := s.components[component.Component]
+		components[i] = network.ComponentStats{
+			Component:     component.Component,
+			BytesSent:     uint64(rate(component.BytesSent, last.BytesSent)),
+			BytesReceived: uint64(rate(component.BytesReceived, last.BytesReceived)),
+		}
+		s.components[component.Component] = component
+	}
+
+	s.Overlays.EachComponent(func(overlay *stdcomponents.NetworkStatsOverlay) bool {
+		overlay.Peers = len(stats.Peers)
+		overlay.RTT = total.RTT
+		overlay.Loss = total.Loss()
+		overlay.BytesSentPerSecond = rate(total.BytesSent(), previous.BytesSent())
+		overlay.BytesReceivedPerSecond = rate(total.BytesReceived(), previous.BytesReceived())
+		overlay.PacketsSentPerSecond = rate(total.PacketsSent, previous.PacketsSent)
+		overlay.PacketsReceivedPerSecond = rate(total.PacketsReceived, previous.PacketsReceived)
+		overlay.Components = components
+
+		overlay.Lines = append(overlay.Lines[:0],
+			fmt.Sprintf("Net: %d peers, RTT %d ms, loss %.1f%%", overlay.Peers, overlay.RTT.Milliseconds(), overlay.Loss*100),
+			fmt.Sprintf("Up: %.1f KB/s, %.0f pkt/s", overlay.BytesSentPerSecond/1024, overlay.PacketsSentPerSecond),
+			fmt.Sprintf("Down: %.1f KB/s, %.0f pkt/s", overlay.BytesReceivedPerSecond/1024, overlay.PacketsReceivedPerSecond),
+		)
+		for _, component := range components {
+			overlay.Lines = append(overlay.Lines, fmt.Sprintf("Component %d: up %d B/s, down %d B/s",
+				component.Component, component.BytesSent, component.BytesReceived))
+		}
+		return true
+	})
+}
+func (s *NetworkStatsSystem) Destroy() {}
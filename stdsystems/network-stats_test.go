@@ -0,0 +1,62 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package stdsystems
+
+import (
+	"github.com/stretchr/testify/require"
+	"gomp"
+	"gomp/network"
+	"gomp/pkg/ecs"
+	"gomp/pkg/render"
+	"gomp/pkg/render/softrender"
+	"gomp/stdcomponents"
+	"testing"
+)
+
+type statsTestComponents struct {
+	Overlays stdcomponents.NetworkStatsOverlayComponentManager
+}
+
+type statsTestSystems struct {
+	Stats NetworkStatsSystem
+}
+
+func TestNetworkStatsToggle(t *testing.T) {
+	world := ecs.NewWorld(statsTestComponents{
+		Overlays: stdcomponents.NewNetworkStatsOverlayComponentManager(),
+	}, statsTestSystems{Stats: NewNetworkStatsSystem()})
+	world.Systems.Stats.Stats = func() network.Stats { return network.Stats{} }
+	world.Init()
+	defer world.Destroy()
+	world.Systems.Stats.Init()
+
+	// Hotkeys are polled from any render backend set as gomp.Input
+	backend := softrender.New(1, 1)
+	gomp.Input = backend
+	defer func() { gomp.Input = nil }()
+
+	visible := func() bool {
+		visible := false
+		world.Components.Overlays.EachComponent(func(overlay *stdcomponents.NetworkStatsOverlay) bool {
+			visible = overlay.Visible
+			return false
+		})
+		return visible
+	}
+
+	world.Systems.Stats.Run(0)
+	require.False(t, visible())
+
+	backend.SetKeyDown(render.KeyF3, true)
+	world.Systems.Stats.Run(0)
+	require.True(t, visible())
+
+	// Held key is pressed once
+	backend.Present(&render.CommandList{})
+	world.Systems.Stats.Run(0)
+	require.True(t, visible())
+}
@@ -15,8 +15,10 @@ Thank you for your support!
 package stdsystems
 
 import (
-	rl "github.com/gen2brain/raylib-go/raylib"
 	"gomp/network"
+	"gomp/pkg/render"
+	"log"
+	"net/http"
 	"time"
 )
 
@@ -28,22 +30,49 @@ const (
 	Client
 )
 
+const DefaultNetworkAddr = "127.0.0.1:27015"
+
 func NewNetworkSystem() NetworkSystem {
-	return NetworkSystem{}
+	return NetworkSystem{
+		Addr: DefaultNetworkAddr,
+	}
 }
 
+// NetworkSystem hosts on P and connects on O to the Addr.
+// With Mode set it hosts or connects on its own, e.g. on dedicated servers built with the headless tag.
 type NetworkSystem struct {
+	Addr string
+	Mode NetworkMode
+
+	// StatsAddr serves network counters in Prometheus text format on /metrics when set
+	StatsAddr string
+
+	started bool
 }
 
 func (s *NetworkSystem) Init() {
+	if s.StatsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", network.StatsHandler(network.Quic.Stats))
+		go func() {
+			log.Println(http.ListenAndServe(s.StatsAddr, mux))
+		}()
+	}
 }
 func (s *NetworkSystem) Run(dt time.Duration) {
-	if rl.IsKeyPressed(rl.KeyP) {
-		network.Quic.Host("127.0.0.1:27015")
+	if network.Quic.Mode() != network.ModeNone || s.started {
+		return
 	}
 
-	if rl.IsKeyPressed(rl.KeyO) {
-		network.Quic.Connect("127.0.0.1:27015")
+	switch {
+	case s.Mode == Server || isKeyPressed(render.KeyP):
+		network.Quic.Host(s.Addr)
+	case s.Mode == Client || isKeyPressed(render.KeyO):
+		network.Quic.Connect(s.Addr)
+	default:
+		return
 	}
+	// Failed automatic start is not retried every tick
+	s.started = s.Mode != None
 }
 func (s *NetworkSystem) Destroy() {}
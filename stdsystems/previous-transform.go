@@ -0,0 +1,51 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package stdsystems
+
+import (
+	"gomp/pkg/ecs"
+	"gomp/stdcomponents"
+)
+
+func NewPreviousTransformSystem() PreviousTransformSystem {
+	return PreviousTransformSystem{}
+}
+
+// PreviousTransformSystem snapshots Position, Rotation and Scale into PreviousTransform.
+// Should run first in FixedUpdate, TextureProSystem then renders between snapshot and simulated state.
+type PreviousTransformSystem struct {
+	PreviousTransforms *stdcomponents.PreviousTransformComponentManager
+	Positions          *stdcomponents.PositionComponentManager
+	Rotations          *stdcomponents.RotationComponentManager
+	Scales             *stdcomponents.ScaleComponentManager
+}
+
+func (s *PreviousTransformSystem) Init() {}
+func (s *PreviousTransformSystem) Run() {
+	s.PreviousTransforms.EachParallel(func(entity ecs.Entity, previous *stdcomponents.PreviousTransform) bool {
+		if position := s.Positions.Get(entity); position != nil {
+			previous.Position = position.XY
+		}
+		if rotation := s.Rotations.Get(entity); rotation != nil {
+			previous.Rotation = rotation.Angle
+		}
+		if scale := s.Scales.Get(entity); scale != nil {
+			previous.Scale = scale.XY
+		}
+		previous.Valid = true
+		return true
+	})
+}
+func (s *PreviousTransformSystem) Destroy() {}
@@ -0,0 +1,198 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package stdsystems
+
+import (
+	"cmp"
+	"gomp/pkg/ecs"
+	"gomp/pkg/render"
+	"gomp/stdcomponents"
+	"slices"
+)
+
+func NewRenderSystem() RenderSystem {
+	return RenderSystem{
+		ClearColor: render.Black,
+		DebugColor: render.Color{G: 255, A: 255},
+	}
+}
+
+// RenderSystem records every RLTexturePro into Commands and appends them to Frame, or presents them to Backend without one.
+// The scene is drawn once per active Camera2D in camera Order, or in screen space when there are none.
+// Sprites outside a camera view are culled, by their AABB when they have one or else by their quad.
+// Draws are sorted by RenderLayer, then by the layer sort mode, sprites with equal order are grouped
+// by texture to form batches. Without Frame and Backend the commands are only recorded.
+type RenderSystem struct {
+	RLTexturePros *stdcomponents.RLTextureProComponentManager
+	RenderOrders  *stdcomponents.RenderOrderComponentManager
+	RenderLayers  *stdcomponents.RenderLayerComponentManager
+	Layers        *stdcomponents.RenderLayers
+	Cameras       *stdcomponents.Camera2DComponentManager
+	AABBs         *stdcomponents.AABBComponentManager
+
+	Backend    render.Backend
+	Frame      *render.CommandList // Frame of gomp.Game, the screen is cleared only while it is empty
+	ClearColor render.Color
+	DebugColor render.Color        // Bounds outline of sprites on Debug layers
+	CullMargin float32             // Grows camera views, for AABBs smaller than their sprites
+	Overlay    *render.CommandList // Screen space commands drawn over the scene, gomp.Game draws its Overlay on its own
+	Commands   render.CommandList
+	Stats      RenderStats
+
+	drawList []renderDrawEntry
+	cameras  []*stdcomponents.Camera2D
+}
+
+// RenderStats are counters of the last frame, summed over cameras
+type RenderStats struct {
+	Cameras   int
+	Drawn     int // Sprites submitted to the backend
+	Culled    int // Sprites skipped as out of view
+	DrawCalls int // Texture batches, consecutive sprites of one texture are a single call
+}
+
+type renderDrawEntry struct {
+	entity  ecs.Entity
+	layer   stdcomponents.RenderLayerId
+	z       float32
+	texture uint32
+	bounds  render.Rectangle
+}
+
+func (s *RenderSystem) Init() {}
+func (s *RenderSystem) Run() {
+	s.Stats = RenderStats{}
+
+	s.drawList = s.drawList[:0]
+	s.RLTexturePros.EachEntity(func(entity ecs.Entity) bool {
+		texturePro := s.RLTexturePros.Get(entity)
+		if texturePro.Texture == nil {
+			return true
+		}
+		entry := renderDrawEntry{entity: entity, layer: stdcomponents.WorldRenderLayer, texture: texturePro.Texture.ID}
+		renderLayer := s.RenderLayers.Get(entity)
+		if renderLayer != nil {
+			entry.layer = renderLayer.Layer
+		}
+		settings := s.Layers.Get(entry.layer)
+		if settings.Hidden {
+			return true
+		}
+		switch settings.Sort {
+		case stdcomponents.RenderSortY:
+			if renderOrder := s.RenderOrders.Get(entity); renderOrder != nil {
+				entry.z = renderOrder.CalculatedZ
+			}
+		case stdcomponents.RenderSortZ:
+			if renderLayer != nil {
+				entry.z = renderLayer.Z
+			}
+		case stdcomponents.RenderSortInsertion:
+			// Unique per entity, so batching by texture can not reorder them
+			entry.z = float32(len(s.drawList))
+		}
+		if aabb := s.AABBs.Get(entity); aabb != nil {
+			entry.bounds = render.Rectangle{X: aabb.Min.X, Y: aabb.Min.Y, Width: aabb.Max.X - aabb.Min.X, Height: aabb.Max.Y - aabb.Min.Y}
+		} else {
+			entry.bounds = texturePro.Bounds()
+		}
+		s.drawList = append(s.drawList, entry)
+		return true
+	})
+	// Stable, equal order and texture keep component order
+	slices.SortStableFunc(s.drawList, func(a, b renderDrawEntry) int {
+		if a.layer != b.layer {
+			return cmp.Compare(a.layer, b.layer)
+		}
+		if a.z != b.z {
+			return cmp.Compare(a.z, b.z)
+		}
+		return cmp.Compare(a.texture, b.texture)
+	})
+
+	s.cameras = s.cameras[:0]
+	s.Cameras.EachComponent(func(camera *stdcomponents.Camera2D) bool {
+		if camera.Active {
+			s.cameras = append(s.cameras, camera)
+		}
+		return true
+	})
+	slices.SortStableFunc(s.cameras, func(a, b *stdcomponents.Camera2D) int {
+		return cmp.Compare(a.Order, b.Order)
+	})
+	s.Stats.Cameras = len(s.cameras)
+
+	s.Commands.Reset()
+	// A scene drawn over another one keeps it visible
+	if s.Frame == nil || s.Frame.Len() == 0 {
+		s.Commands.Clear(s.ClearColor)
+	}
+	if len(s.cameras) == 0 {
+		s.drawTexturePros(nil, 0, 1)
+	}
+	for _, camera := range s.cameras {
+		renderCamera := camera.Render()
+		view := renderCamera.VisibleRect()
+		view.X -= s.CullMargin
+		view.Y -= s.CullMargin
+		view.Width += s.CullMargin * 2
+		view.Height += s.CullMargin * 2
+
+		s.Commands.BeginCamera(renderCamera)
+		if camera.Background.A != 0 {
+			s.Commands.Clear(camera.Background)
+		}
+		s.drawTexturePros(&view, camera.Layers, renderCamera.Zoom)
+		s.Commands.EndCamera()
+	}
+	if s.Overlay != nil {
+		s.Commands.Append(s.Overlay)
+	}
+
+	switch {
+	case s.Frame != nil:
+		s.Frame.Append(&s.Commands)
+	case s.Backend != nil:
+		s.Backend.Present(&s.Commands)
+	}
+}
+func (s *RenderSystem) Destroy() {}
+
+// drawTexturePros records the draw list entries on layers, view nil disables culling
+func (s *RenderSystem) drawTexturePros(view *render.Rectangle, layers stdcomponents.RenderLayerMask, zoom float32) {
+	var texture uint32
+	for i := range s.drawList {
+		entry := &s.drawList[i]
+		if !layers.Has(entry.layer) {
+			continue
+		}
+		if view != nil && !view.Intersects(entry.bounds) {
+			s.Stats.Culled++
+			continue
+		}
+		if entry.texture != texture {
+			texture = entry.texture
+			s.Stats.DrawCalls++
+		}
+		s.Stats.Drawn++
+
+		texturePro := s.RLTexturePros.Get(entry.entity)
+		s.Commands.DrawTexture(*texturePro.Texture, texturePro.Frame, texturePro.Dest, texturePro.Origin, texturePro.Rotation, texturePro.Tint)
+		if s.Layers.Get(entry.layer).Debug {
+			s.Commands.DrawRectangleLines(entry.bounds, 1/zoom, s.DebugColor)
+			texture = 0
+		}
+	}
+}
@@ -0,0 +1,473 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package stdsystems
+
+import (
+	"flag"
+	"github.com/stretchr/testify/require"
+	"gomp"
+	"gomp/pkg/ecs"
+	"gomp/pkg/render"
+	"gomp/pkg/render/softrender"
+	"gomp/stdcomponents"
+	"gomp/vectors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden images in testdata")
+
+// goldenTolerance absorbs rounding differences of blending, not misplaced pixels
+const goldenTolerance = 2
+
+type renderTestComponents struct {
+	Positions        stdcomponents.PositionComponentManager
+	Rotations        stdcomponents.RotationComponentManager
+	Scales           stdcomponents.ScaleComponentManager
+	Sprites          stdcomponents.SpriteComponentManager
+	SpriteMatrixes   stdcomponents.SpriteMatrixComponentManager
+	RLTexturePros    stdcomponents.RLTextureProComponentManager
+	Renderables      stdcomponents.RenderableComponentManager
+	RenderOrders     stdcomponents.RenderOrderComponentManager
+	AnimationPlayers stdcomponents.AnimationPlayerComponentManager
+	AnimationStates  stdcomponents.AnimationStateComponentManager
+	Flips            stdcomponents.FlipComponentManager
+	Tints            stdcomponents.TintComponentManager
+	YSorts           stdcomponents.YSortComponentManager
+	Cameras          stdcomponents.Camera2DComponentManager
+	AABBs            stdcomponents.AABBComponentManager
+	RenderLayers     stdcomponents.RenderLayerComponentManager
+	Layers           stdcomponents.RenderLayers
+	Previous         stdcomponents.PreviousTransformComponentManager
+	TimeGroups       stdcomponents.TimeGroupComponentManager
+}
+
+type renderTestSystems struct {
+	AnimationSpriteMatrix AnimationSpriteMatrixSystem
+	AnimationPlayer       AnimationPlayerSystem
+	SpriteMatrix          SpriteMatrixSystem
+	Sprite                SpriteSystem
+	YSort                 YSortSystem
+	TexturePro            TextureProSystem
+	Camera                CameraSystem
+	Render                RenderSystem
+	PreviousTransform     PreviousTransformSystem
+}
+
+type renderTest struct {
+	ecs.World[renderTestComponents, renderTestSystems]
+	backend *softrender.Backend
+	quad    render.Texture // 8x8, red, green, blue and white quarters
+	strip   render.Texture // 24x8, red, green and blue frames with a white corner
+	white   render.Texture // 8x8, tints show as is
+}
+
+func newRenderTest(t *testing.T, width, height int) *renderTest {
+	test := &renderTest{
+		World: ecs.NewWorld(renderTestComponents{
+			Positions:        stdcomponents.NewPositionComponentManager(),
+			Rotations:        stdcomponents.NewRotationComponentManager(),
+			Scales:           stdcomponents.NewScaleComponentManager(),
+			Sprites:          stdcomponents.NewSpriteComponentManager(),
+			SpriteMatrixes:   stdcomponents.NewSpriteMatrixComponentManager(),
+			RLTexturePros:    stdcomponents.NewRlTextureProComponentManager(),
+			Renderables:      stdcomponents.NewRenderableComponentManager(),
+			RenderOrders:     stdcomponents.NewRenderOrderComponentManager(),
+			AnimationPlayers: stdcomponents.NewAnimationPlayerComponentManager(),
+			AnimationStates:  stdcomponents.NewAnimationStateComponentManager(),
+			Flips:            stdcomponents.NewFlipComponentManager(),
+			Tints:            stdcomponents.NewTintComponentManager(),
+			YSorts:           stdcomponents.NewYSortComponentManager(),
+			Cameras:          stdcomponents.NewCamera2DComponentManager(),
+			AABBs:            stdcomponents.NewAABBComponentManager(),
+			RenderLayers:     stdcomponents.NewRenderLayerComponentManager(),
+			Layers:           stdcomponents.NewRenderLayers(),
+			Previous:         stdcomponents.NewPreviousTransformComponentManager(),
+			TimeGroups:       stdcomponents.NewTimeGroupComponentManager(),
+		}, renderTestSystems{
+			AnimationSpriteMatrix: NewAnimationSpriteMatrixSystem(),
+			AnimationPlayer:       NewAnimationPlayerSystem(),
+			SpriteMatrix:          NewSpriteMatrixSystem(),
+			Sprite:                NewSpriteSystem(),
+			YSort:                 NewYSortSystem(),
+			TexturePro:            NewTextureProSystem(),
+			Camera:                NewCameraSystem(),
+			Render:                NewRenderSystem(),
+			PreviousTransform:     NewPreviousTransformSystem(),
+		}),
+		backend: softrender.New(width, height),
+	}
+	test.Init()
+	t.Cleanup(test.Destroy)
+
+	test.Systems.Render.Backend = test.backend
+	test.Systems.AnimationPlayer.Init()
+
+	quad := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	fill(quad, image.Rect(0, 0, 4, 4), color.NRGBA{R: 255, A: 255})
+	fill(quad, image.Rect(4, 0, 8, 4), color.NRGBA{G: 255, A: 255})
+	fill(quad, image.Rect(0, 4, 4, 8), color.NRGBA{B: 255, A: 255})
+	fill(quad, image.Rect(4, 4, 8, 8), color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+	test.quad = test.backend.LoadTexture(quad)
+
+	strip := image.NewNRGBA(image.Rect(0, 0, 24, 8))
+	fill(strip, image.Rect(0, 0, 8, 8), color.NRGBA{R: 255, A: 255})
+	fill(strip, image.Rect(8, 0, 16, 8), color.NRGBA{G: 255, A: 255})
+	fill(strip, image.Rect(16, 0, 24, 8), color.NRGBA{B: 255, A: 255})
+	for frame := 0; frame < 3; frame++ {
+		fill(strip, image.Rect(frame*8, 0, frame*8+2, 2), color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+	}
+	test.strip = test.backend.LoadTexture(strip)
+
+	white := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	fill(white, white.Rect, color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+	test.white = test.backend.LoadTexture(white)
+
+	return test
+}
+
+// frame runs the render pipeline in the order a scene does
+func (test *renderTest) frame() *image.RGBA {
+	test.Systems.AnimationSpriteMatrix.Run()
+	test.Systems.AnimationPlayer.Run(time.Second / 60)
+	test.Systems.SpriteMatrix.Run()
+	test.Systems.Sprite.Run()
+	test.Systems.YSort.Run()
+	test.Systems.TexturePro.Run()
+	test.Systems.Camera.Run(time.Second / 60)
+	test.Systems.Render.Run()
+	return test.backend.Image()
+}
+
+func (test *renderTest) createSprite(texture *render.Texture, position, scale vectors.Vec2) ecs.Entity {
+	entity := test.Entities.Create()
+	test.Components.Positions.Create(entity, stdcomponents.Position{XY: position})
+	test.Components.Scales.Create(entity, stdcomponents.Scale{XY: scale})
+	test.Components.Sprites.Create(entity, stdcomponents.Sprite{
+		Texture: texture,
+		Frame:   render.NewRectangle(0, 0, 8, 8),
+		Tint:    render.White,
+	})
+	return entity
+}
+
+func TestRenderGoldenSprite(t *testing.T) {
+	test := newRenderTest(t, 32, 32)
+
+	test.createSprite(&test.quad, vectors.Vec2{X: 2, Y: 2}, vectors.Vec2{X: 1, Y: 1})
+
+	rotated := test.Entities.Create()
+	test.Components.Positions.Create(rotated, stdcomponents.Position{XY: vectors.Vec2{X: 22, Y: 22}})
+	test.Components.Scales.Create(rotated, stdcomponents.Scale{XY: vectors.Vec2{X: 1.5, Y: 1.5}})
+	test.Components.Rotations.Create(rotated, stdcomponents.Rotation{}.SetFromDegrees(90))
+	test.Components.Sprites.Create(rotated, stdcomponents.Sprite{
+		Texture: &test.quad,
+		Frame:   render.NewRectangle(0, 0, 8, 8),
+		Origin:  vectors.Vec2{X: 4, Y: 4},
+		Tint:    render.White,
+	})
+
+	requireGolden(t, "sprite", test.frame())
+}
+
+func TestRenderGoldenAnimation(t *testing.T) {
+	test := newRenderTest(t, 32, 16)
+
+	const instance ecs.SharedComponentInstanceId = 1
+	test.Components.SpriteMatrixes.Create(instance, stdcomponents.SpriteMatrix{
+		Texture: &test.strip,
+		FPS:     1,
+		Animations: []stdcomponents.SpriteMatrixAnimation{
+			{Name: "idle", Frame: render.NewRectangle(0, 0, 8, 8), NumOfFrames: 3, Loop: true},
+		},
+	})
+
+	players := make([]ecs.Entity, 3)
+	for i := range players {
+		players[i] = test.Entities.Create()
+		test.Components.Positions.Create(players[i], stdcomponents.Position{XY: vectors.Vec2{X: float32(i) * 10}})
+		test.Components.Scales.Create(players[i], stdcomponents.Scale{XY: vectors.Vec2{X: 1, Y: 2}})
+		test.Components.AnimationStates.Create(players[i], 0)
+		test.Components.AnimationPlayers.Create(players[i], stdcomponents.AnimationPlayer{})
+		test.Components.SpriteMatrixes.Set(players[i], instance)
+	}
+	test.frame()
+
+	// Every player is initialized with a second per frame, push each one a different number of frames ahead
+	for i, player := range players {
+		animation := test.Components.AnimationPlayers.Get(player)
+		require.Equal(t, uint8(2), animation.Last)
+		animation.ElapsedTime = animation.FrameDuration * time.Duration(i)
+	}
+
+	requireGolden(t, "animation", test.frame())
+	for i, player := range players {
+		require.Equal(t, uint8(i), test.Components.AnimationPlayers.Get(player).Current)
+	}
+}
+
+func TestRenderGoldenYSort(t *testing.T) {
+	test := newRenderTest(t, 32, 32)
+
+	// Created bottom to top, y sorting must draw the lowest on top
+	colors := []render.Color{{R: 255, A: 255}, {G: 255, A: 255}, {B: 255, A: 255}}
+	for i, c := range colors {
+		entity := test.createSprite(&test.white, vectors.Vec2{X: float32(12 - i*6), Y: float32(16 - i*6)}, vectors.Vec2{X: 2, Y: 2})
+		test.Components.Tints.Create(entity, c)
+		test.Components.YSorts.Create(entity, stdcomponents.YSort{})
+	}
+
+	requireGolden(t, "ysort", test.frame())
+}
+
+func TestRenderGoldenFlipTint(t *testing.T) {
+	test := newRenderTest(t, 32, 32)
+
+	flips := []stdcomponents.Flip{{}, {X: true}, {Y: true}, {X: true, Y: true}}
+	for i, flip := range flips {
+		entity := test.createSprite(&test.quad, vectors.Vec2{X: float32(i%2) * 16, Y: float32(i/2) * 16}, vectors.Vec2{X: 2, Y: 2})
+		test.Components.Flips.Create(entity, flip)
+		if i == len(flips)-1 {
+			test.Components.Tints.Create(entity, render.Color{R: 255, G: 128, B: 128, A: 255})
+		}
+	}
+
+	// Flips are applied every frame and must not accumulate
+	test.frame()
+	requireGolden(t, "flip-tint", test.frame())
+}
+
+func TestRenderGoldenCameras(t *testing.T) {
+	test := newRenderTest(t, 32, 32)
+
+	player := test.createSprite(&test.quad, vectors.Vec2{X: 100, Y: 100}, vectors.Vec2{X: 1, Y: 1})
+	test.createSprite(&test.white, vectors.Vec2{X: 120, Y: 100}, vectors.Vec2{X: 1, Y: 1})
+
+	// Split screen halves, the right one is rotated, and a zoomed out minimap on top
+	cameras := []stdcomponents.Camera2D{
+		{Active: true, Viewport: render.NewRectangle(0, 0, 16, 32), Target: player},
+		{Active: true, Viewport: render.NewRectangle(16, 0, 16, 32), Target: player, Rotation: 90},
+		{Active: true, Order: 1, Viewport: render.NewRectangle(20, 0, 12, 8), Position: vectors.Vec2{X: 114, Y: 104}, Zoom: 0.5, Background: render.Color{R: 64, G: 64, B: 64, A: 255}},
+	}
+	for _, camera := range cameras {
+		test.Components.Cameras.Create(test.Entities.Create(), camera)
+	}
+
+	requireGolden(t, "cameras", test.frame())
+}
+
+func TestRenderSystemCullingAndBatching(t *testing.T) {
+	test := newRenderTest(t, 32, 32)
+
+	// Interleaved textures with equal order are batched into one call per texture
+	for i := range 4 {
+		texture := &test.quad
+		if i%2 == 1 {
+			texture = &test.white
+		}
+		test.createSprite(texture, vectors.Vec2{X: float32(i * 8), Y: 0}, vectors.Vec2{X: 1, Y: 1})
+	}
+	// Far away, and one whose quad is in view but AABB is not
+	test.createSprite(&test.quad, vectors.Vec2{X: 500, Y: 500}, vectors.Vec2{X: 1, Y: 1})
+	inView := test.createSprite(&test.quad, vectors.Vec2{X: 0, Y: 16}, vectors.Vec2{X: 1, Y: 1})
+	test.Components.AABBs.Create(inView, stdcomponents.AABB{Min: vectors.Vec2{X: -100, Y: -100}, Max: vectors.Vec2{X: -90, Y: -90}})
+
+	test.Components.Cameras.Create(test.Entities.Create(), stdcomponents.Camera2D{
+		Active:   true,
+		Viewport: render.NewRectangle(0, 0, 32, 32),
+		Position: vectors.Vec2{X: 16, Y: 16},
+	})
+	test.frame()
+	require.Equal(t, RenderStats{Cameras: 1, Drawn: 4, Culled: 2, DrawCalls: 2}, test.Systems.Render.Stats)
+
+	// The margin brings the AABB into view
+	test.Systems.Render.CullMargin = 100
+	test.frame()
+	require.Equal(t, RenderStats{Cameras: 1, Drawn: 5, Culled: 1, DrawCalls: 2}, test.Systems.Render.Stats)
+
+	// Without cameras nothing is culled
+	test.Components.Cameras.EachComponent(func(camera *stdcomponents.Camera2D) bool {
+		camera.Active = false
+		return true
+	})
+	test.frame()
+	require.Equal(t, RenderStats{Drawn: 6, DrawCalls: 2}, test.Systems.Render.Stats)
+}
+
+func TestRenderGoldenLayers(t *testing.T) {
+	test := newRenderTest(t, 32, 32)
+
+	// Created in reverse of the expected order everywhere, layers must restore it
+	ui := test.createSprite(&test.white, vectors.Vec2{X: 0, Y: 0}, vectors.Vec2{X: 1, Y: 1})
+	test.Components.Tints.Create(ui, render.Color{R: 255, G: 255, A: 255})
+	test.Components.RenderLayers.Create(ui, stdcomponents.RenderLayer{Layer: stdcomponents.UIRenderLayer, Z: 1})
+	uiBelow := test.createSprite(&test.white, vectors.Vec2{X: 4, Y: 4}, vectors.Vec2{X: 1, Y: 1})
+	test.Components.Tints.Create(uiBelow, render.Color{R: 255, B: 255, A: 255})
+	test.Components.RenderLayers.Create(uiBelow, stdcomponents.RenderLayer{Layer: stdcomponents.UIRenderLayer})
+
+	for i, c := range []render.Color{{B: 255, A: 255}, {R: 255, A: 255}} {
+		entity := test.createSprite(&test.white, vectors.Vec2{X: float32(8 + i*4), Y: float32(20 - i*4)}, vectors.Vec2{X: 1, Y: 1})
+		test.Components.Tints.Create(entity, c)
+		test.Components.YSorts.Create(entity, stdcomponents.YSort{})
+	}
+	effect := test.createSprite(&test.white, vectors.Vec2{X: 22, Y: 22}, vectors.Vec2{X: 1, Y: 1})
+	test.Components.Tints.Create(effect, render.Color{G: 255, B: 255, A: 255})
+	test.Components.RenderLayers.Create(effect, stdcomponents.RenderLayer{Layer: stdcomponents.EffectsRenderLayer})
+
+	background := test.createSprite(&test.white, vectors.Vec2{X: 0, Y: 0}, vectors.Vec2{X: 4, Y: 4})
+	test.Components.Tints.Create(background, render.Color{R: 64, G: 64, B: 64, A: 255})
+	test.Components.RenderLayers.Create(background, stdcomponents.RenderLayer{Layer: stdcomponents.BackgroundRenderLayer})
+	test.Components.YSorts.Create(background, stdcomponents.YSort{})
+
+	// The world camera is scrolled, the ui camera stays at the screen origin
+	test.Components.Cameras.Create(test.Entities.Create(), stdcomponents.Camera2D{
+		Active:   true,
+		Viewport: render.NewRectangle(0, 0, 32, 32),
+		Position: vectors.Vec2{X: 20, Y: 16},
+		Layers:   stdcomponents.BackgroundRenderLayer.Mask() | stdcomponents.WorldRenderLayer.Mask() | stdcomponents.EffectsRenderLayer.Mask(),
+	})
+	test.Components.Cameras.Create(test.Entities.Create(), stdcomponents.Camera2D{
+		Active:   true,
+		Order:    1,
+		Viewport: render.NewRectangle(0, 0, 32, 32),
+		Position: vectors.Vec2{X: 16, Y: 16},
+		Layers:   stdcomponents.UIRenderLayer.Mask(),
+	})
+	test.Components.Layers[stdcomponents.EffectsRenderLayer].Debug = true
+
+	requireGolden(t, "layers", test.frame())
+	require.Equal(t, RenderStats{Cameras: 2, Drawn: 6, DrawCalls: 2}, test.Systems.Render.Stats)
+
+	test.Components.Layers[stdcomponents.UIRenderLayer].Hidden = true
+	test.frame()
+	require.Equal(t, 4, test.Systems.Render.Stats.Drawn)
+}
+
+func TestRenderInterpolation(t *testing.T) {
+	test := newRenderTest(t, 32, 32)
+
+	entity := test.createSprite(&test.white, vectors.Vec2{X: 0, Y: 0}, vectors.Vec2{X: 1, Y: 1})
+	test.Components.Rotations.Create(entity, stdcomponents.Rotation{})
+	test.Components.Previous.Create(entity, stdcomponents.PreviousTransform{})
+	texturePro := func() *stdcomponents.RLTexturePro {
+		test.frame()
+		return test.Components.RLTexturePros.Get(entity)
+	}
+
+	// Nothing snapshot yet, the current state is rendered whatever the alpha
+	test.Systems.TexturePro.Alpha = 0.5
+	test.Components.Positions.Get(entity).XY = vectors.Vec2{X: 10, Y: 20}
+	require.Equal(t, render.NewRectangle(10, 20, 8, 8), texturePro().Dest)
+
+	// A fixed update moves it, frames in between are interpolated
+	test.Systems.PreviousTransform.Run()
+	test.Components.Positions.Get(entity).XY = vectors.Vec2{X: 20, Y: 40}
+	test.Components.Scales.Get(entity).XY = vectors.Vec2{X: 3, Y: 3}
+	*test.Components.Rotations.Get(entity) = stdcomponents.Rotation{}.SetFromDegrees(90)
+
+	test.Systems.TexturePro.Alpha = 0
+	require.Equal(t, render.NewRectangle(10, 20, 8, 8), texturePro().Dest)
+	test.Systems.TexturePro.Alpha = 0.5
+	tr := texturePro()
+	require.Equal(t, render.NewRectangle(15, 30, 16, 16), tr.Dest)
+	require.InDelta(t, 45, tr.Rotation, 1e-3)
+	test.Systems.TexturePro.Alpha = 1
+	require.Equal(t, render.NewRectangle(20, 40, 24, 24), texturePro().Dest)
+}
+
+// renderTestScene renders its world into the frame of the game
+type renderTestScene struct {
+	test        *renderTest
+	id          gomp.SceneId
+	transparent bool
+}
+
+func (s *renderTestScene) Init()                                {}
+func (s *renderTestScene) Update(dt time.Duration) gomp.SceneId { return s.id }
+func (s *renderTestScene) FixedUpdate(dt time.Duration)         {}
+func (s *renderTestScene) Render(dt time.Duration)              { s.test.frame() }
+func (s *renderTestScene) Destroy()                             {}
+func (s *renderTestScene) OnEnter()                             {}
+func (s *renderTestScene) OnExit()                              {}
+func (s *renderTestScene) Id() gomp.SceneId                     { return s.id }
+func (s *renderTestScene) Transparent() bool                    { return s.transparent }
+
+// presentCounter counts the frames shown by the backend
+type presentCounter struct {
+	*softrender.Backend
+	presents int
+}
+
+func (b *presentCounter) Present(list *render.CommandList) {
+	b.presents++
+	b.Backend.Present(list)
+}
+
+func TestRenderStackedScenes(t *testing.T) {
+	base := newRenderTest(t, 32, 32)
+	base.createSprite(&base.quad, vectors.Vec2{}, vectors.Vec2{X: 1, Y: 1})
+
+	// Textures of the frame backend are drawn by both scenes
+	over := newRenderTest(t, 32, 32)
+	over.Systems.Render.ClearColor = render.Color{B: 255, A: 255}
+	over.createSprite(&base.white, vectors.Vec2{X: 8, Y: 8}, vectors.Vec2{X: 1, Y: 1})
+
+	backend := &presentCounter{Backend: base.backend}
+	game := gomp.NewGame(&renderTestScene{test: base, id: 0}, &renderTestScene{test: over, id: 1, transparent: true})
+	game.RenderSystem.Backend = backend
+	game.Init()
+	defer game.Destroy()
+	base.Systems.Render.Frame = &game.Frame
+	over.Systems.Render.Frame = &game.Frame
+
+	game.Push(1, nil)
+	game.Update(0)
+	game.Render(0)
+
+	// The transparent scene draws over the opaque one in the same frame, which is cleared once
+	require.Equal(t, 1, backend.presents)
+	frame := base.backend.Image()
+	require.Equal(t, color.RGBA{R: 255, A: 255}, frame.RGBAAt(1, 1))
+	require.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, frame.RGBAAt(9, 9))
+	require.Equal(t, color.RGBA{A: 255}, frame.RGBAAt(20, 20))
+}
+
+func requireGolden(t *testing.T, name string, got *image.RGBA) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".png")
+
+	if *updateGolden {
+		require.NoError(t, os.MkdirAll("testdata", 0o755))
+		file, err := os.Create(path)
+		require.NoError(t, err)
+		defer file.Close()
+		require.NoError(t, png.Encode(file, got))
+		return
+	}
+
+	file, err := os.Open(path)
+	require.NoError(t, err, "golden image is missing, run go test -update")
+	defer file.Close()
+	want, err := png.Decode(file)
+	require.NoError(t, err)
+
+	mismatched := softrender.Diff(want, got, goldenTolerance)
+	require.Zero(t, mismatched, "%s differs in %d pixels, run go test -update if the change is intended", path, mismatched)
+}
+
+func fill(img *image.NRGBA, rect image.Rectangle, c color.NRGBA) {
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+}
@@ -7,8 +7,8 @@ with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
 package stdsystems
 
 import (
-	rl "github.com/gen2brain/raylib-go/raylib"
 	"gomp/pkg/ecs"
+	"gomp/pkg/render"
 	"gomp/stdcomponents"
 )
 
@@ -39,12 +39,15 @@ func (s *SpriteMatrixSystem) Run() {
 				Texture: spriteMatrix.Texture, //
 				Frame:   frame,                //
 				Origin:  spriteMatrix.Origin,
-				Dest:    rl.Rectangle{X: position.XY.X, Y: position.XY.Y, Width: frame.Width, Height: frame.Height}, //
+				Tint:    render.White,                                                                                   // SpriteMatrix has no tint, Tint component overrides it
+				Dest:    render.Rectangle{X: position.XY.X, Y: position.XY.Y, Width: frame.Width, Height: frame.Height}, //
 			})
 		} else {
 			// Run spriteRender
 
 			tr.Frame = frame
+			tr.Dest.Width = frame.Width
+			tr.Dest.Height = frame.Height
 		}
 		return true
 	})
@@ -15,9 +15,10 @@ Thank you for your support!
 package stdsystems
 
 import (
-	rl "github.com/gen2brain/raylib-go/raylib"
 	"gomp/pkg/ecs"
+	"gomp/pkg/render"
 	"gomp/stdcomponents"
+	"gomp/vectors"
 )
 
 func NewSpriteSystem() SpriteSystem {
@@ -56,14 +57,15 @@ func (s *SpriteSystem) Run() {
 			s.RLTexturePros.Create(entity, stdcomponents.RLTexturePro{
 				Texture: sprite.Texture, //
 				Frame:   sprite.Frame,   //
-				Origin: rl.Vector2{
+				Origin: vectors.Vec2{
 					X: sprite.Origin.X * scale.XY.X,
 					Y: sprite.Origin.Y * scale.XY.Y,
 				},
-				Dest: rl.Rectangle{X: position.XY.X, Y: position.XY.Y, Width: sprite.Frame.Width, Height: sprite.Frame.Height}, //
+				Dest: render.Rectangle{X: position.XY.X, Y: position.XY.Y, Width: sprite.Frame.Width, Height: sprite.Frame.Height}, //
 				Tint: sprite.Tint,
 			})
 		} else {
+			tr.Frame = sprite.Frame
 			tr.Dest.Width = sprite.Frame.Width
 			tr.Dest.Height = sprite.Frame.Height
 			tr.Tint = sprite.Tint
@@ -0,0 +1,98 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package stdsystems
+
+import (
+	"gomp/pkg/ecs"
+	"gomp/stdcomponents"
+	"gomp/vectors"
+)
+
+func NewTextureProSystem() TextureProSystem {
+	return TextureProSystem{
+		Alpha: 1,
+	}
+}
+
+// TextureProSystem applies animation frame, flip, scale, rotation, position and tint to RLTexturePro.
+// It runs after SpriteSystem and SpriteMatrixSystem, they reset the base frame and size every tick.
+// Entities with a PreviousTransform are placed between it and the current state by Alpha.
+type TextureProSystem struct {
+	RLTexturePros    *stdcomponents.RLTextureProComponentManager
+	Positions        *stdcomponents.PositionComponentManager
+	Rotations        *stdcomponents.RotationComponentManager
+	Scales           *stdcomponents.ScaleComponentManager
+	AnimationPlayers *stdcomponents.AnimationPlayerComponentManager
+	Flips            *stdcomponents.FlipComponentManager
+	Tints            *stdcomponents.TintComponentManager
+
+	PreviousTransforms *stdcomponents.PreviousTransformComponentManager
+	Alpha              float32 // Interpolation alpha of the frame, see gomp.Game.InterpolationAlpha
+}
+
+func (s *TextureProSystem) Init() {}
+func (s *TextureProSystem) Run() {
+	s.RLTexturePros.EachEntityParallel(func(entity ecs.Entity) bool {
+		texturePro := s.RLTexturePros.Get(entity)
+		previous := s.PreviousTransforms.Get(entity)
+		if previous != nil && !previous.Valid {
+			previous = nil
+		}
+
+		if animation := s.AnimationPlayers.Get(entity); animation != nil {
+			if animation.Vertical {
+				texturePro.Frame.Y += texturePro.Frame.Height * float32(animation.Current)
+			} else {
+				texturePro.Frame.X += texturePro.Frame.Width * float32(animation.Current)
+			}
+		}
+		if flip := s.Flips.Get(entity); flip != nil {
+			if flip.X {
+				texturePro.Frame.Width *= -1
+			}
+			if flip.Y {
+				texturePro.Frame.Height *= -1
+			}
+		}
+		if scale := s.Scales.Get(entity); scale != nil {
+			xy := scale.XY
+			if previous != nil {
+				xy = previous.Scale.Lerp(xy, s.Alpha)
+			}
+			texturePro.Dest.Width *= xy.X
+			texturePro.Dest.Height *= xy.Y
+		}
+		if rotation := s.Rotations.Get(entity); rotation != nil {
+			angle := *rotation
+			if previous != nil {
+				angle.Angle = vectors.LerpAngle(previous.Rotation, angle.Angle, float64(s.Alpha))
+			}
+			texturePro.Rotation = float32(angle.Degrees())
+		}
+		if position := s.Positions.Get(entity); position != nil {
+			xy := position.XY
+			if previous != nil {
+				xy = previous.Position.Lerp(xy, s.Alpha)
+			}
+			texturePro.Dest.X = xy.X
+			texturePro.Dest.Y = xy.Y
+		}
+		if tint := s.Tints.Get(entity); tint != nil {
+			texturePro.Tint = *tint
+		}
+		return true
+	})
+}
+func (s *TextureProSystem) Destroy() {}
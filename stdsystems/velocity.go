@@ -7,6 +7,7 @@ with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
 package stdsystems
 
 import (
+	"gomp"
 	"gomp/pkg/ecs"
 	"gomp/stdcomponents"
 	"time"
@@ -20,6 +21,8 @@ type VelocitySystem struct {
 	Velocities  *stdcomponents.VelocityComponentManager
 	Positions   *stdcomponents.PositionComponentManager
 	RigidBodies *stdcomponents.RigidBodyComponentManager
+	TimeGroups  *stdcomponents.TimeGroupComponentManager
+	Time        *gomp.TimeControl // Group scales, nil ignores TimeGroup
 }
 
 func (s *VelocitySystem) Init() {}
@@ -31,8 +34,13 @@ func (s *VelocitySystem) Run(dt time.Duration) {
 		velocity := s.Velocities.Get(e)
 		position := s.Positions.Get(e)
 
-		position.XY.X += velocity.X * dtSec
-		position.XY.Y += velocity.Y * dtSec
+		entityDtSec := dtSec
+		if group := s.TimeGroups.Get(e); group != nil {
+			entityDtSec = float32(s.Time.GroupDt(group.Group, dt).Seconds())
+		}
+
+		position.XY.X += velocity.X * entityDtSec
+		position.XY.Y += velocity.Y * entityDtSec
 		return true
 	})
 }
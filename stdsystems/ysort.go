@@ -25,25 +25,32 @@ func NewYSortSystem() YSortSystem {
 	return YSortSystem{}
 }
 
+// YSortSystem writes RenderOrder from Y position, entities on layers not sorted by Y are skipped
 type YSortSystem struct {
 	EntityManager *ecs.EntityManager
 	YSorts        *stdcomponents.YSortComponentManager
 	Positions     *stdcomponents.PositionComponentManager
 	RenderOrders  *stdcomponents.RenderOrderComponentManager
+	RenderLayers  *stdcomponents.RenderLayerComponentManager
+	Layers        *stdcomponents.RenderLayers
 }
 
 func (s *YSortSystem) Init() {}
 func (s *YSortSystem) Run() {
 	s.YSorts.EachEntity(func(entity ecs.Entity) bool {
+		layer := stdcomponents.WorldRenderLayer
+		if renderLayer := s.RenderLayers.Get(entity); renderLayer != nil {
+			layer = renderLayer.Layer
+		}
+		if s.Layers.Get(layer).Sort != stdcomponents.RenderSortY {
+			return true
+		}
+
 		pos := s.Positions.Get(entity)
 		renderOrder := s.RenderOrders.Get(entity)
 
-		// Calculate depth based on Y position
-		yDepth := pos.XY.Y * ySortOffsetScale
-
-		// Preserve original Z layer but add Y-based offset
-		//renderOrder.CalculatedZ = float32(int(pos.Z)) + yDepth
-		renderOrder.CalculatedZ = yDepth
+		// Calculate depth based on Y position, layers are ordered by RenderSystem
+		renderOrder.CalculatedZ = pos.XY.Y * ySortOffsetScale
 
 		return true
 	})
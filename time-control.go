@@ -0,0 +1,89 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package gomp
+
+import (
+	"gomp/stdcomponents"
+	"time"
+)
+
+func NewTimeControl() TimeControl {
+	t := TimeControl{
+		Scale: 1,
+	}
+	for i := range t.groupScales {
+		t.groupScales[i] = 1
+	}
+	return t
+}
+
+// TimeControl turns real time into game time, Engine scales every dt passed to the game with it.
+// Per group scales are applied by systems to entities with a stdcomponents.TimeGroup.
+type TimeControl struct {
+	Scale  float64 // Global time scale, 1 is real time
+	Paused bool
+
+	groupScales [stdcomponents.TimeGroupCount]float64
+	steps       int
+	hitstopLeft time.Duration
+}
+
+func (t *TimeControl) TogglePause() {
+	t.Paused = !t.Paused
+}
+
+// Step advances a paused game by one fixed update
+func (t *TimeControl) Step() {
+	if t.Paused {
+		t.steps++
+	}
+}
+
+// Hitstop freezes the game for duration of real time, a running longer hitstop is kept
+func (t *TimeControl) Hitstop(duration time.Duration) {
+	t.hitstopLeft = max(t.hitstopLeft, duration)
+}
+
+func (t *TimeControl) SetGroupScale(group uint8, scale float64) {
+	t.groupScales[group] = scale
+}
+
+func (t *TimeControl) GroupScale(group uint8) float64 {
+	return t.groupScales[group]
+}
+
+// GroupDt scales game time dt by the group scale, safe on nil
+func (t *TimeControl) GroupDt(group uint8, dt time.Duration) time.Duration {
+	if t == nil {
+		return dt
+	}
+	return time.Duration(float64(dt) * t.groupScales[group])
+}
+
+// Advance turns a frame of real time into game time, a step is exactly one fixedStep
+func (t *TimeControl) Advance(real, fixedStep time.Duration) time.Duration {
+	if t.hitstopLeft > 0 {
+		t.hitstopLeft -= real
+		return 0
+	}
+	if t.Paused {
+		if t.steps > 0 {
+			t.steps--
+			return fixedStep
+		}
+		return 0
+	}
+	return time.Duration(float64(real) * t.Scale)
+}
@@ -0,0 +1,47 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+*/
+
+package gomp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeControl(t *testing.T) {
+	frame := 16 * time.Millisecond
+	step := 20 * time.Millisecond
+	control := NewTimeControl()
+
+	require.Equal(t, frame, control.Advance(frame, step))
+	control.Scale = 0.5
+	require.Equal(t, frame/2, control.Advance(frame, step))
+
+	// Steps are ignored while running, and exactly one fixed step each while paused
+	control.Step()
+	control.TogglePause()
+	require.Zero(t, control.Advance(frame, step))
+	control.Step()
+	control.Step()
+	require.Equal(t, step, control.Advance(frame, step))
+	require.Equal(t, step, control.Advance(frame, step))
+	require.Zero(t, control.Advance(frame, step))
+	control.TogglePause()
+
+	// Hitstop lasts in real time whatever the scale
+	control.Hitstop(2 * frame)
+	control.Hitstop(frame)
+	require.Zero(t, control.Advance(frame, step))
+	require.Zero(t, control.Advance(frame, step))
+	require.Equal(t, frame/2, control.Advance(frame, step))
+
+	control.SetGroupScale(1, 0.25)
+	require.Equal(t, frame/4, control.GroupDt(1, frame))
+	require.Equal(t, frame, control.GroupDt(0, frame))
+	require.Equal(t, frame, (*TimeControl)(nil).GroupDt(1, frame))
+}
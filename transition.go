@@ -0,0 +1,57 @@
+/*
+This Source Code Form is subject to the terms of the Mozilla
+Public License, v. 2.0. If a copy of the MPL was not distributed
+with this file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+===-===-===-===-===-===-===-===-===-===
+Donations during this file development:
+-===-===-===-===-===-===-===-===-===-===
+
+none :)
+
+Thank you for your support!
+*/
+
+package gomp
+
+import (
+	"gomp/pkg/render"
+	"gomp/vectors"
+	"time"
+)
+
+// Transition is an effect drawn over the screen while scenes change, the change happens halfway through
+type Transition interface {
+	Duration() time.Duration
+	// Draw records the effect at progress from 0 to 1 into Game.Overlay
+	Draw(commands *render.CommandList, screen render.Rectangle, progress float32)
+}
+
+func NewFadeTransition(length time.Duration) *FadeTransition {
+	return &FadeTransition{
+		Color:  render.Black,
+		Length: length,
+	}
+}
+
+// FadeTransition fades the screen out to Color and back in
+type FadeTransition struct {
+	Color  render.Color
+	Length time.Duration
+}
+
+func (t *FadeTransition) Duration() time.Duration {
+	return t.Length
+}
+
+func (t *FadeTransition) Draw(commands *render.CommandList, screen render.Rectangle, progress float32) {
+	cover := 1 - 2*progress
+	if cover < 0 {
+		cover = -cover
+	}
+	cover = 1 - cover
+
+	color := t.Color
+	color.A = uint8(float32(color.A) * cover)
+	commands.DrawRectangle(screen, vectors.Vec2{}, 0, color)
+}
@@ -14,4 +14,15 @@ Thank you for your support!
 
 package vectors
 
+import "math"
+
 type Radians = float64
+
+// LerpAngle interpolates between two angles along the shortest arc
+func LerpAngle(from, to Radians, t float64) Radians {
+	diff := math.Mod(to-from+math.Pi, 2*math.Pi)
+	if diff < 0 {
+		diff += 2 * math.Pi
+	}
+	return from + (diff-math.Pi)*t
+}
@@ -104,3 +104,11 @@ func (v Vec2) Dot(other Vec2) float32 {
 func (v Vec2) ToVec3() Vec3 {
 	return Vec3{v.X, v.Y, 0}
 }
+
+// Lerp linearly interpolates between v and other. Values of t above 1 extrapolate.
+func (v Vec2) Lerp(other Vec2, t float32) Vec2 {
+	return Vec2{
+		X: v.X + (other.X-v.X)*t,
+		Y: v.Y + (other.Y-v.Y)*t,
+	}
+}